@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"gh-smart-commit/pkg/git"
+	"gh-smart-commit/pkg/prompt"
+	"gh-smart-commit/pkg/ui"
+)
+
+// emptyTreeHash is git's empty tree, the parent of a root commit
+const emptyTreeHash = "4b825dc642cb6eb9a060e54bf8d69288fbee4904"
+
+// amendDiff returns everything HEAD will contain once amended: its own
+// changes plus whatever is staged now
+func amendDiff(ctx context.Context, repo git.Repository) (string, error) {
+	diff, err := repo.GetStagedDiffSince(ctx, "HEAD^")
+	if err != nil {
+		// HEAD is the root commit
+		return repo.GetStagedDiffSince(ctx, emptyTreeHash)
+	}
+	return diff, nil
+}
+
+// currentMessageFits reports whether the message being amended still passes
+// validation and mentions the files the amended commit touches
+func currentMessageFits(message, diff string) bool {
+	return prompt.ValidateCommitMessage(message) == nil &&
+		prompt.MessageMatchesFiles(message, git.DiffFiles(diff))
+}
+
+// offerKeepMessage shows the current message and asks whether to keep it.
+// With --auto-commit a fitting message is kept without asking.
+func offerKeepMessage(message string, autoCommit bool) (bool, error) {
+	formatter := ui.NewCommitMessageFormatter()
+	if ui.IsQuiet() {
+		fmt.Println(message)
+	} else {
+		fmt.Print(formatter.FormatCurrent(message))
+	}
+
+	if autoCommit {
+		return true, nil
+	}
+
+	ui.Print(formatter.FormatKeepConfirmation())
+	return askKeepMessage(os.Stdin)
+}
+
+// askKeepMessage reads the keep/regenerate answer. Keeping is the default;
+// only "r" or "regenerate" asks for a new message.
+func askKeepMessage(input io.Reader) (bool, error) {
+	response, err := bufio.NewReader(input).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return false, err
+	}
+
+	response = strings.ToLower(strings.TrimSpace(response))
+	return response != "r" && response != "regenerate", nil
+}
@@ -4,15 +4,22 @@ import (
 	"bufio"
 	"context"
 	"fmt"
+	"io/fs"
 	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
 	"runtime"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 
+	"gh-smart-commit/pkg/bashsafety"
 	"gh-smart-commit/pkg/git"
-	"gh-smart-commit/pkg/ollama"
+	"gh-smart-commit/pkg/history"
 	"gh-smart-commit/pkg/prompt"
 	"gh-smart-commit/pkg/ui"
 )
@@ -34,8 +41,9 @@ You'll be asked to confirm before executing the generated command.
 Examples:
   gh-smart-commit bash "list all Go files in this project"
   gh-smart-commit bash "find files larger than 10MB"
-  gh-smart-commit bash "create a backup of the src directory"`,
-	Args: cobra.MinimumNArgs(1),
+  gh-smart-commit bash "create a backup of the src directory"
+  gh-smart-commit bash --history
+  gh-smart-commit bash --rerun 1`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		return runBash(cmd, args)
 	},
@@ -46,17 +54,56 @@ func init() {
 
 	// Command-specific flags
 	bashCmd.Flags().Bool("dry-run", false, "Show generated command without executing")
+	bashCmd.Flags().Bool("print", false, "Print only the raw command on stdout and exit, for use in $(...)")
 	bashCmd.Flags().Bool("auto-execute", false, "Execute command without confirmation (dangerous!)")
+	bashCmd.Flags().Bool("allow-dangerous", false, "Allow --auto-execute to run commands flagged as dangerous")
+	bashCmd.Flags().Bool("explain", false, "Show a one-line explanation below the generated command")
+	bashCmd.Flags().Bool("print-prompt", false, "Print the prompt that would be sent to the model and exit")
+	bashCmd.Flags().Int("tree-depth", defaultTreeOptions.Depth, "Directory levels of the file tree to include in the prompt (0 to omit it)")
+	bashCmd.Flags().Int("tree-max-entries", defaultTreeOptions.MaxEntries, "Maximum file tree entries to include in the prompt")
+	bashCmd.Flags().Bool("history", false, "List recently generated commands and exit")
+	bashCmd.Flags().Int("history-limit", 20, "Number of commands --history lists")
+	bashCmd.Flags().Int("rerun", 0, "Run the command with this --history index again, with the same confirmation")
 }
 
 func runBash(cmd *cobra.Command, args []string) error {
-	ctx := context.Background()
+	ctx := cmd.Context()
 
 	// Get flags
 	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	printOnly, _ := cmd.Flags().GetBool("print")
 	autoExecute, _ := cmd.Flags().GetBool("auto-execute")
+	allowDangerous, _ := cmd.Flags().GetBool("allow-dangerous")
+	printPromptOnly, _ := cmd.Flags().GetBool("print-prompt")
+	explain, _ := cmd.Flags().GetBool("explain")
+	treeDepth, _ := cmd.Flags().GetInt("tree-depth")
+	treeMaxEntries, _ := cmd.Flags().GetInt("tree-max-entries")
+	showHistory, _ := cmd.Flags().GetBool("history")
+	historyLimit, _ := cmd.Flags().GetInt("history-limit")
+	rerun, _ := cmd.Flags().GetInt("rerun")
 	verbose := viper.GetBool("verbose")
 
+	// Keep stdout for the command alone
+	if printOnly {
+		ui.SetQuiet(true)
+	}
+
+	runOpts := bashRunOptions{
+		Print:          printOnly,
+		DryRun:         dryRun,
+		AutoExecute:    autoExecute,
+		AllowDangerous: allowDangerous,
+		Verbose:        verbose,
+	}
+
+	if showHistory {
+		return showBashHistory(historyLimit)
+	}
+
+	if cmd.Flags().Changed("rerun") {
+		return rerunBashCommand(ctx, rerun, runOpts)
+	}
+
 	// Join args to form the description
 	description := strings.Join(args, " ")
 	if strings.TrimSpace(description) == "" {
@@ -69,7 +116,7 @@ func runBash(cmd *cobra.Command, args []string) error {
 	}
 
 	// Gather system context
-	systemCtx, err := gatherSystemContext(ctx)
+	systemCtx, err := gatherSystemContext(ctx, configuredTreeOptions(treeDepth, treeMaxEntries))
 	if err != nil {
 		ui.ShowWarning("Failed to gather full system context: " + err.Error())
 		// Continue with partial context
@@ -80,132 +127,241 @@ func runBash(cmd *cobra.Command, args []string) error {
 	}
 
 	// Build prompt
-	builder := prompt.NewBuilder()
+	builder := newPromptBuilder()
 	promptCtx := prompt.Context{
 		Repo:        systemCtx.Repo,
 		Branch:      systemCtx.Branch,
 		Description: description,
 		SystemInfo:  systemCtx,
+		Vars:        promptVars,
+	}
+
+	templateName := "bash"
+	if explain {
+		templateName = "bash-explain"
 	}
 
-	systemPrompt, userPrompt, err := builder.Build("bash", promptCtx)
+	systemPrompt, userPrompt, err := builder.Build(templateName, promptCtx)
 	if err != nil {
 		ui.ShowError("Failed to build prompt: " + err.Error())
 		return err
 	}
 
+	if printPromptOnly {
+		printPrompt(systemPrompt, userPrompt)
+		return nil
+	}
+
 	if verbose {
 		ui.ShowInfo("Sending request to Ollama...")
 	}
 
-	// Create Ollama client
-	ollamaHost := viper.GetString("ollama.host")
-	if !strings.HasPrefix(ollamaHost, "http") {
-		ollamaHost = "http://" + ollamaHost
+	client, cfg, err := connectCommand(ctx, commandChatConfig("bash"))
+	if err != nil {
+		return err
 	}
 
-	client := ollama.NewClient(ollamaHost)
-
-	// Test connection
-	if err := client.Ping(ctx); err != nil {
-		ui.ShowError(fmt.Sprintf("Failed to connect to Ollama at %s: %s", ollamaHost, err.Error()))
+	command, explanation, err := generateBashCommand(ctx, client, cfg, systemPrompt, userPrompt, explain)
+	if err != nil {
+		if ctx.Err() == nil {
+			ui.ShowError("Failed to generate bash command: " + err.Error())
+		}
 		return err
 	}
+	if command == "" {
+		ui.ShowError("Generated command is empty")
+		return fmt.Errorf("generated command is empty")
+	}
 
-	// Prepare chat request
-	chatReq := ollama.ChatRequest{
-		Model: viper.GetString("ollama.model"),
-		Messages: []ollama.Message{
-			{Role: "system", Content: systemPrompt},
-			{Role: "user", Content: userPrompt},
-		},
-		Options: ollama.Options{
-			Temperature: float32(viper.GetFloat64("ollama.temperature")),
-		},
+	executed, err := confirmAndRun(ctx, command, explanation, systemCtx.Shell, runOpts)
+	recordBashHistory(description, command, executed)
+	return err
+}
+
+// generateBashCommand asks the model for the command the prompts describe
+// and returns it sanitized. With explain the model answers with JSON that
+// also holds the explanation.
+func generateBashCommand(ctx context.Context, client ChatClient, cfg chatConfig, systemPrompt, userPrompt string, explain bool) (command, explanation string, err error) {
+	chatReq := cfg.request(systemPrompt, userPrompt)
+	if explain {
+		chatReq.Format = "json"
 	}
 
 	// Create beautiful streaming spinner
 	spinner := ui.NewStreamingSpinner("Generating...")
 	spinner.Start()
+	defer spinner.Stop()
 
-	respChan, errChan := client.Chat(ctx, chatReq)
-
-	var responseBuilder strings.Builder
-	var streamErr error
-
-	for {
-		select {
-		case resp, ok := <-respChan:
-			if !ok {
-				goto StreamComplete
-			}
-			spinner.Update()
-			responseBuilder.WriteString(resp.Message.Content)
-
-		case err := <-errChan:
-			streamErr = err
-			goto StreamComplete
+	response, err := streamChat(ctx, client, "bash", chatReq, func(string) { spinner.Update() })
+	if err != nil {
+		return "", "", err
+	}
 
-		case <-ctx.Done():
-			return ctx.Err()
+	// The explained variant answers with the command and its rationale
+	if explain {
+		var answer prompt.CommandSchema
+		if err := prompt.DecodeStructured(response, &answer); err != nil {
+			return "", "", fmt.Errorf("failed to read the explained command: %w", err)
 		}
+		response = answer.Command
+		explanation = strings.TrimSpace(answer.Explanation)
 	}
 
-StreamComplete:
-	spinner.Stop()
+	// Clean up the generated command
+	return prompt.SanitizeBashCommand(response), explanation, nil
+}
 
-	if streamErr != nil {
-		ui.ShowError("Failed to generate bash command: " + streamErr.Error())
-		return streamErr
-	}
+// bashRunOptions decide whether and how a generated command is run
+type bashRunOptions struct {
+	Print          bool // write only the command to stdout, never run it
+	DryRun         bool
+	AutoExecute    bool
+	AllowDangerous bool
+	Verbose        bool
+}
 
-	// Clean up the generated command
-	command := prompt.SanitizeBashCommand(responseBuilder.String())
+// confirmAndRun shows command with its explanation, if any, flags it if
+// dangerous and runs it in shell once confirmed. It reports whether the
+// command was executed.
+func confirmAndRun(ctx context.Context, command, explanation, shell string, opts bashRunOptions) (bool, error) {
+	// Display the generated command beautifully, or just the command in quiet mode
+	formatter := ui.NewBashCommandFormatter()
+	if ui.IsQuiet() {
+		fmt.Println(command)
+	} else {
+		fmt.Print(formatter.FormatGenerated(command))
+	}
+	if explanation != "" {
+		ui.Print(formatter.FormatExplanation(explanation))
+	}
 
-	if command == "" {
-		ui.ShowError("Generated command is empty")
-		return fmt.Errorf("generated command is empty")
+	// Flag destructive commands before anything can run them
+	level, reasons := bashsafety.ClassifyCommandDanger(command)
+	dangerous := level == bashsafety.LevelDangerous
+	if dangerous {
+		ui.Print(formatter.FormatDanger(reasons))
 	}
 
-	// Display the generated command beautifully
-	formatter := ui.NewBashCommandFormatter()
-	fmt.Print(formatter.FormatGenerated(command))
+	if opts.Print {
+		return false, nil
+	}
 
-	if dryRun {
+	if opts.DryRun {
 		ui.ShowInfo("Dry run mode - not executing command")
-		return nil
+		return false, nil
+	}
+
+	if opts.AutoExecute && dangerous && !opts.AllowDangerous {
+		ui.ShowError("Refusing to auto-execute a dangerous command; review it or add --allow-dangerous")
+		return false, fmt.Errorf("refusing to auto-execute dangerous command")
 	}
 
-	// Ask for confirmation unless auto-execute is enabled
-	if !autoExecute {
-		fmt.Print(formatter.FormatConfirmation())
+	// Ask for confirmation unless auto-execute is enabled. Dangerous
+	// commands need the full word "yes".
+	if !opts.AutoExecute {
+		if dangerous {
+			ui.Print(formatter.FormatDangerConfirmation())
+		} else {
+			ui.Print(formatter.FormatConfirmation())
+		}
 		reader := bufio.NewReader(os.Stdin)
 		response, err := reader.ReadString('\n')
 		if err != nil {
 			ui.ShowError("Failed to read user input: " + err.Error())
-			return err
+			return false, err
 		}
 
 		response = strings.ToLower(strings.TrimSpace(response))
-		if response != "y" && response != "yes" {
+		accepted := response == "yes" || (!dangerous && response == "y")
+		if !accepted {
 			ui.ShowInfo("Command execution cancelled")
-			return nil
+			return false, nil
 		}
 	}
 
 	// Execute the command
-	if verbose {
+	if opts.Verbose {
 		ui.ShowInfo("Executing command...")
 	}
 
-	if err := runShellCommand(ctx, command); err != nil {
+	if err := runShellCommand(ctx, shell, command); err != nil {
 		ui.ShowError("Failed to execute command: " + err.Error())
+		return true, err
+	}
+
+	return true, nil
+}
+
+// showBashHistory lists the most recent generated commands
+func showBashHistory(limit int) error {
+	path, err := history.DefaultPath()
+	if err != nil {
+		ui.ShowError(err.Error())
 		return err
 	}
 
+	entries, err := history.Recent(path, limit)
+	if err != nil {
+		ui.ShowError("Failed to read history: " + err.Error())
+		return err
+	}
+
+	if len(entries) == 0 {
+		ui.ShowInfo("No commands in history yet")
+		return nil
+	}
+
+	if ui.IsQuiet() {
+		for i, entry := range entries {
+			fmt.Printf("%d\t%s\n", i+1, entry.Command)
+		}
+		return nil
+	}
+
+	fmt.Print(ui.NewBashCommandFormatter().FormatHistory(entries))
 	return nil
 }
 
+// rerunBashCommand runs a command from the history again, going through the
+// same danger check and confirmation as a freshly generated one
+func rerunBashCommand(ctx context.Context, index int, opts bashRunOptions) error {
+	path, err := history.DefaultPath()
+	if err != nil {
+		ui.ShowError(err.Error())
+		return err
+	}
+
+	entry, err := history.Get(path, index)
+	if err != nil {
+		ui.ShowError("Failed to find command: " + err.Error())
+		return err
+	}
+
+	if opts.Verbose {
+		ui.ShowInfo(fmt.Sprintf("Task: %s", entry.Description))
+	}
+
+	executed, err := confirmAndRun(ctx, entry.Command, "", userShell(), opts)
+	recordBashHistory(entry.Description, entry.Command, executed)
+	return err
+}
+
+// recordBashHistory appends a command to the history file; failures only warn
+func recordBashHistory(description, command string, executed bool) {
+	path, err := history.DefaultPath()
+	if err == nil {
+		err = history.Append(path, history.Entry{
+			Description: description,
+			Command:     command,
+			Timestamp:   time.Now(),
+			Executed:    executed,
+		})
+	}
+	if err != nil {
+		ui.ShowWarning("Failed to record command history: " + err.Error())
+	}
+}
+
 // SystemContext holds system information for command generation
 type SystemContext struct {
 	OS         string
@@ -219,8 +375,86 @@ type SystemContext struct {
 	User       string
 }
 
+// userShell returns $SHELL, or the command interpreter on Windows, which
+// has no $SHELL
+func userShell() string {
+	if shell := os.Getenv("SHELL"); shell != "" {
+		return shell
+	}
+	if runtime.GOOS == "windows" {
+		return os.Getenv("ComSpec")
+	}
+	return ""
+}
+
+// treeOptions limits how much of the file tree goes into the prompt
+type treeOptions struct {
+	Depth      int      // directory levels below the working directory
+	MaxEntries int      // total lines, before a "... (N more entries)" note
+	Ignore     []string // names or globs of files and directories to leave out
+	ShowHidden []string // dotfiles and dot directories to list anyway, e.g. ".github"
+}
+
+var defaultTreeOptions = treeOptions{Depth: 2, MaxEntries: 50}
+
+// defaultTreeIgnore is the default bash.tree_ignore
+var defaultTreeIgnore = []string{"node_modules", "vendor", "__pycache__"}
+
+// configuredTreeOptions returns tree options with the ignore lists from
+// bash.tree_ignore and bash.tree_show_hidden
+func configuredTreeOptions(depth, maxEntries int) treeOptions {
+	return treeOptions{
+		Depth:      depth,
+		MaxEntries: maxEntries,
+		Ignore:     viper.GetStringSlice("bash.tree_ignore"),
+		ShowHidden: viper.GetStringSlice("bash.tree_show_hidden"),
+	}
+}
+
+// skips reports whether the tree leaves out the entry called name. Hidden
+// entries are only skipped when hidden is set, as a repository listing
+// already honors .gitignore.
+func (t treeOptions) skips(name string, hidden bool) bool {
+	for _, pattern := range t.Ignore {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	if !hidden || !strings.HasPrefix(name, ".") {
+		return false
+	}
+	for _, shown := range t.ShowHidden {
+		if strings.TrimSuffix(shown, "/") == name {
+			return false
+		}
+	}
+	return true
+}
+
+// filterTreePaths drops the paths with a component the tree ignores
+func filterTreePaths(paths []string, tree treeOptions) []string {
+	if len(tree.Ignore) == 0 {
+		return paths
+	}
+
+	kept := paths[:0:0]
+	for _, p := range paths {
+		ignored := false
+		for _, name := range strings.Split(strings.TrimSuffix(p, "/"), "/") {
+			if tree.skips(name, false) {
+				ignored = true
+				break
+			}
+		}
+		if !ignored {
+			kept = append(kept, p)
+		}
+	}
+	return kept
+}
+
 // gatherSystemContext collects system information for the prompt
-func gatherSystemContext(ctx context.Context) (*SystemContext, error) {
+func gatherSystemContext(ctx context.Context, tree treeOptions) (*SystemContext, error) {
 	sysCtx := &SystemContext{
 		OS:   runtime.GOOS,
 		Arch: runtime.GOARCH,
@@ -238,10 +472,7 @@ func gatherSystemContext(ctx context.Context) (*SystemContext, error) {
 		sysCtx.User = user
 	}
 
-	// Get shell
-	if shell := os.Getenv("SHELL"); shell != "" {
-		sysCtx.Shell = shell
-	}
+	sysCtx.Shell = userShell()
 
 	// Check if we're in a git repository
 	repo := git.NewLocalRepo(".")
@@ -257,48 +488,165 @@ func gatherSystemContext(ctx context.Context) (*SystemContext, error) {
 		}
 	}
 
-	// Get a basic file tree (limited depth for context)
-	if fileTree, err := getFileTree(sysCtx.WorkingDir, 2); err == nil {
-		sysCtx.FileTree = fileTree
+	// Get the file tree, honoring .gitignore inside a repository
+	if tree.Depth > 0 {
+		var paths []string
+		var err error
+		if sysCtx.IsGitRepo {
+			paths, err = repo.ListFiles(ctx)
+			paths = filterTreePaths(paths, tree)
+		} else {
+			paths, err = walkFiles(sysCtx.WorkingDir, tree)
+		}
+		if err == nil {
+			sysCtx.FileTree = renderFileTree(paths, tree)
+		}
 	}
 
 	return sysCtx, nil
 }
 
-// getFileTree generates a basic file tree for context
-func getFileTree(dir string, maxDepth int) (string, error) {
-	if maxDepth <= 0 {
-		return "", nil
-	}
+// walkFiles lists the files and directories under dir down to tree.Depth
+// levels, skipping hidden entries other than tree.ShowHidden and those
+// matching tree.Ignore. Directories end in "/"
+func walkFiles(dir string, tree treeOptions) ([]string, error) {
+	var paths []string
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || p == dir {
+			return err
+		}
 
-	var result strings.Builder
-	entries, err := os.ReadDir(dir)
-	if err != nil {
-		return "", err
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+
+		if tree.skips(d.Name(), true) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if d.IsDir() {
+			paths = append(paths, rel+"/")
+			if strings.Count(rel, "/")+1 >= tree.Depth {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		paths = append(paths, rel)
+		return nil
+	})
+	return paths, err
+}
+
+// treeNode is a file or directory in a rendered file tree
+type treeNode struct {
+	name     string
+	dir      bool
+	children map[string]*treeNode
+}
+
+// renderFileTree renders paths as an indented tree, directories first, down
+// to tree.Depth levels. Paths ending in "/" are directories; directories
+// below the depth limit are listed without their contents
+func renderFileTree(paths []string, tree treeOptions) string {
+	root := &treeNode{dir: true, children: map[string]*treeNode{}}
+	for _, p := range paths {
+		node := root
+		parts := strings.Split(strings.Trim(p, "/"), "/")
+		for i, part := range parts {
+			if part == "" {
+				continue
+			}
+			child, ok := node.children[part]
+			if !ok {
+				child = &treeNode{name: part, children: map[string]*treeNode{}}
+				node.children[part] = child
+			}
+			if i < len(parts)-1 || strings.HasSuffix(p, "/") {
+				child.dir = true
+			}
+			node = child
+		}
 	}
 
-	count := 0
-	for _, entry := range entries {
-		// Skip hidden files and common ignore patterns
-		if strings.HasPrefix(entry.Name(), ".") ||
-			entry.Name() == "node_modules" ||
-			entry.Name() == "vendor" ||
-			entry.Name() == "__pycache__" {
-			continue
+	var lines []string
+	var walk func(node *treeNode, depth int)
+	walk = func(node *treeNode, depth int) {
+		children := make([]*treeNode, 0, len(node.children))
+		for _, child := range node.children {
+			children = append(children, child)
 		}
+		sort.Slice(children, func(i, j int) bool {
+			if children[i].dir != children[j].dir {
+				return children[i].dir
+			}
+			return children[i].name < children[j].name
+		})
+
+		indent := strings.Repeat("  ", depth)
+		for _, child := range children {
+			if !child.dir {
+				lines = append(lines, indent+child.name)
+				continue
+			}
+			lines = append(lines, indent+child.name+"/")
+			if depth+1 < tree.Depth {
+				walk(child, depth+1)
+			}
+		}
+	}
+	walk(root, 0)
 
-		if count > 10 { // Limit entries to avoid huge output
-			result.WriteString("... (more files)\n")
-			break
+	if tree.MaxEntries > 0 && len(lines) > tree.MaxEntries {
+		omitted := len(lines) - tree.MaxEntries
+		lines = append(lines[:tree.MaxEntries], fmt.Sprintf("... (%d more entries)", omitted))
+	}
+	if len(lines) == 0 {
+		return ""
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// runShellCommand executes command in shell, the shell the model was told
+// about, falling back to the platform default when it is unset or missing
+func runShellCommand(ctx context.Context, shell, command string) error {
+	if shell != "" {
+		if _, err := exec.LookPath(shell); err != nil {
+			ui.ShowWarning(fmt.Sprintf("Shell %s not found; falling back to the default shell", shell))
+			shell = ""
 		}
+	}
 
-		if entry.IsDir() {
-			result.WriteString(fmt.Sprintf("%s/\n", entry.Name()))
-		} else {
-			result.WriteString(fmt.Sprintf("%s\n", entry.Name()))
+	name, args := shellInvocation(shell, runtime.GOOS, command)
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+// shellInvocation returns the program and arguments that run command in
+// shell on goos. An empty shell means sh, or cmd on Windows.
+func shellInvocation(shell, goos, command string) (string, []string) {
+	if shell == "" {
+		if goos == "windows" {
+			return "cmd", []string{"/C", command}
 		}
-		count++
+		return "sh", []string{"-c", command}
 	}
 
-	return result.String(), nil
+	// Windows paths use backslashes whatever platform this runs on
+	name := strings.ToLower(path.Base(strings.ReplaceAll(shell, "\\", "/")))
+	name = strings.TrimSuffix(name, ".exe")
+	switch name {
+	case "cmd":
+		return shell, []string{"/C", command}
+	case "powershell", "pwsh":
+		return shell, []string{"-NoProfile", "-Command", command}
+	default:
+		return shell, []string{"-c", command}
+	}
 }
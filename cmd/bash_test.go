@@ -0,0 +1,117 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestShellInvocation(t *testing.T) {
+	tests := []struct {
+		shell string
+		goos  string
+		name  string
+		args  string
+	}{
+		{"", "linux", "sh", "-c ls"},
+		{"/bin/zsh", "darwin", "/bin/zsh", "-c ls"},
+		{"/usr/bin/fish", "linux", "/usr/bin/fish", "-c ls"},
+		{"", "windows", "cmd", "/C ls"},
+		{`C:\Windows\System32\cmd.exe`, "windows", `C:\Windows\System32\cmd.exe`, "/C ls"},
+		{`C:\Program Files\PowerShell\7\pwsh.exe`, "windows", `C:\Program Files\PowerShell\7\pwsh.exe`, "-NoProfile -Command ls"},
+		{"powershell", "windows", "powershell", "-NoProfile -Command ls"},
+	}
+
+	for _, tt := range tests {
+		name, args := shellInvocation(tt.shell, tt.goos, "ls")
+		if name != tt.name || strings.Join(args, " ") != tt.args {
+			t.Errorf("shellInvocation(%q, %q) = %s %v, expected %s %s", tt.shell, tt.goos, name, args, tt.name, tt.args)
+		}
+	}
+}
+
+func TestRenderFileTree(t *testing.T) {
+	paths := []string{"main.go", "cmd/root.go", "cmd/bash.go", "pkg/git/diff.go", "pkg/git/testdata/a.diff", "docs/"}
+
+	expected := "cmd/\n  bash.go\n  root.go\ndocs/\npkg/\n  git/\nmain.go\n"
+	if got := renderFileTree(paths, treeOptions{Depth: 2}); got != expected {
+		t.Errorf("Expected tree:\n%s\ngot:\n%s", expected, got)
+	}
+
+	expected = "cmd/\n  bash.go\n  root.go\ndocs/\npkg/\n  git/\n    testdata/\n    diff.go\nmain.go\n"
+	if got := renderFileTree(paths, treeOptions{Depth: 3}); got != expected {
+		t.Errorf("Expected tree:\n%s\ngot:\n%s", expected, got)
+	}
+}
+
+func TestRenderFileTreeMaxEntries(t *testing.T) {
+	paths := []string{"a.go", "b.go", "c.go", "d.go"}
+
+	expected := "a.go\nb.go\n... (2 more entries)\n"
+	if got := renderFileTree(paths, treeOptions{Depth: 1, MaxEntries: 2}); got != expected {
+		t.Errorf("Expected %q, got %q", expected, got)
+	}
+}
+
+func TestWalkFilesIgnore(t *testing.T) {
+	dir := t.TempDir()
+	for _, p := range []string{".github/workflows/ci.yml", ".env", ".cache/x", "target/app", "src/main.rs", "src/app.log"} {
+		full := filepath.Join(dir, filepath.FromSlash(p))
+		if err := os.MkdirAll(filepath.Dir(full), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(full, nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tree := treeOptions{Depth: 2, Ignore: []string{"target", "*.log"}, ShowHidden: []string{".github/", ".env"}}
+	paths, err := walkFiles(dir, tree)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := ".env,.github/,.github/workflows/,src/,src/main.rs"
+	if got := strings.Join(paths, ","); got != expected {
+		t.Errorf("Expected %s, got %s", expected, got)
+	}
+}
+
+func TestFilterTreePaths(t *testing.T) {
+	paths := []string{"build/out.o", "cmd/build.go", "docs/build/index.html", "main.go"}
+
+	got := filterTreePaths(paths, treeOptions{Ignore: []string{"build"}})
+	if strings.Join(got, ",") != "cmd/build.go,main.go" {
+		t.Errorf("Unexpected paths %v", got)
+	}
+}
+
+func TestGenerateBashCommand(t *testing.T) {
+	client := &fakeChatClient{answers: []string{"```bash\nls -la\n```"}}
+	command, explanation, err := generateBashCommand(context.Background(), client, chatConfig{}, "system", "user", false)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if command != "ls -la" || explanation != "" {
+		t.Errorf("Expected the sanitized command, got %q (%q)", command, explanation)
+	}
+
+	client = &fakeChatClient{answers: []string{`{"command": "du -sh *", "explanation": " Shows the size of each entry "}`}}
+	command, explanation, err = generateBashCommand(context.Background(), client, chatConfig{}, "system", "user", true)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if command != "du -sh *" || explanation != "Shows the size of each entry" {
+		t.Errorf("Expected the command and its explanation, got %q (%q)", command, explanation)
+	}
+	if client.requests[0].Format != "json" {
+		t.Errorf("Expected --explain to ask for JSON, got format %q", client.requests[0].Format)
+	}
+
+	client = &fakeChatClient{answers: []string{"du -sh *"}}
+	if _, _, err := generateBashCommand(context.Background(), client, chatConfig{}, "system", "user", true); err == nil {
+		t.Error("Expected an error for an explained answer that isn't JSON")
+	}
+}
@@ -0,0 +1,215 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"gh-smart-commit/pkg/bench"
+	"gh-smart-commit/pkg/git"
+	"gh-smart-commit/pkg/ollama"
+	"gh-smart-commit/pkg/prompt"
+	"gh-smart-commit/pkg/ui"
+)
+
+// benchCmd represents the bench command
+var benchCmd = &cobra.Command{
+	Use:   "bench",
+	Short: "Compare model latency on the current staged diff",
+	Long: `Run smart-commit style generation against the currently staged diff with
+one or more models and report latency percentiles, throughput and the
+message each model wrote.
+
+Each model is run --runs times. The table shows the minimum, median and
+95th percentile wall-clock latency, plus tokens per second as reported by
+Ollama's timing fields. Models are checked against the installed list
+before anything runs.
+
+Examples:
+  gh-smart-commit bench --models llama3.1:8b,qwen2.5-coder:7b --runs 5`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runBench(cmd, args)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(benchCmd)
+
+	// Command-specific flags
+	benchCmd.Flags().StringSlice("models", []string{}, "Comma-separated list of models to compare (aliases allowed; default: the smart-commit model)")
+	benchCmd.Flags().Int("runs", 3, "Number of runs per model")
+	benchCmd.Flags().Int("max-diff-lines", 500, "Maximum diff lines to include in prompt")
+}
+
+func runBench(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+
+	// Get flags
+	models, _ := cmd.Flags().GetStringSlice("models")
+	runs, _ := cmd.Flags().GetInt("runs")
+	maxDiffLines, _ := cmd.Flags().GetInt("max-diff-lines")
+	verbose := viper.GetBool("verbose")
+
+	if runs < 1 {
+		ui.ShowError("--runs must be at least 1")
+		return fmt.Errorf("invalid number of runs: %d", runs)
+	}
+
+	if len(models) == 0 {
+		models = []string{commandModel("smart-commit")}
+	}
+
+	// Initialize Git repository
+	repo := git.NewLocalRepo(".")
+
+	// Check if we're in a Git repository
+	isGit, err := repo.IsInsideWorkTree(ctx)
+	if err != nil {
+		ui.ShowError("Failed to check if inside Git repository: " + err.Error())
+		return err
+	}
+	if !isGit {
+		ui.ShowError("Not inside a Git repository")
+		return fmt.Errorf("not inside a Git repository")
+	}
+
+	// Get staged diff
+	diff, err := repo.GetStagedDiff(ctx)
+	if err != nil {
+		ui.ShowError("Failed to get staged diff: " + err.Error())
+		return err
+	}
+
+	if strings.TrimSpace(diff) == "" {
+		ui.ShowWarning("No staged changes found. Please stage your changes with 'git add' first")
+		return fmt.Errorf("no staged changes found")
+	}
+
+	if maxDiffLines > 0 {
+		diff = git.TruncateDiff(diff, maxDiffLines)
+	}
+
+	repoName, _ := repo.GetRepoName(ctx)
+	branch, _ := repo.GetCurrentBranch(ctx)
+
+	// Build prompt once so every model sees the same input
+	builder := newPromptBuilder()
+	promptCtx := prompt.Context{
+		Repo:   repoName,
+		Branch: branch,
+		Diff:   diff,
+		Vars:   promptVars,
+	}
+
+	systemPrompt, userPrompt, err := builder.Build("smart-commit", promptCtx)
+	if err != nil {
+		ui.ShowError("Failed to build prompt: " + err.Error())
+		return err
+	}
+
+	// Create Ollama client
+	client := newOllamaClient()
+
+	// Test connection
+	if err := connectOllama(ctx, client); err != nil {
+		return err
+	}
+
+	resolved := make([]string, 0, len(models))
+	for _, model := range models {
+		if model = ResolveModel(strings.TrimSpace(model)); model != "" {
+			resolved = append(resolved, model)
+		}
+	}
+
+	// Catch typos before spending minutes on the models that do exist
+	if installed, err := client.ListModels(ctx); err == nil {
+		var missing []string
+		for _, model := range resolved {
+			if !ollama.HasModel(installed, model) {
+				missing = append(missing, model)
+			}
+		}
+		if len(missing) > 0 {
+			ui.ShowError(fmt.Sprintf("Models not installed: %s (run 'ollama pull <model>')", strings.Join(missing, ", ")))
+			return fmt.Errorf("models not installed: %s", strings.Join(missing, ", "))
+		}
+	} else if verbose {
+		ui.ShowWarning("Failed to list models, skipping validation: " + err.Error())
+	}
+
+	rows := make([]ui.BenchRow, 0, len(resolved))
+	for _, model := range resolved {
+
+		chatReq := ollama.ChatRequest{
+			Model: model,
+			Messages: []ollama.Message{
+				{Role: "system", Content: systemPrompt},
+				{Role: "user", Content: userPrompt},
+			},
+			Options: ollama.Options{
+				Temperature: commandTemperature("smart-commit"),
+				NumCtx:      viper.GetInt("ollama.context_window"),
+			},
+			Think: thinkOption(),
+		}
+
+		spinner := ui.NewStreamingSpinner(ui.WithEmoji("bench", "Benchmarking "+model))
+		spinner.Start()
+
+		samples := make([]bench.Sample, 0, runs)
+		var message string
+		var runErr error
+		for i := 0; i < runs; i++ {
+			start := time.Now()
+			resp, err := client.ChatOnce(ctx, chatReq)
+			if err != nil {
+				logExchange("bench", chatReq, "", err)
+				runErr = err
+				break
+			}
+			logExchange("bench", chatReq, resp.Message.Content, nil)
+			spinner.Update()
+
+			message, _ = prompt.SplitCommitMessage(prompt.SanitizeCommitMessage(resp.Message.Content))
+
+			samples = append(samples, bench.Sample{
+				Latency:      time.Since(start),
+				EvalCount:    resp.EvalCount,
+				EvalDuration: time.Duration(resp.EvalDuration),
+			})
+		}
+
+		spinner.Stop()
+
+		// Don't record every remaining model as failed after Ctrl-C
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		row := ui.BenchRow{Model: model, Runs: len(samples)}
+		if runErr != nil {
+			row.Err = runErr.Error()
+			if verbose {
+				ui.ShowWarning(fmt.Sprintf("Benchmark of %s failed: %s", model, runErr.Error()))
+			}
+		} else {
+			summary := bench.Summarize(samples)
+			row.Min = summary.Min
+			row.Median = summary.Median
+			row.P95 = summary.P95
+			row.TokensPerSec = summary.TokensPerSec
+			row.Message = message
+		}
+
+		rows = append(rows, row)
+	}
+
+	formatter := ui.NewBenchFormatter()
+	fmt.Print(formatter.FormatTable(rows))
+
+	return nil
+}
@@ -3,11 +3,11 @@ package cmd
 import (
 	"context"
 	"fmt"
-	"gh-smart-commit/pkg/cache"
 	"gh-smart-commit/pkg/git"
-	"gh-smart-commit/pkg/ollama"
 	"gh-smart-commit/pkg/prompt"
 	"gh-smart-commit/pkg/ui"
+	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
@@ -34,26 +34,41 @@ Results are cached in .git/gh-smart-commit.cache to avoid repeated analysis.`,
 	},
 }
 
+// branchDiffLines caps the branch diff in the prompt; the commits carry most
+// of the description
+const branchDiffLines = 500
+
 func init() {
 	rootCmd.AddCommand(branchDescribeCmd)
 
 	// Command-specific flags
 	branchDescribeCmd.Flags().Int("commits", 10, "Number of recent commits to analyze")
+	branchDescribeCmd.Flags().String("since", "", "Analyze the commits since a date or duration, e.g. \"1 week ago\", 3d or 2024-05-01 (instead of --commits)")
 	branchDescribeCmd.Flags().Bool("no-cache", false, "Skip cache and regenerate description")
 	branchDescribeCmd.Flags().String("base-branch", "main", "Base branch to compare against")
 	branchDescribeCmd.Flags().Bool("include-stats", true, "Include diff statistics in analysis")
+	branchDescribeCmd.Flags().Bool("print-prompt", false, "Print the prompt that would be sent to the model and exit")
+	branchDescribeCmd.Flags().Bool("include-merges", false, "Include merge commits, whose generated subjects are skipped by default")
 }
 
 func runBranchDescribe(cmd *cobra.Command, args []string) error {
-	ctx := context.Background()
+	ctx := cmd.Context()
 
 	// Get flags
 	commitCount, _ := cmd.Flags().GetInt("commits")
 	noCache, _ := cmd.Flags().GetBool("no-cache")
 	baseBranch, _ := cmd.Flags().GetString("base-branch")
 	includeStats, _ := cmd.Flags().GetBool("include-stats")
+	printPromptOnly, _ := cmd.Flags().GetBool("print-prompt")
+	includeMerges, _ := cmd.Flags().GetBool("include-merges")
+	since, _ := cmd.Flags().GetString("since")
 	verbose := viper.GetBool("verbose")
 
+	if since != "" && cmd.Flags().Changed("commits") {
+		ui.ShowError("--since cannot be combined with --commits")
+		return fmt.Errorf("--since cannot be combined with --commits")
+	}
+
 	// Initialize Git repository
 	repo := git.NewLocalRepo(".")
 
@@ -72,30 +87,56 @@ func runBranchDescribe(cmd *cobra.Command, args []string) error {
 	repoName, _ := repo.GetRepoName(ctx)
 	currentBranch, _ := repo.GetCurrentBranch(ctx)
 
+	// A freshly initialized repository has no history to describe yet
+	hasCommits, err := repo.HasCommits(ctx)
+	if err != nil {
+		ui.ShowError("Failed to check for commits: " + err.Error())
+		return err
+	}
+	if !hasCommits {
+		ui.ShowWarning(fmt.Sprintf("Branch %s has no commits yet. Make an initial commit first, e.g. git commit -m \"Initial commit\"", currentBranch))
+		return fmt.Errorf("branch %s has no commits yet", currentBranch)
+	}
+
 	// Show context info if verbose
 	contextFormatter := ui.NewContextFormatter()
 	if info := contextFormatter.FormatRepoInfo(repoName, currentBranch, verbose); info != "" {
-		fmt.Print(info)
+		ui.Print(info)
 	}
 
 	if verbose {
-		ui.ShowInfo(fmt.Sprintf("Analyzing %d recent commits", commitCount))
+		if since != "" {
+			ui.ShowInfo(fmt.Sprintf("Analyzing commits since %s", since))
+		} else {
+			ui.ShowInfo(fmt.Sprintf("Analyzing %d recent commits", commitCount))
+		}
 		if baseBranch != "" && baseBranch != currentBranch {
 			ui.ShowInfo(fmt.Sprintf("Comparing against base branch: %s", baseBranch))
 		}
 	}
 
 	// Set up cache
-	cacheInstance := cache.NewCache(".")
+	cacheInstance := repoCache(ctx, repo)
 	cacheKey := fmt.Sprintf("branch-describe-%s-%d", currentBranch, commitCount)
+	if since != "" {
+		cacheKey = fmt.Sprintf("branch-describe-%s-since-%s", currentBranch, since)
+	}
+	if includeMerges {
+		cacheKey += "-merges"
+	}
 
 	// Try to get from cache first
-	if !noCache {
+	if !noCache && !printPromptOnly {
 		if cachedDescription, found, err := cacheInstance.Get(cacheKey); err == nil && found {
 			if verbose {
 				ui.ShowInfo("Using cached description")
 			}
 
+			if ui.IsQuiet() {
+				fmt.Println(cachedDescription)
+				return nil
+			}
+
 			formatter := ui.NewBranchFormatter()
 			output := formatter.FormatDescription(cachedDescription, true)
 			fmt.Print(output)
@@ -106,12 +147,31 @@ func runBranchDescribe(cmd *cobra.Command, args []string) error {
 	}
 
 	// Get recent commits
-	commits, err := repo.GetRecentCommits(ctx, commitCount)
+	var commits []git.Commit
+	if since != "" {
+		commits, err = repo.GetCommitsSince(ctx, gitSince(since, time.Now()))
+	} else {
+		commits, err = repo.GetRecentCommits(ctx, commitCount)
+	}
 	if err != nil {
 		ui.ShowError("Failed to get recent commits: " + err.Error())
 		return err
 	}
 
+	// Merge commits mostly carry generated "Merge branch ..." subjects
+	if !includeMerges {
+		all := len(commits)
+		commits = withoutMerges(commits)
+		if all > 0 && len(commits) == 0 {
+			ui.ShowWarning("The recent commits are all merges; use --include-merges to describe them")
+			return fmt.Errorf("only merge commits found on branch %s", currentBranch)
+		}
+	}
+
+	if len(commits) == 0 && since != "" {
+		ui.ShowWarning(fmt.Sprintf("No commits found on branch %s since %s", currentBranch, since))
+		return fmt.Errorf("no commits found on branch %s since %s", currentBranch, since)
+	}
 	if len(commits) == 0 {
 		ui.ShowWarning(fmt.Sprintf("No commits found on branch %s", currentBranch))
 		return fmt.Errorf("no commits found on branch %s", currentBranch)
@@ -123,16 +183,17 @@ func runBranchDescribe(cmd *cobra.Command, args []string) error {
 		// Show recent commits if very verbose
 		contextFormatter := ui.NewContextFormatter()
 		if commitInfo := contextFormatter.FormatCommitList(commits); commitInfo != "" {
-			fmt.Print(commitInfo)
+			ui.Print(commitInfo)
 		}
 	}
 
 	// Get branch comparison diff if base branch is specified
 	var branchDiff string
 	if baseBranch != "" && baseBranch != currentBranch {
-		// Try to get diff against base branch
-		if diff, diffErr := getBranchDiff(ctx, repo, baseBranch, currentBranch); diffErr == nil {
-			branchDiff = diff
+		// Diff HEAD rather than the branch name, which on a detached HEAD
+		// is a label and not a ref
+		if diff, diffErr := repo.GetBranchDiff(ctx, baseBranch, "HEAD"); diffErr == nil {
+			branchDiff = git.TruncateDiff(diff, branchDiffLines)
 			if verbose {
 				diffLines := len(strings.Split(branchDiff, "\n"))
 				ui.ShowInfo(fmt.Sprintf("Branch diff: %d lines", diffLines))
@@ -142,13 +203,21 @@ func runBranchDescribe(cmd *cobra.Command, args []string) error {
 		}
 	}
 
+	// Show the model dates the way date.format asks, e.g. "3 days ago"
+	for i := range commits {
+		if date := ui.FormatDate(commits[i].Time); date != "" {
+			commits[i].Date = date
+		}
+	}
+
 	// Build prompt context
-	builder := prompt.NewBuilder()
+	builder := newPromptBuilder()
 	promptCtx := prompt.Context{
 		Repo:    repoName,
 		Branch:  currentBranch,
 		Commits: commits,
 		Diff:    branchDiff,
+		Vars:    promptVars,
 	}
 
 	systemPrompt, userPrompt, err := builder.Build("branch-describe", promptCtx)
@@ -157,80 +226,32 @@ func runBranchDescribe(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	if verbose {
-		ui.ShowInfo("Sending request to Ollama...")
+	if printPromptOnly {
+		printPrompt(systemPrompt, userPrompt)
+		return nil
 	}
 
-	// Create Ollama client
-	ollamaHost := viper.GetString("ollama.host")
-	if !strings.HasPrefix(ollamaHost, "http") {
-		ollamaHost = "http://" + ollamaHost
+	if verbose {
+		ui.ShowInfo("Sending request to Ollama...")
 	}
 
-	client := ollama.NewClient(ollamaHost)
-
-	// Test connection
-	if err := client.Ping(ctx); err != nil {
-		ui.ShowError(fmt.Sprintf("Failed to connect to Ollama at %s: %s", ollamaHost, err.Error()))
+	client, cfg, err := connectCommand(ctx, commandChatConfig("branch-describe"))
+	if err != nil {
 		return err
 	}
 
-	// Prepare chat request
-	chatReq := ollama.ChatRequest{
-		Model: viper.GetString("ollama.model"),
-		Messages: []ollama.Message{
-			{Role: "system", Content: systemPrompt},
-			{Role: "user", Content: userPrompt},
-		},
-		Options: ollama.Options{
-			Temperature: float32(viper.GetFloat64("ollama.temperature")),
-		},
-	}
-
-	// Create beautiful streaming spinner
-	spinner := ui.NewStreamingSpinner("📝 Generating branch description")
-	spinner.Start()
-
-	respChan, errChan := client.Chat(ctx, chatReq)
-
-	var responseBuilder strings.Builder
-	var streamErr error
-
-	for {
-		select {
-		case resp, ok := <-respChan:
-			if !ok {
-				goto StreamComplete
-			}
-			spinner.Update()
-			responseBuilder.WriteString(resp.Message.Content)
-
-		case err := <-errChan:
-			streamErr = err
-			goto StreamComplete
-
-		case <-ctx.Done():
-			return ctx.Err()
+	description, err := generateBranchDescription(ctx, client, cfg, systemPrompt, userPrompt)
+	if err != nil {
+		if ctx.Err() == nil {
+			ui.ShowError("Failed to generate branch description: " + err.Error())
 		}
+		return err
 	}
-
-StreamComplete:
-	spinner.Stop()
-
-	if streamErr != nil {
-		ui.ShowError("Failed to generate branch description: " + streamErr.Error())
-		return streamErr
-	}
-
-	description := strings.TrimSpace(responseBuilder.String())
 	if description == "" {
 		ui.ShowWarning("No description generated")
 		return fmt.Errorf("no description generated")
 	}
 
-	// Clean up the description
-	description = cleanupDescription(description)
-
 	// Cache the result (expire after 24 hours)
 	if !noCache {
 		if err := cacheInstance.Set(cacheKey, description, 24*time.Hour); err != nil && verbose {
@@ -238,27 +259,50 @@ StreamComplete:
 		}
 	}
 
-	// Display the description beautifully
+	// Display the description beautifully, or just the description in quiet mode
 	formatter := ui.NewBranchFormatter()
-	output := formatter.FormatDescription(description, false)
-	fmt.Print(output)
+	if ui.IsQuiet() {
+		fmt.Println(description)
+	} else {
+		output := formatter.FormatDescription(description, false)
+		fmt.Print(output)
+	}
 
 	// Show summary stats if requested
 	if includeStats {
 		if stats := getStatsString(ctx, repo, baseBranch, currentBranch); stats != "" {
 			statsOutput := formatter.FormatStats(stats)
-			fmt.Print(statsOutput)
+			ui.Print(statsOutput)
 		}
 	}
 
 	return nil
 }
 
-// getBranchDiff gets the diff between two branches
-func getBranchDiff(ctx context.Context, repo *git.LocalRepo, baseBranch, targetBranch string) (string, error) {
-	// For now, we'll use a simple approach - this could be enhanced to use git diff branch..branch
-	// But since our git package doesn't have this yet, we'll skip it for this implementation
-	return "", fmt.Errorf("branch diff not implemented yet")
+// generateBranchDescription asks the model for the branch description the
+// prompts describe and returns it cleaned up, or "" when the model gave none
+func generateBranchDescription(ctx context.Context, client ChatClient, cfg chatConfig, systemPrompt, userPrompt string) (string, error) {
+	// Create beautiful streaming spinner
+	spinner := ui.NewStreamingSpinner(ui.WithEmoji("summary", "Generating branch description"))
+	spinner.Start()
+	defer spinner.Stop()
+
+	// Count words over everything so far; chunks can split a word
+	var streamed strings.Builder
+	response, err := streamChat(ctx, client, "branch-describe", cfg.request(systemPrompt, userPrompt), func(chunk string) {
+		streamed.WriteString(chunk)
+		words := len(strings.Fields(streamed.String()))
+		spinner.UpdateMessage(ui.WithEmoji("summary", fmt.Sprintf("Generating branch description (%d words)", words)))
+	})
+	if err != nil {
+		return "", err
+	}
+
+	description := prompt.StripReasoning(response)
+	if description == "" {
+		return "", nil
+	}
+	return cleanupDescription(description), nil
 }
 
 // cleanupDescription cleans up the AI-generated description
@@ -306,3 +350,35 @@ func getStatsString(ctx context.Context, repo *git.LocalRepo, baseBranch, curren
 	return fmt.Sprintf("%d commits, %d files changed, +%d/-%d lines",
 		len(commits), totalFiles, totalAdditions, totalDeletions)
 }
+
+// withoutMerges returns commits without the merge commits
+func withoutMerges(commits []git.Commit) []git.Commit {
+	kept := make([]git.Commit, 0, len(commits))
+	for _, c := range commits {
+		if !c.IsMerge() {
+			kept = append(kept, c)
+		}
+	}
+	return kept
+}
+
+// sinceShorthandPattern matches --since shorthands such as "3d" or "2w",
+// which git doesn't understand on its own
+var sinceShorthandPattern = regexp.MustCompile(`^(\d+)([dw])$`)
+
+// gitSince turns a --since duration such as "36h", "3d" or "2w" into a
+// timestamp for git log. Anything else, like "2024-05-01" or "last monday",
+// is left for git to parse.
+func gitSince(since string, now time.Time) string {
+	if match := sinceShorthandPattern.FindStringSubmatch(since); match != nil {
+		days, _ := strconv.Atoi(match[1])
+		if match[2] == "w" {
+			days *= 7
+		}
+		return now.AddDate(0, 0, -days).Format(time.RFC3339)
+	}
+	if d, err := time.ParseDuration(since); err == nil && d > 0 {
+		return now.Add(-d).Format(time.RFC3339)
+	}
+	return since
+}
@@ -0,0 +1,96 @@
+package cmd
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGitSince(t *testing.T) {
+	now := time.Date(2024, 5, 15, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		since string
+		want  string
+	}{
+		{"3d", "2024-05-12T12:00:00Z"},
+		{"2w", "2024-05-01T12:00:00Z"},
+		{"36h", "2024-05-14T00:00:00Z"},
+		{"1 week ago", "1 week ago"},
+		{"2024-05-01", "2024-05-01"},
+	}
+
+	for _, tt := range tests {
+		if got := gitSince(tt.since, now); got != tt.want {
+			t.Errorf("gitSince(%q) = %q, expected %q", tt.since, got, tt.want)
+		}
+	}
+}
+
+func TestGenerateBranchDescription(t *testing.T) {
+	client := &fakeChatClient{answers: []string{"This branch: adds a cache for model answers"}}
+
+	description, err := generateBranchDescription(context.Background(), client, chatConfig{Model: "llama3.1:8b"}, "system", "user")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if description != "Adds a cache for model answers" {
+		t.Errorf("Expected the cleaned up description, got %q", description)
+	}
+
+	client = &fakeChatClient{answers: []string{"<think>Nothing to say</think>"}}
+	if description, _ := generateBranchDescription(context.Background(), client, chatConfig{}, "system", "user"); description != "" {
+		t.Errorf("Expected no description, got %q", description)
+	}
+}
+
+func TestBranchDescribeDetachedHead(t *testing.T) {
+	dir := newTestRepo(t)
+	base := gitIn(t, dir, "branch", "--show-current")
+	gitIn(t, dir, "commit", "-q", "-m", "Add main function")
+	gitIn(t, dir, "checkout", "-q", "--detach")
+	writeTestFile(t, filepath.Join(dir, "feature.go"), "package main\n\nfunc detachedFeature() {}\n")
+	gitIn(t, dir, "add", "feature.go")
+	gitIn(t, dir, "commit", "-q", "-m", "Add detached feature")
+
+	output := captureStdout(t, func() {
+		err := runCommandWith(t, branchDescribeCmd, runBranchDescribe, map[string]string{
+			"base-branch":  base,
+			"print-prompt": "true",
+			"no-cache":     "true",
+		}, "")
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	})
+
+	if !strings.Contains(output, "func detachedFeature()") {
+		t.Errorf("Expected the branch diff in the prompt on a detached HEAD, got:\n%s", output)
+	}
+}
+
+// captureStdout returns what fn writes to standard output
+func captureStdout(t *testing.T, fn func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldStdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = oldStdout }()
+
+	output := make(chan string)
+	go func() {
+		data, _ := io.ReadAll(r)
+		output <- string(data)
+	}()
+
+	fn()
+	w.Close()
+	return <-output
+}
@@ -0,0 +1,155 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"gh-smart-commit/pkg/git"
+	"gh-smart-commit/pkg/prompt"
+	"gh-smart-commit/pkg/ui"
+)
+
+// changelogCmd represents the changelog command
+var changelogCmd = &cobra.Command{
+	Use:   "changelog",
+	Short: "Draft a changelog section from commit history",
+	Long: `Collect the commits between two refs, group them by Conventional Commits
+type (or by keywords when no type is present) and draft a Keep a Changelog
+section for them.
+
+Examples:
+  gh-smart-commit changelog --from v1.1.0
+  gh-smart-commit changelog --from v1.1.0 --to v1.2.0 > notes.md`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runChangelog(cmd, args)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(changelogCmd)
+
+	// Command-specific flags
+	changelogCmd.Flags().String("from", "", "Tag or ref to start from (exclusive)")
+	changelogCmd.Flags().String("to", "HEAD", "Ref to end at (inclusive)")
+	changelogCmd.MarkFlagRequired("from")
+}
+
+func runChangelog(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+
+	// Get flags
+	from, _ := cmd.Flags().GetString("from")
+	to, _ := cmd.Flags().GetString("to")
+	verbose := viper.GetBool("verbose")
+
+	// Initialize Git repository
+	repo := git.NewLocalRepo(".")
+
+	// Check if we're in a Git repository
+	isGit, err := repo.IsInsideWorkTree(ctx)
+	if err != nil {
+		ui.ShowError("Failed to check if inside Git repository: " + err.Error())
+		return err
+	}
+	if !isGit {
+		ui.ShowError("Not inside a Git repository")
+		return fmt.Errorf("not inside a Git repository")
+	}
+
+	commits, err := repo.GetCommitsInRange(ctx, from, to)
+	if err != nil {
+		ui.ShowError("Failed to get commits: " + err.Error())
+		return err
+	}
+
+	if len(commits) == 0 {
+		ui.ShowWarning(fmt.Sprintf("No commits found between %s and %s", from, to))
+		return fmt.Errorf("no commits found between %s and %s", from, to)
+	}
+
+	groups := prompt.GroupCommits(commits)
+	if len(groups) == 0 {
+		ui.ShowWarning("Only merge and housekeeping commits found; nothing to put in a changelog")
+		return fmt.Errorf("no changelog-worthy commits found")
+	}
+
+	repoName, _ := repo.GetRepoName(ctx)
+
+	if verbose {
+		ui.ShowInfo(fmt.Sprintf("Found %d commits between %s and %s", len(commits), from, to))
+		for _, group := range groups {
+			ui.ShowInfo(fmt.Sprintf("%s: %d commits", group.Name, len(group.Commits)))
+		}
+	}
+
+	// A release heading only makes sense for a named ref
+	release := to
+	if to == "HEAD" {
+		release = "Unreleased"
+	}
+
+	// Build prompt
+	builder := newPromptBuilder()
+	promptCtx := prompt.Context{
+		Repo:    repoName,
+		Groups:  groups,
+		Release: release,
+		Vars:    promptVars,
+	}
+
+	systemPrompt, userPrompt, err := builder.Build("changelog", promptCtx)
+	if err != nil {
+		ui.ShowError("Failed to build prompt: " + err.Error())
+		return err
+	}
+
+	if verbose {
+		ui.ShowInfo("Sending request to Ollama...")
+	}
+
+	client, cfg, err := connectCommand(ctx, commandChatConfig("changelog"))
+	if err != nil {
+		return err
+	}
+
+	changelog, err := generateChangelog(ctx, client, cfg, systemPrompt, userPrompt)
+	if err != nil {
+		if ctx.Err() == nil {
+			ui.ShowError("Failed to generate changelog: " + err.Error())
+		}
+		return err
+	}
+	if changelog == "" {
+		ui.ShowWarning("No changelog generated")
+		return fmt.Errorf("no changelog generated")
+	}
+
+	// Display the changelog, or just the markdown in quiet mode
+	if ui.IsQuiet() {
+		fmt.Println(changelog)
+		return nil
+	}
+
+	formatter := ui.NewChangelogFormatter()
+	fmt.Print(formatter.FormatChangelog(changelog, from, to, len(commits)))
+
+	return nil
+}
+
+// generateChangelog asks the model for the changelog section the prompts
+// describe and returns it without any reasoning
+func generateChangelog(ctx context.Context, client ChatClient, cfg chatConfig, systemPrompt, userPrompt string) (string, error) {
+	// Create beautiful streaming spinner
+	spinner := ui.NewStreamingSpinner(ui.WithEmoji("changelog", "Drafting changelog"))
+	spinner.Start()
+	defer spinner.Stop()
+
+	response, err := streamChat(ctx, client, "changelog", cfg.request(systemPrompt, userPrompt), func(string) { spinner.Update() })
+	if err != nil {
+		return "", err
+	}
+	return prompt.StripReasoning(response), nil
+}
@@ -0,0 +1,283 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strings"
+
+	"github.com/spf13/viper"
+
+	"gh-smart-commit/pkg/debuglog"
+	"gh-smart-commit/pkg/ollama"
+	"gh-smart-commit/pkg/ui"
+)
+
+// ChatClient is the part of *ollama.Client that commands generate text with.
+// Command logic takes a ChatClient so tests can use an httptest-backed
+// client or a fake instead of a live server.
+type ChatClient interface {
+	Ping(ctx context.Context) error
+	Chat(ctx context.Context, req ollama.ChatRequest) (<-chan ollama.ChatResponse, <-chan error)
+	ChatComplete(ctx context.Context, req ollama.ChatRequest) (string, error)
+}
+
+// ollamaHosts returns the configured hosts in the order they are tried:
+// ollama.hosts when set, otherwise ollama.host, which may be a
+// comma-separated list. An ollama.host given by flag or environment beats
+// ollama.hosts from a config file. Hosts without a scheme get http://.
+func ollamaHosts() []string {
+	configured := viper.GetStringSlice("ollama.hosts")
+	if source := configSource("ollama.host"); len(configured) == 0 || source == "flag" || source == "env" {
+		configured = strings.Split(viper.GetString("ollama.host"), ",")
+	}
+
+	var hosts []string
+	for _, host := range configured {
+		host = strings.TrimSpace(host)
+		if host == "" {
+			continue
+		}
+		if !strings.HasPrefix(host, "http") {
+			host = "http://" + host
+		}
+		hosts = append(hosts, host)
+	}
+	return hosts
+}
+
+// newOllamaClient creates a chat client for the configured hosts using the
+// configured API kind. connectOllama picks the host it talks to.
+func newOllamaClient() *ollama.FailoverClient {
+	return ollama.NewFailoverClient(ollamaHosts(),
+		ollama.WithAPIKind(viper.GetString("api.kind")),
+		ollama.WithAPIKey(viper.GetString("api.key")),
+		ollama.WithEmbedModel(viper.GetString("ollama.embed_model")),
+		ollama.WithKeepAlive(viper.GetString("ollama.keep_alive")),
+		ollama.WithStallTimeout(viper.GetDuration("ollama.stall_timeout")),
+	)
+}
+
+// newHelperClient creates a client for work done before a command connects,
+// such as summarizing an oversized diff. With several hosts it pings them
+// quietly to pick one; a failure shows on the first request instead.
+func newHelperClient(ctx context.Context) *ollama.FailoverClient {
+	client := newOllamaClient()
+	if len(ollamaHosts()) > 1 {
+		_ = client.Ping(ctx)
+	}
+	return client
+}
+
+// commandTemperature returns the temperature a command should use: an
+// explicit --temperature wins, then commands.<command>.temperature, then
+// ollama.temperature. Values outside [0, 1] are clamped with a warning.
+func commandTemperature(command string) float32 {
+	key := "ollama.temperature"
+	if !rootCmd.PersistentFlags().Changed("temperature") && viper.IsSet("commands."+command+".temperature") {
+		key = "commands." + command + ".temperature"
+	}
+
+	temperature := viper.GetFloat64(key)
+	if temperature < 0 || temperature > 1 {
+		clamped := math.Max(0, math.Min(1, temperature))
+		ui.ShowWarning(fmt.Sprintf("%s is %g, outside 0 to 1; using %g", key, temperature, clamped))
+		temperature = clamped
+	}
+	return float32(temperature)
+}
+
+// connectOllama selects the first configured host that is reachable and
+// runs Ollama before a command sends it work, showing the error if none is.
+// With --verbose the selected host and its server version are shown.
+func connectOllama(ctx context.Context, client *ollama.FailoverClient) error {
+	if err := client.Ping(ctx); err != nil {
+		if hosts := ollamaHosts(); len(hosts) > 1 {
+			ui.ShowError("Failed to connect to any Ollama host:\n" + err.Error())
+		} else {
+			ui.ShowError(fmt.Sprintf("Failed to connect to Ollama at %s: %s", strings.Join(hosts, ""), err.Error()))
+		}
+		return err
+	}
+
+	if viper.GetBool("verbose") {
+		if version, err := client.ServerVersion(ctx); err == nil && version != "" {
+			ui.ShowInfo(fmt.Sprintf("Connected to Ollama %s at %s", version, client.Host()))
+		} else {
+			ui.ShowInfo("Connected to " + client.Host())
+		}
+	}
+	return nil
+}
+
+// ResolveModel maps an alias from ollama.aliases to the model it stands for.
+// Names that aren't aliases are returned unchanged.
+func ResolveModel(name string) string {
+	// viper lower-cases map keys, so aliases are matched case-insensitively
+	if model := viper.GetStringMapString("ollama.aliases")[strings.ToLower(name)]; model != "" {
+		return model
+	}
+	return name
+}
+
+// commandModel returns the model a command should use: an explicit --model
+// wins, then commands.<command>.model, then ollama.model
+func commandModel(command string) string {
+	name := viper.GetString("ollama.model")
+	if !rootCmd.PersistentFlags().Changed("model") {
+		if model := viper.GetString("commands." + command + ".model"); model != "" {
+			name = model
+		}
+	}
+	return ResolveModel(name)
+}
+
+// modelLister lists the models installed on the server
+type modelLister interface {
+	ListModels(ctx context.Context) ([]ollama.Model, error)
+}
+
+// modelPickerInput is where the model picker reads answers from
+var modelPickerInput io.Reader = os.Stdin
+
+// ensureModel returns model when it is installed. Otherwise, when stdin is
+// a terminal, the user picks one of the installed models for this run and
+// can save it as ollama.model; elsewhere it fails with a hint to pull the
+// model. If the models can't be listed, or the server lists none, model is
+// returned unchanged and the request itself reports any problem.
+func ensureModel(ctx context.Context, lister modelLister, model string) (string, error) {
+	installed, err := lister.ListModels(ctx)
+	if err != nil || len(installed) == 0 || ollama.HasModel(installed, model) {
+		return model, nil
+	}
+
+	if !ui.IsInteractive() {
+		return "", fmt.Errorf("model %s is not installed; run 'ollama pull %s'", model, model)
+	}
+
+	names := make([]string, len(installed))
+	for i, m := range installed {
+		names[i] = m.Name
+	}
+
+	input := bufio.NewReader(modelPickerInput)
+	choice, err := ui.PickModel(model, names, input)
+	if err != nil {
+		return "", err
+	}
+
+	save, err := ui.AskSaveModel(choice, input)
+	if err != nil {
+		return "", err
+	}
+	if save {
+		path, err := configFilePath()
+		if err == nil {
+			err = writeConfigValue(path, "ollama.model", choice)
+		}
+		if err != nil {
+			ui.ShowWarning("Failed to save the model: " + err.Error())
+		} else {
+			ui.ShowSuccess(fmt.Sprintf("Set ollama.model = %s in %s", choice, path))
+		}
+	}
+
+	return choice, nil
+}
+
+// printPrompt writes the messages that would be sent to the model to stdout
+func printPrompt(systemPrompt, userPrompt string) {
+	fmt.Print(ui.NewDebugFormatter().FormatPrompt(systemPrompt, userPrompt))
+}
+
+// thinkOption returns the configured ollama.think setting, or nil when it is
+// unset so the model keeps its default behaviour
+func thinkOption() *bool {
+	if !viper.IsSet("ollama.think") {
+		return nil
+	}
+	think := viper.GetBool("ollama.think")
+	return &think
+}
+
+// chatConfig is the model configuration a command generates with. RunE
+// resolves it from flags and config, so the generation logic it passes it to
+// doesn't read viper.
+type chatConfig struct {
+	Model         string
+	Temperature   float32
+	Think         *bool
+	ContextWindow int // ollama.context_window, sent as num_ctx so Ollama doesn't cut the prompt to its own default
+}
+
+// commandChatConfig resolves the chat configuration of command
+func commandChatConfig(command string) chatConfig {
+	return chatConfig{
+		Model:         commandModel(command),
+		Temperature:   commandTemperature(command),
+		Think:         thinkOption(),
+		ContextWindow: viper.GetInt("ollama.context_window"),
+	}
+}
+
+// request builds a chat request for the system and user prompts
+func (c chatConfig) request(systemPrompt, userPrompt string) ollama.ChatRequest {
+	return ollama.ChatRequest{
+		Model: c.Model,
+		Messages: []ollama.Message{
+			{Role: "system", Content: systemPrompt},
+			{Role: "user", Content: userPrompt},
+		},
+		Options: ollama.Options{
+			Temperature: c.Temperature,
+			NumCtx:      c.ContextWindow,
+		},
+		Think: c.Think,
+	}
+}
+
+// connectCommand connects to Ollama and returns cfg with its model matched
+// against the installed ones
+func connectCommand(ctx context.Context, cfg chatConfig) (*ollama.FailoverClient, chatConfig, error) {
+	client := newOllamaClient()
+	if err := connectOllama(ctx, client); err != nil {
+		return nil, cfg, err
+	}
+
+	model, err := ensureModel(ctx, client, cfg.Model)
+	if err != nil {
+		ui.ShowError(err.Error())
+		return nil, cfg, err
+	}
+	cfg.Model = model
+	return client, cfg, nil
+}
+
+// streamChat streams chatReq, calling onChunk with every chunk, and logs the
+// exchange under command. It returns the whole response, or what arrived
+// before an error along with the error.
+func streamChat(ctx context.Context, client ChatClient, command string, chatReq ollama.ChatRequest, onChunk func(string)) (string, error) {
+	respChan, errChan := client.Chat(ctx, chatReq)
+	response, err := ollama.CollectStream(ctx, respChan, errChan, onChunk)
+	if ctx.Err() != nil {
+		return "", ctx.Err()
+	}
+	logExchange(command, chatReq, response, err)
+	return response, err
+}
+
+// logExchange appends a chat request and its raw response to the debug log
+// when --log-file (debug.log_file) is set
+func logExchange(command string, req ollama.ChatRequest, response string, err error) {
+	path := viper.GetString("debug.log_file")
+	if path == "" {
+		return
+	}
+
+	if logErr := debuglog.Append(path, debuglog.NewEntry(command, req, response, err)); logErr != nil {
+		ui.ShowWarning("Failed to write debug log: " + logErr.Error())
+	}
+}
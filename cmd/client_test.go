@@ -0,0 +1,207 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+
+	"gh-smart-commit/pkg/ollama"
+)
+
+// setConfig overrides a viper key for the duration of a test
+func setConfig(t *testing.T, key string, value interface{}) {
+	old := viper.Get(key)
+	viper.Set(key, value)
+	t.Cleanup(func() { viper.Set(key, old) })
+}
+
+// fakeChatClient answers each chat with the next of answers, repeating the
+// last, and records the requests it gets
+type fakeChatClient struct {
+	answers  []string
+	requests []ollama.ChatRequest
+}
+
+func (f *fakeChatClient) Ping(ctx context.Context) error {
+	return nil
+}
+
+func (f *fakeChatClient) Chat(ctx context.Context, req ollama.ChatRequest) (<-chan ollama.ChatResponse, <-chan error) {
+	respChan := make(chan ollama.ChatResponse, 1)
+	errChan := make(chan error)
+	respChan <- ollama.ChatResponse{Message: ollama.Message{Content: f.answer(req)}, Done: true}
+	close(respChan)
+	close(errChan)
+	return respChan, errChan
+}
+
+func (f *fakeChatClient) ChatComplete(ctx context.Context, req ollama.ChatRequest) (string, error) {
+	return f.answer(req), nil
+}
+
+func (f *fakeChatClient) answer(req ollama.ChatRequest) string {
+	f.requests = append(f.requests, req)
+	if len(f.requests) <= len(f.answers) {
+		return f.answers[len(f.requests)-1]
+	}
+	return f.answers[len(f.answers)-1]
+}
+
+func TestChatConfigRequest(t *testing.T) {
+	think := false
+	cfg := chatConfig{Model: "llama3.1:8b", Temperature: 0.2, Think: &think, ContextWindow: 16384}
+
+	req := cfg.request("You write changelogs.", "Commits:")
+	if req.Model != "llama3.1:8b" || req.Options.Temperature != 0.2 || req.Think != &think || req.Options.NumCtx != 16384 {
+		t.Errorf("Expected the configuration in the request, got %+v", req)
+	}
+	if len(req.Messages) != 2 || req.Messages[0].Role != "system" || req.Messages[1].Content != "Commits:" {
+		t.Errorf("Expected the system and user prompts, got %+v", req.Messages)
+	}
+}
+
+func TestCommandChatConfigContextWindow(t *testing.T) {
+	setConfig(t, "ollama.context_window", 16384)
+
+	if got := commandChatConfig("changelog").request("system", "user").Options.NumCtx; got != 16384 {
+		t.Errorf("Expected num_ctx from ollama.context_window, got %d", got)
+	}
+}
+
+func TestGenerateChangelog(t *testing.T) {
+	client := &fakeChatClient{answers: []string{"<think>Group them</think>\n## [Unreleased]\n### Added\n- Caching"}}
+
+	changelog, err := generateChangelog(context.Background(), client, chatConfig{Model: "llama3.1:8b"}, "system", "user")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if changelog != "## [Unreleased]\n### Added\n- Caching" {
+		t.Errorf("Expected the changelog without reasoning, got %q", changelog)
+	}
+	if len(client.requests) != 1 || client.requests[0].Model != "llama3.1:8b" {
+		t.Errorf("Expected one request for the configured model, got %+v", client.requests)
+	}
+}
+
+func TestResolveModel(t *testing.T) {
+	setConfig(t, "ollama.aliases", map[string]interface{}{
+		"reviewer": "qwen2.5-coder:32b",
+		"fast":     "llama3.2:3b",
+	})
+
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"reviewer", "qwen2.5-coder:32b"},
+		{"Fast", "llama3.2:3b"},
+		{"mistral:7b", "mistral:7b"},
+	}
+
+	for _, tt := range tests {
+		if got := ResolveModel(tt.name); got != tt.want {
+			t.Errorf("ResolveModel(%q) = %q, expected %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestCommandModel(t *testing.T) {
+	setConfig(t, "ollama.model", "fast")
+	setConfig(t, "ollama.aliases", map[string]interface{}{
+		"reviewer": "qwen2.5-coder:32b",
+		"fast":     "llama3.2:3b",
+	})
+	setConfig(t, "commands.lint-suggestions.model", "reviewer")
+
+	if got := commandModel("lint-suggestions"); got != "qwen2.5-coder:32b" {
+		t.Errorf("Expected the per-command alias to resolve, got %q", got)
+	}
+	if got := commandModel("smart-commit"); got != "llama3.2:3b" {
+		t.Errorf("Expected the global model to be used, got %q", got)
+	}
+}
+
+func TestCommandTemperature(t *testing.T) {
+	setConfig(t, "ollama.temperature", 0.3)
+	setConfig(t, "commands.branch-describe.temperature", 0.7)
+	setConfig(t, "commands.bash.temperature", 1.5)
+
+	if got := commandTemperature("branch-describe"); got != 0.7 {
+		t.Errorf("Expected the per-command temperature, got %g", got)
+	}
+	if got := commandTemperature("smart-commit"); got != 0.3 {
+		t.Errorf("Expected the global temperature, got %g", got)
+	}
+	if got := commandTemperature("bash"); got != 1 {
+		t.Errorf("Expected an out of range temperature to be clamped to 1, got %g", got)
+	}
+}
+
+// fakeLister returns a fixed model list
+type fakeLister struct {
+	models []ollama.Model
+	err    error
+}
+
+func (f fakeLister) ListModels(ctx context.Context) ([]ollama.Model, error) {
+	return f.models, f.err
+}
+
+func TestEnsureModel(t *testing.T) {
+	ctx := context.Background()
+	installed := fakeLister{models: []ollama.Model{{Name: "llama3.2:3b"}, {Name: "qwen2.5-coder:7b"}}}
+
+	if model, err := ensureModel(ctx, installed, "llama3.2:3b"); err != nil || model != "llama3.2:3b" {
+		t.Errorf("Expected an installed model to be kept, got %q, %v", model, err)
+	}
+
+	if model, err := ensureModel(ctx, fakeLister{err: fmt.Errorf("connection refused")}, "llama3:8b"); err != nil || model != "llama3:8b" {
+		t.Errorf("Expected the model unchanged when listing fails, got %q, %v", model, err)
+	}
+
+	// Tests don't run on a terminal, so there is no picker
+	_, err := ensureModel(ctx, installed, "llama3:8b")
+	if err == nil || !strings.Contains(err.Error(), "ollama pull llama3:8b") {
+		t.Errorf("Expected a pull hint, got %v", err)
+	}
+}
+
+func TestOllamaHosts(t *testing.T) {
+	setConfig(t, "ollama.hosts", nil)
+	setConfig(t, "ollama.host", "gpu-box:11434, https://fallback.local ,")
+
+	want := []string{"http://gpu-box:11434", "https://fallback.local"}
+	if got := ollamaHosts(); strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+
+	setConfig(t, "ollama.hosts", []string{"127.0.0.1:11434"})
+	if got := ollamaHosts(); len(got) != 1 || got[0] != "http://127.0.0.1:11434" {
+		t.Errorf("Expected ollama.hosts to take precedence, got %v", got)
+	}
+
+	t.Run("env", func(t *testing.T) {
+		t.Setenv("GH_SMART_COMMIT_OLLAMA_HOST", "env-box:11434")
+		setConfig(t, "ollama.host", "env-box:11434")
+		if got := ollamaHosts(); len(got) != 1 || got[0] != "http://env-box:11434" {
+			t.Errorf("Expected the environment to beat ollama.hosts, got %v", got)
+		}
+	})
+
+	t.Run("flag", func(t *testing.T) {
+		flag := rootCmd.PersistentFlags().Lookup("ollama-host")
+		flag.Value.Set("flag-box:11434")
+		flag.Changed = true
+		defer func() {
+			flag.Value.Set(flag.DefValue)
+			flag.Changed = false
+		}()
+		setConfig(t, "ollama.host", "flag-box:11434")
+		if got := ollamaHosts(); len(got) != 1 || got[0] != "http://flag-box:11434" {
+			t.Errorf("Expected --ollama-host to beat ollama.hosts, got %v", got)
+		}
+	})
+}
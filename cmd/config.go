@@ -0,0 +1,351 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"gh-smart-commit/pkg/ollama"
+	"gh-smart-commit/pkg/ui"
+)
+
+// configKey describes a known configuration key
+type configKey struct {
+	flag  string                                  // Persistent flag bound to the key, if any
+	parse func(value string) (interface{}, error) // Validates and converts a raw value
+}
+
+// knownConfigKeys lists the settings that are validated by `config set`
+var knownConfigKeys = map[string]configKey{
+	"ollama.host":                       {flag: "ollama-host", parse: parseNonEmpty},
+	"ollama.model":                      {flag: "model", parse: parseNonEmpty},
+	"ollama.temperature":                {flag: "temperature", parse: parseTemperature},
+	"ollama.think":                      {parse: parseBool},
+	"ollama.embed_model":                {parse: parseNonEmpty},
+	"ollama.context_window":             {parse: parseNonNegativeInt},
+	"ollama.keep_alive":                 {parse: parseKeepAlive},
+	"ollama.stall_timeout":              {parse: parseDuration},
+	"verbose":                           {flag: "verbose", parse: parseBool},
+	"quiet":                             {flag: "quiet", parse: parseBool},
+	"color":                             {flag: "color", parse: parseColorMode},
+	"git.concurrency":                   {flag: "git-concurrency", parse: parsePositiveInt},
+	"api.kind":                          {parse: parseAPIKind},
+	"debug.log_file":                    {flag: "log-file"},
+	"commit.warn_untracked":             {parse: parseBool},
+	"commit.similar_examples":           {parse: parseBool},
+	"commit.verb_enforcement":           {parse: parseVerbEnforcement},
+	"commit.retries":                    {parse: parseNonNegativeInt},
+	"commit.keep_partial":               {parse: parseBool},
+	"commit.max_files":                  {parse: parseNonNegativeInt},
+	"commit.body":                       {parse: parseBool},
+	"commit.include_stat_in_body":       {parse: parseBool},
+	"commit.conventional":               {parse: parseBool},
+	"diff.redact":                       {parse: parseBool},
+	"profile":                           {flag: "profile"},
+	"date.format":                       {parse: parseDateFormat},
+	"output.emoji":                      {parse: parseBool},
+	"commit.prefix_from_branch.pattern": {parse: parseRegexp},
+	"commit.prefix_from_branch.format":  {},
+	"prompt.system_prefix":              {},
+	"prompt.system_suffix":              {},
+}
+
+// configCmd represents the config command
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "View and change persistent settings",
+	Long: `View and change the settings stored in the gh-smart-commit config file.
+
+Examples:
+  gh-smart-commit config list
+  gh-smart-commit config get ollama.model
+  gh-smart-commit config set ollama.model qwen2.5-coder:7b
+  gh-smart-commit config path`,
+}
+
+var configGetCmd = &cobra.Command{
+	Use:   "get <key>",
+	Short: "Print the effective value of a setting",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if !viper.IsSet(args[0]) {
+			ui.ShowError("Unknown setting: " + args[0])
+			return fmt.Errorf("unknown setting: %s", args[0])
+		}
+		fmt.Println(viper.Get(args[0]))
+		return nil
+	},
+}
+
+var configSetCmd = &cobra.Command{
+	Use:   "set <key> <value>",
+	Short: "Write a setting to the config file",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path, err := configFilePath()
+		if err != nil {
+			ui.ShowError("Failed to resolve config file: " + err.Error())
+			return err
+		}
+
+		if _, known := knownConfigKeys[args[0]]; !known {
+			ui.ShowWarning(fmt.Sprintf("%s is not a known setting; storing it as a string", args[0]))
+		}
+
+		if err := writeConfigValue(path, args[0], args[1]); err != nil {
+			ui.ShowError("Failed to set " + args[0] + ": " + err.Error())
+			return err
+		}
+
+		ui.ShowSuccess(fmt.Sprintf("Set %s = %s in %s", args[0], args[1], path))
+		return nil
+	},
+}
+
+var configListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List all effective settings and where they come from",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		keys := viper.AllKeys()
+		sort.Strings(keys)
+
+		entries := make([]ui.ConfigEntry, 0, len(keys))
+		for _, key := range keys {
+			entries = append(entries, ui.ConfigEntry{
+				Key:    key,
+				Value:  fmt.Sprintf("%v", viper.Get(key)),
+				Source: configSource(key),
+			})
+		}
+
+		fmt.Print(ui.NewConfigFormatter().FormatSettings(entries))
+		return nil
+	},
+}
+
+var configPathCmd = &cobra.Command{
+	Use:   "path",
+	Short: "Print the config file location",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path, err := configFilePath()
+		if err != nil {
+			ui.ShowError("Failed to resolve config file: " + err.Error())
+			return err
+		}
+		fmt.Println(path)
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(configCmd)
+	configCmd.AddCommand(configGetCmd, configSetCmd, configListCmd, configPathCmd)
+}
+
+// configFilePath returns the config file in use, or the default location
+func configFilePath() (string, error) {
+	if cfgFile != "" {
+		return cfgFile, nil
+	}
+
+	if used := viper.ConfigFileUsed(); used != "" {
+		return used, nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(home, ".config", "gh-smart-commit.yaml"), nil
+}
+
+// configSource reports where the effective value of a key comes from
+func configSource(key string) string {
+	if k, ok := knownConfigKeys[key]; ok && k.flag != "" {
+		if f := rootCmd.PersistentFlags().Lookup(k.flag); f != nil && f.Changed {
+			return "flag"
+		}
+	}
+
+	if _, ok := os.LookupEnv(configEnvName(key)); ok {
+		return "env"
+	}
+
+	if profileConfig != nil && profileConfig.InConfig(key) {
+		return "profile"
+	}
+
+	if repoConfig != nil && repoConfig.InConfig(key) {
+		return "repo"
+	}
+
+	if viper.InConfig(key) {
+		return "file"
+	}
+
+	return "default"
+}
+
+// configEnvName returns the environment variable that overrides a key
+func configEnvName(key string) string {
+	replacer := strings.NewReplacer(".", "_", "-", "_")
+	return "GH_SMART_COMMIT_" + strings.ToUpper(replacer.Replace(key))
+}
+
+// writeConfigValue validates value and stores it under key in the config
+// file at path, leaving other settings in the file untouched
+func writeConfigValue(path, key, value string) error {
+	parsed, err := parseConfigValue(key, value)
+	if err != nil {
+		return err
+	}
+
+	fileConfig := viper.New()
+	fileConfig.SetConfigFile(path)
+	fileConfig.SetConfigType("yaml")
+
+	if _, err := os.Stat(path); err == nil {
+		if err := fileConfig.ReadInConfig(); err != nil {
+			return fmt.Errorf("failed to read config file: %w", err)
+		}
+	}
+
+	fileConfig.Set(key, parsed)
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+
+	return fileConfig.WriteConfigAs(path)
+}
+
+// parseConfigValue validates a raw value for a key
+func parseConfigValue(key, value string) (interface{}, error) {
+	if k, ok := knownConfigKeys[key]; ok && k.parse != nil {
+		return k.parse(value)
+	}
+	return value, nil
+}
+
+// parseNonEmpty rejects empty values
+func parseNonEmpty(value string) (interface{}, error) {
+	if strings.TrimSpace(value) == "" {
+		return nil, fmt.Errorf("value must not be empty")
+	}
+	return value, nil
+}
+
+// parseBool parses a boolean value
+func parseBool(value string) (interface{}, error) {
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return nil, fmt.Errorf("expected true or false, got %q", value)
+	}
+	return b, nil
+}
+
+// parseTemperature parses a temperature in [0,1]
+func parseTemperature(value string) (interface{}, error) {
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return nil, fmt.Errorf("expected a number, got %q", value)
+	}
+	if f < 0 || f > 1 {
+		return nil, fmt.Errorf("temperature must be between 0 and 1, got %g", f)
+	}
+	return f, nil
+}
+
+// parsePositiveInt parses an integer of at least 1
+func parsePositiveInt(value string) (interface{}, error) {
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return nil, fmt.Errorf("expected an integer, got %q", value)
+	}
+	if n < 1 {
+		return nil, fmt.Errorf("value must be at least 1, got %d", n)
+	}
+	return n, nil
+}
+
+// parseNonNegativeInt parses an integer of at least 0
+func parseNonNegativeInt(value string) (interface{}, error) {
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return nil, fmt.Errorf("expected an integer, got %q", value)
+	}
+	if n < 0 {
+		return nil, fmt.Errorf("value must not be negative, got %d", n)
+	}
+	return n, nil
+}
+
+// parseDuration accepts a non-negative duration such as "30s"
+func parseDuration(value string) (interface{}, error) {
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 {
+		return nil, fmt.Errorf("expected a duration like 30s, got %q", value)
+	}
+	return value, nil
+}
+
+// parseKeepAlive accepts what Ollama accepts for keep_alive: a duration such
+// as "30m" or a number of seconds, where a negative number means forever
+func parseKeepAlive(value string) (interface{}, error) {
+	if _, err := strconv.Atoi(value); err == nil {
+		return value, nil
+	}
+	if _, err := time.ParseDuration(value); err != nil {
+		return nil, fmt.Errorf("expected a duration like 30m or a number of seconds, got %q", value)
+	}
+	return value, nil
+}
+
+// parseColorMode accepts the --color modes
+func parseColorMode(value string) (interface{}, error) {
+	switch value {
+	case ui.ColorAuto, ui.ColorAlways, ui.ColorNever:
+		return value, nil
+	default:
+		return nil, fmt.Errorf("expected %s, %s or %s, got %q", ui.ColorAuto, ui.ColorAlways, ui.ColorNever, value)
+	}
+}
+
+// parseDateFormat accepts the date.format values
+func parseDateFormat(value string) (interface{}, error) {
+	switch value {
+	case ui.DateShort, ui.DateRelative, ui.DateISO:
+		return value, nil
+	default:
+		return nil, fmt.Errorf("expected %s, %s or %s, got %q", ui.DateShort, ui.DateRelative, ui.DateISO, value)
+	}
+}
+
+// parseRegexp accepts a valid regular expression
+func parseRegexp(value string) (interface{}, error) {
+	if _, err := regexp.Compile(value); err != nil {
+		return nil, fmt.Errorf("invalid regular expression: %w", err)
+	}
+	return value, nil
+}
+
+// parseAPIKind accepts the supported chat API kinds
+func parseAPIKind(value string) (interface{}, error) {
+	switch value {
+	case ollama.APIKindOllama, ollama.APIKindOpenAI:
+		return value, nil
+	default:
+		return nil, fmt.Errorf("expected %s or %s, got %q", ollama.APIKindOllama, ollama.APIKindOpenAI, value)
+	}
+}
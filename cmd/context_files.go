@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+
+	"gh-smart-commit/pkg/prompt"
+	"gh-smart-commit/pkg/tokens"
+)
+
+const (
+	// maxContextFileLines is how much of each --context-file is shown
+	maxContextFileLines = 300
+	// contextFileWindowShare is the part (1/n) of ollama.context_window all
+	// --context-file contents may take together, leaving the rest to the diff
+	contextFileWindowShare = 4
+)
+
+// readContextFiles reads the files passed with --context-file. Each is cut
+// to its first maxContextFileLines lines and, with a context window set,
+// together they are cut to a quarter of it. Files left with no room at all
+// are returned in skipped.
+func readContextFiles(paths []string, window int) (files []prompt.ContextFile, skipped []string, err error) {
+	budget := -1
+	if window > 0 {
+		budget = window / contextFileWindowShare
+	}
+
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read context file: %w", err)
+		}
+		if bytes.IndexByte(data, 0) >= 0 {
+			return nil, nil, fmt.Errorf("context file %s is binary", path)
+		}
+
+		content, truncated := headLines(string(data), maxContextFileLines, budget)
+		if content == "" && truncated {
+			skipped = append(skipped, path)
+			continue
+		}
+		if budget >= 0 {
+			budget -= tokens.Estimate(content)
+		}
+
+		files = append(files, prompt.ContextFile{Path: path, Content: content, Truncated: truncated})
+	}
+
+	return files, skipped, nil
+}
+
+// headLines returns the leading lines of text, at most maxLines of them and,
+// when maxTokens isn't negative, no more than maxTokens tokens. truncated
+// reports whether anything was cut.
+func headLines(text string, maxLines, maxTokens int) (head string, truncated bool) {
+	lines := strings.SplitAfter(strings.TrimRight(text, "\n"), "\n")
+
+	var b strings.Builder
+	used := 0
+	for i, line := range lines {
+		cost := tokens.Estimate(line)
+		if i == maxLines || (maxTokens >= 0 && used+cost > maxTokens) {
+			return strings.TrimRight(b.String(), "\n"), true
+		}
+		b.WriteString(line)
+		used += cost
+	}
+	return strings.TrimRight(b.String(), "\n"), false
+}
@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestHeadLines(t *testing.T) {
+	text := "one\ntwo\nthree\n"
+
+	tests := []struct {
+		maxLines, maxTokens int
+		want                string
+		truncated           bool
+	}{
+		{10, -1, "one\ntwo\nthree", false},
+		{2, -1, "one\ntwo", true},
+		{10, 3, "one\ntwo", true},
+		{10, 0, "", true},
+	}
+
+	for _, tt := range tests {
+		got, truncated := headLines(text, tt.maxLines, tt.maxTokens)
+		if got != tt.want || truncated != tt.truncated {
+			t.Errorf("headLines(%d, %d) = %q, %v, expected %q, %v", tt.maxLines, tt.maxTokens, got, truncated, tt.want, tt.truncated)
+		}
+	}
+}
+
+func TestReadContextFiles(t *testing.T) {
+	dir := t.TempDir()
+	small := filepath.Join(dir, "small.go")
+	large := filepath.Join(dir, "large.go")
+	os.WriteFile(small, []byte("package small\n"), 0644)
+	os.WriteFile(large, []byte(strings.Repeat("func f() {}\n", maxContextFileLines+50)), 0644)
+
+	files, skipped, err := readContextFiles([]string{small, large}, 0)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(files) != 2 || len(skipped) != 0 {
+		t.Fatalf("Expected 2 files and none skipped, got %d and %v", len(files), skipped)
+	}
+	if files[0].Content != "package small" || files[0].Truncated {
+		t.Errorf("Expected the small file in full, got %+v", files[0])
+	}
+	if n := strings.Count(files[1].Content, "\n") + 1; n != maxContextFileLines || !files[1].Truncated {
+		t.Errorf("Expected the large file cut to %d lines, got %d (truncated=%v)", maxContextFileLines, n, files[1].Truncated)
+	}
+
+	// A quarter of a 400-token window is used up by the large file
+	files, skipped, err = readContextFiles([]string{large, small}, 400)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(files) != 1 || len(skipped) != 1 || skipped[0] != small {
+		t.Errorf("Expected the small file to be skipped, got %d files and %v", len(files), skipped)
+	}
+
+	if _, _, err := readContextFiles([]string{filepath.Join(dir, "missing.go")}, 0); err == nil {
+		t.Error("Expected an error for a missing file")
+	}
+}
@@ -0,0 +1,120 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"gh-smart-commit/pkg/git"
+	"gh-smart-commit/pkg/ui"
+)
+
+// debugCmd groups diagnostic commands that are not part of the everyday interface
+var debugCmd = &cobra.Command{
+	Use:    "debug",
+	Short:  "Diagnostic commands for bug reports",
+	Hidden: true,
+}
+
+var debugContextCmd = &cobra.Command{
+	Use:   "context",
+	Short: "Print everything gh-smart-commit gathers about the current repository",
+	Long: `Print the repository and system context the other commands would gather:
+file lists, diff sizes, repository name, branch, detected language, ticket,
+default base branch and the system context used by the bash command.
+
+Attach the output to bug reports.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runDebugContext(cmd, args)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(debugCmd)
+	debugCmd.AddCommand(debugContextCmd)
+}
+
+func runDebugContext(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	formatter := ui.NewDebugFormatter()
+	repo := git.NewLocalRepo(".")
+
+	sysCtx, err := gatherSystemContext(ctx, configuredTreeOptions(defaultTreeOptions.Depth, defaultTreeOptions.MaxEntries))
+	if err != nil {
+		ui.ShowError("Failed to gather system context: " + err.Error())
+		return err
+	}
+
+	fmt.Print(formatter.FormatSection("Tool", []ui.DebugField{
+		{Name: "version", Value: version},
+		{Name: "model", Value: ResolveModel(viper.GetString("ollama.model"))},
+		{Name: "host", Value: strings.Join(ollamaHosts(), ", ")},
+		{Name: "api kind", Value: viper.GetString("api.kind")},
+		{Name: "config file", Value: viper.ConfigFileUsed()},
+	}))
+
+	fmt.Print(formatter.FormatSection("System", []ui.DebugField{
+		{Name: "os/arch", Value: sysCtx.OS + "/" + sysCtx.Arch},
+		{Name: "working dir", Value: sysCtx.WorkingDir},
+		{Name: "shell", Value: sysCtx.Shell},
+		{Name: "user", Value: sysCtx.User},
+		{Name: "file tree", Value: sysCtx.FileTree},
+	}))
+
+	if !sysCtx.IsGitRepo {
+		ui.ShowWarning("Not inside a Git repository; skipping repository context")
+		return nil
+	}
+
+	// Each lookup is independent; report failures inline instead of stopping
+	show := func(value string, err error) string {
+		if err != nil {
+			return "error: " + err.Error()
+		}
+		return value
+	}
+
+	branch, branchErr := repo.GetCurrentBranch(ctx)
+	defaultBranch, defaultErr := repo.GetDefaultBranch(ctx)
+	state, stateErr := repo.RepoState(ctx)
+	stateValue := string(state)
+	if stateValue == "" {
+		stateValue = "clean"
+	}
+
+	stagedFiles, stagedErr := repo.GetStagedFiles(ctx)
+	unstagedFiles, unstagedErr := repo.GetUnstagedFiles(ctx)
+	stagedDiff, stagedDiffErr := repo.GetStagedDiff(ctx)
+	unstagedDiff, unstagedDiffErr := repo.GetUnstagedDiff(ctx)
+
+	language := git.DetectLanguage(append(append([]string{}, stagedFiles...), unstagedFiles...))
+
+	fmt.Print(formatter.FormatSection("Repository", []ui.DebugField{
+		{Name: "name", Value: sysCtx.Repo},
+		{Name: "branch", Value: show(branch, branchErr)},
+		{Name: "default branch", Value: show(defaultBranch, defaultErr)},
+		{Name: "state", Value: show(stateValue, stateErr)},
+		{Name: "ticket", Value: git.TicketFromBranch(branch)},
+		{Name: "language", Value: language},
+	}))
+
+	fmt.Print(formatter.FormatSection("Changes", []ui.DebugField{
+		{Name: "staged files", Value: show(strings.Join(stagedFiles, "\n"), stagedErr)},
+		{Name: "staged diff", Value: show(diffSize(stagedDiff), stagedDiffErr)},
+		{Name: "unstaged files", Value: show(strings.Join(unstagedFiles, "\n"), unstagedErr)},
+		{Name: "unstaged diff", Value: show(diffSize(unstagedDiff), unstagedDiffErr)},
+	}))
+
+	return nil
+}
+
+// diffSize describes the size of a diff in lines and bytes
+func diffSize(diff string) string {
+	if diff == "" {
+		return "empty"
+	}
+	return fmt.Sprintf("%d lines, %d bytes", strings.Count(diff, "\n"), len(diff))
+}
@@ -0,0 +1,253 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/spf13/viper"
+
+	"gh-smart-commit/pkg/git"
+	"gh-smart-commit/pkg/ollama"
+	"gh-smart-commit/pkg/prompt"
+	"gh-smart-commit/pkg/tokens"
+	"gh-smart-commit/pkg/ui"
+)
+
+const (
+	// overflowSummaryMaxLines caps how much of the overflow is sent to the
+	// summarization pass
+	overflowSummaryMaxLines = 2000
+	// defaultContextWindow is the assumed model context size in tokens
+	defaultContextWindow = 8192
+	// minFittedDiffLines is the shortest diff fitPromptToWindow cuts down to
+	minFittedDiffLines = 20
+	// fileGroupDepth is how many directory levels --max-files groups by
+	fileGroupDepth = 2
+	// maxFileGroups caps the directories listed by fileGroupSummary
+	maxFileGroups = 20
+	// fileGroupExamples is how many file names are listed per directory
+	fileGroupExamples = 3
+)
+
+// filterPromptDiff strips binary and generated files (diff.generated) from a
+// diff before it is sent to the model and, with diff.redact, masks secrets
+// found by the built-in patterns and diff.redact_patterns
+func filterPromptDiff(diff string) (string, error) {
+	diff = git.FilterDiff(diff, git.FilterOptions{
+		GeneratedPatterns: viper.GetStringSlice("diff.generated"),
+	})
+	if !viper.GetBool("diff.redact") {
+		return diff, nil
+	}
+
+	patterns, err := redactPatterns()
+	if err != nil {
+		return "", err
+	}
+	return git.RedactDiff(diff, patterns), nil
+}
+
+// redactContextFiles masks secrets in the --context-file contents with the
+// same patterns as the diff when diff.redact is on
+func redactContextFiles(files []prompt.ContextFile) ([]prompt.ContextFile, error) {
+	if !viper.GetBool("diff.redact") {
+		return files, nil
+	}
+
+	patterns, err := redactPatterns()
+	if err != nil {
+		return nil, err
+	}
+	for i := range files {
+		files[i].Content = git.RedactText(files[i].Content, patterns)
+	}
+	return files, nil
+}
+
+// redactPatterns returns diff.redact_patterns, failing on an entry that isn't
+// a valid regular expression
+func redactPatterns() ([]string, error) {
+	patterns := viper.GetStringSlice("diff.redact_patterns")
+	for _, pattern := range patterns {
+		if _, err := regexp.Compile(pattern); err != nil {
+			return nil, fmt.Errorf("invalid diff.redact_patterns entry %q: %w", pattern, err)
+		}
+	}
+	return patterns, nil
+}
+
+// fileGroupSummary stands in for the diff of a change touching too many files
+// to show line by line: it lists the directories involved, largest first,
+// with their file types and a few file names
+func fileGroupSummary(files []string) string {
+	groups := git.GroupFiles(files, fileGroupDepth)
+
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("The change touches %d files, too many to show line by line. Files by directory:\n", len(files)))
+	for i, g := range groups {
+		if i == maxFileGroups {
+			b.WriteString(fmt.Sprintf("- and %d more directories\n", len(groups)-maxFileGroups))
+			break
+		}
+
+		dir := g.Dir + "/"
+		if g.Dir == "." {
+			dir = "repository root"
+		}
+		count := fmt.Sprintf("%d files", len(g.Files))
+		if len(g.Files) == 1 {
+			count = "1 file"
+		}
+		if len(g.Files) > 1 && len(g.Exts) > 0 {
+			count += ", mostly " + g.Exts[0]
+		}
+		examples := g.Files
+		if len(examples) > fileGroupExamples {
+			examples = append(examples[:fileGroupExamples:fileGroupExamples], "...")
+		}
+		b.WriteString(fmt.Sprintf("- %s (%s): %s\n", dir, count, strings.Join(examples, ", ")))
+	}
+	return b.String()
+}
+
+// changedAreas lists the directories files fall in, largest first, e.g.
+// "cmd/ (3 files), pkg/git/ (1 file)", so the model can name every component
+// a change touches
+func changedAreas(files []string) string {
+	var areas []string
+	for _, g := range git.GroupFiles(files, fileGroupDepth) {
+		dir := g.Dir + "/"
+		if g.Dir == "." {
+			dir = "repository root"
+		}
+		count := fmt.Sprintf("%d files", len(g.Files))
+		if len(g.Files) == 1 {
+			count = "1 file"
+		}
+		areas = append(areas, fmt.Sprintf("%s (%s)", dir, count))
+	}
+	return strings.Join(areas, ", ")
+}
+
+// summarizeOverflow fits diff into maxLines by keeping the hunks with the most
+// changes verbatim and replacing the rest with a model-written summary.
+// deterministic pins the summary's sampling like the main request.
+func summarizeOverflow(ctx context.Context, diff string, maxLines int, deterministic bool) (string, error) {
+	kept, overflow := git.SelectHunks(diff, maxLines)
+	if overflow == "" {
+		return kept, nil
+	}
+
+	overflowLines := strings.Count(overflow, "\n")
+	overflow = git.TruncateDiff(overflow, overflowSummaryMaxLines)
+
+	spinnerMessage := ui.WithEmoji("summary", fmt.Sprintf("Summarizing %d diff lines that don't fit", overflowLines))
+	summary, err := summarizeDiff(ctx, newHelperClient(ctx), overflow, spinnerMessage, "summarize-overflow", deterministic)
+	if err != nil {
+		return "", err
+	}
+
+	return kept + "\nSummary of further changes not shown above:\n" + summary + "\n", nil
+}
+
+// chunkSummaries replaces a diff that is too large for one prompt with
+// per-chunk summaries: the diff is split into chunks of whole files of at most
+// chunkLines each, and every chunk is summarized with its own model call. The
+// commit message is then written from the combined summaries.
+func chunkSummaries(ctx context.Context, diff string, chunkLines int, deterministic bool) (string, error) {
+	chunks := git.ChunkDiff(diff, chunkLines)
+	client := newHelperClient(ctx)
+
+	summaries := make([]string, len(chunks))
+	for i, chunk := range chunks {
+		spinnerMessage := ui.WithEmoji("summary", fmt.Sprintf("Summarizing part %d of %d", i+1, len(chunks)))
+		summary, err := summarizeDiff(ctx, client, chunk, spinnerMessage, "chunk", deterministic)
+		if err != nil {
+			return "", fmt.Errorf("part %d of %d: %w", i+1, len(chunks), err)
+		}
+		summaries[i] = summary
+	}
+
+	return mergeChunkSummaries(chunks, summaries), nil
+}
+
+// mergeChunkSummaries joins per-chunk summaries into the text that stands in
+// for the diff, labelling each part with the files it covers
+func mergeChunkSummaries(chunks, summaries []string) string {
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("The diff was too large to show, so it was summarized in %d parts.\n", len(chunks)))
+	for i, chunk := range chunks {
+		b.WriteString(fmt.Sprintf("\nPart %d (%s):\n%s\n", i+1, strings.Join(git.DiffFiles(chunk), ", "), strings.TrimSpace(summaries[i])))
+	}
+	return b.String()
+}
+
+// summarizeDiff asks the model for a bullet summary of diff using the
+// diff-summary template
+func summarizeDiff(ctx context.Context, client ChatClient, diff, spinnerMessage, logCommand string, deterministic bool) (string, error) {
+	systemPrompt, userPrompt, err := newPromptBuilder().Build("diff-summary", prompt.Context{Diff: diff})
+	if err != nil {
+		return "", err
+	}
+
+	chatReq := ollama.ChatRequest{
+		Model: commandModel("smart-commit"),
+		Messages: []ollama.Message{
+			{Role: "system", Content: systemPrompt},
+			{Role: "user", Content: userPrompt},
+		},
+		Options: ollama.Options{
+			Temperature: commandTemperature("smart-commit"),
+			NumCtx:      viper.GetInt("ollama.context_window"),
+		},
+		Think: thinkOption(),
+	}
+	if deterministic {
+		applyDeterministic(&chatReq)
+	}
+
+	spinner := ui.NewStreamingSpinner(spinnerMessage)
+	spinner.Start()
+	summary, err := client.ChatComplete(ctx, chatReq)
+	spinner.Stop()
+	logExchange(logCommand, chatReq, summary, err)
+	if err != nil {
+		return "", err
+	}
+
+	return prompt.StripReasoning(summary), nil
+}
+
+// fitPromptToWindow builds the prompt and, while its estimated size doesn't
+// fit in a context window of window tokens, rebuilds it with the diff cut to
+// three quarters of its length. It returns the number of lines the diff was
+// cut to, or 0 when the full diff fits.
+func fitPromptToWindow(builder *prompt.Builder, templateName string, promptCtx prompt.Context, window int) (system, user string, lines int, err error) {
+	system, user, err = builder.Build(templateName, promptCtx)
+	if err != nil || tokens.Fits(system+user, window) {
+		return system, user, 0, err
+	}
+
+	diff := promptCtx.Diff
+	lines = strings.Count(diff, "\n")
+	for lines > minFittedDiffLines {
+		lines = lines * 3 / 4
+		if lines < minFittedDiffLines {
+			lines = minFittedDiffLines
+		}
+
+		promptCtx.Diff = git.TruncateDiff(diff, lines)
+		system, user, err = builder.Build(templateName, promptCtx)
+		if err != nil {
+			return "", "", 0, err
+		}
+		if tokens.Fits(system+user, window) {
+			return system, user, lines, nil
+		}
+	}
+
+	return "", "", 0, fmt.Errorf("the prompt needs about %d tokens but ollama.context_window is %d",
+		tokens.Estimate(system+user)+tokens.ResponseReserve, window)
+}
@@ -0,0 +1,133 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"gh-smart-commit/pkg/git"
+	"gh-smart-commit/pkg/prompt"
+	"gh-smart-commit/pkg/tokens"
+)
+
+func TestMergeChunkSummaries(t *testing.T) {
+	diff := "diff --git a/auth.go b/auth.go\n--- a/auth.go\n+++ b/auth.go\n@@ -1 +1 @@\n-a\n+b\n" +
+		"diff --git a/README.md b/README.md\n--- a/README.md\n+++ b/README.md\n@@ -1 +1 @@\n-x\n+y\n"
+
+	chunks := git.ChunkDiff(diff, 6)
+	if len(chunks) != 2 {
+		t.Fatalf("Expected 2 chunks, got %d", len(chunks))
+	}
+
+	merged := mergeChunkSummaries(chunks, []string{"- Change login check\n", "  - Retitle README"})
+
+	for _, want := range []string{
+		"summarized in 2 parts",
+		"Part 1 (auth.go):\n- Change login check\n",
+		"Part 2 (README.md):\n- Retitle README\n",
+	} {
+		if !strings.Contains(merged, want) {
+			t.Errorf("Expected %q in merged summaries, got:\n%s", want, merged)
+		}
+	}
+}
+
+func TestFileGroupSummary(t *testing.T) {
+	files := []string{"proto/gen/a.pb.go", "proto/gen/b.pb.go", "proto/gen/c.pb.go", "proto/gen/d.pb.go", "Makefile"}
+
+	summary := fileGroupSummary(files)
+
+	for _, want := range []string{
+		"touches 5 files",
+		"- proto/gen/ (4 files, mostly .go): proto/gen/a.pb.go, proto/gen/b.pb.go, proto/gen/c.pb.go, ...\n",
+		"- repository root (1 file): Makefile\n",
+	} {
+		if !strings.Contains(summary, want) {
+			t.Errorf("Expected %q in summary, got:\n%s", want, summary)
+		}
+	}
+}
+
+func TestChangedAreas(t *testing.T) {
+	files := []string{"cmd/root.go", "pkg/git/diff.go", "cmd/lint.go", "README.md", "cmd/bash.go"}
+
+	want := "cmd/ (3 files), pkg/git/ (1 file), repository root (1 file)"
+	if got := changedAreas(files); got != want {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+	if got := changedAreas(nil); got != "" {
+		t.Errorf("Expected no areas for no files, got %q", got)
+	}
+}
+
+func TestFilterPromptDiffRedact(t *testing.T) {
+	diff := "diff --git a/.env b/.env\n@@ -0,0 +1,2 @@\n+DB_PASSWORD=hunter2\n+REGION=eu-west-1\n"
+
+	setConfig(t, "diff.redact", false)
+	if got, err := filterPromptDiff(diff); err != nil || !strings.Contains(got, "hunter2") {
+		t.Errorf("Expected the diff unchanged without diff.redact, got %q (err %v)", got, err)
+	}
+
+	setConfig(t, "diff.redact", true)
+	setConfig(t, "diff.redact_patterns", []string{`REGION=(\S+)`})
+	got, err := filterPromptDiff(diff)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !strings.Contains(got, "+DB_PASSWORD=[REDACTED]\n+REGION=[REDACTED]\n") {
+		t.Errorf("Expected built-in and custom patterns to be masked, got:\n%s", got)
+	}
+
+	setConfig(t, "diff.redact_patterns", []string{`(unclosed`})
+	if _, err := filterPromptDiff(diff); err == nil {
+		t.Error("Expected an invalid diff.redact_patterns entry to fail")
+	}
+}
+
+func TestRedactContextFiles(t *testing.T) {
+	files := []prompt.ContextFile{{Path: "config.go", Content: "const apiKey = \"s3cr3t-value\"\nconst region = \"eu-west-1\"\n"}}
+
+	setConfig(t, "diff.redact", false)
+	if got, err := redactContextFiles(files); err != nil || !strings.Contains(got[0].Content, "s3cr3t-value") {
+		t.Errorf("Expected the file unchanged without diff.redact, got %+v (err %v)", got, err)
+	}
+
+	setConfig(t, "diff.redact", true)
+	setConfig(t, "diff.redact_patterns", []string{`region = "(\S+)"`})
+	got, err := redactContextFiles(files)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if got[0].Content != "const apiKey = \"[REDACTED]\"\nconst region = \"[REDACTED]\"\n" {
+		t.Errorf("Expected built-in and custom patterns to be masked, got:\n%s", got[0].Content)
+	}
+}
+
+func TestFitPromptToWindow(t *testing.T) {
+	var diff strings.Builder
+	for i := 0; i < 400; i++ {
+		diff.WriteString("+\tcheck(user, password, token, session, request)\n")
+	}
+	promptCtx := prompt.Context{Repo: "test-repo", Diff: diff.String()}
+	builder := prompt.NewBuilder()
+
+	system, user, lines, err := fitPromptToWindow(builder, "smart-commit", promptCtx, 0)
+	if err != nil || lines != 0 || !strings.Contains(user, diff.String()) {
+		t.Errorf("Expected the full diff without a window, got %d lines (err %v)", lines, err)
+	}
+
+	window := tokens.Estimate(system+user) / 2
+	system, user, lines, err = fitPromptToWindow(builder, "smart-commit", promptCtx, window)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if lines == 0 || lines >= 400 {
+		t.Errorf("Expected the diff to be cut, got %d lines", lines)
+	}
+	if !tokens.Fits(system+user, window) {
+		t.Errorf("Expected the prompt to fit in %d tokens, got about %d", window, tokens.Estimate(system+user))
+	}
+
+	if _, _, _, err := fitPromptToWindow(builder, "smart-commit", promptCtx, tokens.ResponseReserve+10); err == nil {
+		t.Error("Expected an error when even the shortest diff doesn't fit")
+	}
+}
@@ -0,0 +1,164 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"gh-smart-commit/pkg/git"
+	"gh-smart-commit/pkg/ollama"
+	"gh-smart-commit/pkg/ui"
+)
+
+// doctorCmd represents the doctor command
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Diagnose the local environment",
+	Long: `Check that everything gh-smart-commit needs is in place:
+
+- git is installed and the current directory is a work tree
+- the Ollama server is reachable
+- the configured model is installed
+- the config file location is writable
+- $EDITOR is set
+
+Exits with a non-zero status if a critical check fails.`,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true, // a failed check is not a usage error
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runDoctor(cmd, args)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(doctorCmd)
+}
+
+// doctorCheck is a single environment check
+type doctorCheck struct {
+	name     string
+	critical bool
+	run      func(ctx context.Context) (string, error)
+}
+
+func runDoctor(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+
+	client := newOllamaClient()
+	model := ResolveModel(viper.GetString("ollama.model"))
+
+	checks := []doctorCheck{
+		{
+			name:     "git is installed",
+			critical: true,
+			run: func(ctx context.Context) (string, error) {
+				if err := git.CheckGitAvailable(); err != nil {
+					return "", err
+				}
+				return exec.LookPath("git")
+			},
+		},
+		{
+			name: "inside a Git work tree",
+			run: func(ctx context.Context) (string, error) {
+				isGit, err := git.NewLocalRepo(".").IsInsideWorkTree(ctx)
+				if err != nil {
+					return "", err
+				}
+				if !isGit {
+					return "", fmt.Errorf("not inside a Git repository")
+				}
+				return "", nil
+			},
+		},
+		{
+			name:     "Ollama is reachable",
+			critical: true,
+			run: func(ctx context.Context) (string, error) {
+				if err := client.Ping(ctx); err != nil {
+					return "", err
+				}
+				if version, err := client.ServerVersion(ctx); err == nil && version != "" {
+					return fmt.Sprintf("%s, version %s", client.Host(), version), nil
+				}
+				return client.Host(), nil
+			},
+		},
+		{
+			name:     fmt.Sprintf("model %s is installed", model),
+			critical: true,
+			run: func(ctx context.Context) (string, error) {
+				models, err := client.ListModels(ctx)
+				if err != nil {
+					return "", err
+				}
+				if !ollama.HasModel(models, model) {
+					return "", fmt.Errorf("run 'ollama pull %s'", model)
+				}
+				return "", nil
+			},
+		},
+		{
+			name: "config location is writable",
+			run: func(ctx context.Context) (string, error) {
+				path, err := configFilePath()
+				if err != nil {
+					return "", err
+				}
+				return path, checkWritableDir(filepath.Dir(path))
+			},
+		},
+		{
+			name: "$EDITOR is set",
+			run: func(ctx context.Context) (string, error) {
+				editor := os.Getenv("EDITOR")
+				if editor == "" {
+					return "", fmt.Errorf("set EDITOR to edit messages before committing")
+				}
+				return editor, nil
+			},
+		},
+	}
+
+	failed := 0
+	for _, check := range checks {
+		detail, err := check.run(ctx)
+		switch {
+		case err == nil && detail != "":
+			ui.ShowSuccess(fmt.Sprintf("%s (%s)", check.name, detail))
+		case err == nil:
+			ui.ShowSuccess(check.name)
+		case check.critical:
+			failed++
+			ui.ShowError(fmt.Sprintf("%s: %s", check.name, err.Error()))
+		default:
+			ui.ShowWarning(fmt.Sprintf("%s: %s", check.name, err.Error()))
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d critical check(s) failed", failed)
+	}
+
+	return nil
+}
+
+// checkWritableDir verifies that files can be created in dir, creating it if needed
+func checkWritableDir(dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	file, err := os.CreateTemp(dir, ".gh-smart-commit-doctor-*")
+	if err != nil {
+		return err
+	}
+	file.Close()
+
+	return os.Remove(file.Name())
+}
@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"gh-smart-commit/pkg/cache"
+	"gh-smart-commit/pkg/git"
+	"gh-smart-commit/pkg/ui"
+)
+
+const (
+	// similarExampleCount is how many past messages are shown as examples
+	similarExampleCount = 3
+	// similarExampleHistory is how many recent commits are searched
+	similarExampleHistory = 100
+	// exampleQueryMaxBytes keeps the embedded diff within the context of
+	// small embedding models
+	exampleQueryMaxBytes = 4000
+)
+
+// similarCommitExamples returns the recent commit messages whose embeddings
+// are closest to the diff's. Commits the vector store hasn't seen are
+// embedded first and the store is saved for the next run.
+func similarCommitExamples(ctx context.Context, repo git.Repository, cacheInstance *cache.Cache, diff string) ([]string, error) {
+	client := newHelperClient(ctx)
+
+	commits, err := repo.GetRecentCommits(ctx, similarExampleHistory)
+	if err != nil {
+		return nil, err
+	}
+	if len(commits) == 0 {
+		return nil, nil
+	}
+
+	store, err := cacheInstance.LoadVectors(client.EmbedModel())
+	if err != nil {
+		return nil, err
+	}
+
+	spinner := ui.NewStreamingSpinner("Finding similar commits...")
+	spinner.Start()
+	defer spinner.Stop()
+
+	hashes := make([]string, 0, len(commits))
+	for _, commit := range commits {
+		hashes = append(hashes, commit.Hash)
+		if store.Has(commit.Hash) {
+			continue
+		}
+
+		vector, err := client.Embed(ctx, commit.Message)
+		if err != nil {
+			return nil, fmt.Errorf("failed to embed commit %.7s: %w", commit.Hash, err)
+		}
+		store.Add(commit.Hash, commit.Message, vector)
+	}
+
+	store.Retain(hashes)
+	if err := store.Save(); err != nil {
+		return nil, err
+	}
+
+	query, err := client.Embed(ctx, exampleQuery(diff))
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed diff: %w", err)
+	}
+
+	var examples []string
+	for _, e := range store.Nearest(query, similarExampleCount) {
+		examples = append(examples, e.Message)
+	}
+	return examples, nil
+}
+
+// exampleQuery trims the diff to the part that is embedded
+func exampleQuery(diff string) string {
+	if len(diff) <= exampleQueryMaxBytes {
+		return diff
+	}
+	return strings.ToValidUTF8(diff[:exampleQueryMaxBytes], "")
+}
@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"bufio"
+	"io"
+	"strings"
+
+	"gh-smart-commit/pkg/ollama"
+)
+
+// commitAnswer is the reply to the commit confirmation prompt
+type commitAnswer int
+
+const (
+	answerCancel commitAnswer = iota
+	answerCommit
+	answerRegenerate
+)
+
+// withGuidance appends the user's free-form guidance to the user prompt
+func withGuidance(userPrompt, guidance string) string {
+	guidance = strings.TrimSpace(guidance)
+	if guidance == "" {
+		return userPrompt
+	}
+	return userPrompt + "\n\nAdditional guidance from the user: " + guidance
+}
+
+// guidanceRequest continues the conversation with the message the user was
+// shown and their guidance, so the model refines it rather than starting over
+func guidanceRequest(chatReq ollama.ChatRequest, message, guidance string) ollama.ChatRequest {
+	req := chatReq
+	req.Messages = append(append([]ollama.Message{}, chatReq.Messages...),
+		ollama.Message{Role: "assistant", Content: message},
+		ollama.Message{Role: "user", Content: "Rewrite the commit message. " + strings.TrimSpace(guidance) + " Output the commit message only:"},
+	)
+	return req
+}
+
+// askCommitAnswer reads the answer to the commit confirmation. Only "y" or
+// "yes" commits and "r" or "regenerate" asks for guidance; anything else,
+// including no answer, cancels.
+func askCommitAnswer(input *bufio.Reader) (commitAnswer, error) {
+	response, err := input.ReadString('\n')
+	if err != nil && err != io.EOF {
+		return answerCancel, err
+	}
+
+	switch strings.ToLower(strings.TrimSpace(response)) {
+	case "y", "yes":
+		return answerCommit, nil
+	case "r", "regenerate":
+		return answerRegenerate, nil
+	}
+	return answerCancel, nil
+}
+
+// askGuidance reads one line of guidance for regenerating the message
+func askGuidance(input *bufio.Reader) (string, error) {
+	response, err := input.ReadString('\n')
+	if err != nil && err != io.EOF {
+		return "", err
+	}
+	return strings.TrimSpace(response), nil
+}
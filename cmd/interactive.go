@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"gh-smart-commit/pkg/git"
+	"gh-smart-commit/pkg/ui"
+)
+
+// chooseStagedFiles lists the staged files with their line counts, lets the
+// user deselect some and unstages those. It returns the unstaged paths.
+// Deselecting every file is refused, as there would be nothing to commit.
+func chooseStagedFiles(ctx context.Context, repo git.Repository, input io.Reader) ([]string, error) {
+	stats, err := repo.GetStagedFileStats(ctx)
+	if err != nil || len(stats) == 0 {
+		return nil, err
+	}
+
+	selected, err := ui.ToggleFiles(stagedFileLabels(stats), input)
+	if err != nil {
+		return nil, err
+	}
+
+	var unstage []string
+	for i, stat := range stats {
+		if !selected[i] {
+			unstage = append(unstage, stat.Path)
+		}
+	}
+	if len(unstage) == len(stats) {
+		return nil, fmt.Errorf("no files selected; nothing was unstaged")
+	}
+
+	for i, path := range unstage {
+		if err := repo.UnstageFile(ctx, path); err != nil {
+			return unstage[:i], err
+		}
+	}
+	return unstage, nil
+}
+
+// stagedFileLabels formats each file as its path followed by its line
+// counts, with the counts lined up
+func stagedFileLabels(stats []git.FileStat) []string {
+	width := 0
+	for _, stat := range stats {
+		width = max(width, len(stat.Path))
+	}
+
+	labels := make([]string, len(stats))
+	for i, stat := range stats {
+		counts := fmt.Sprintf("+%d -%d", stat.Additions, stat.Deletions)
+		if stat.Binary {
+			counts = "binary"
+		}
+		labels[i] = fmt.Sprintf("%-*s  %s", width, stat.Path, counts)
+	}
+	return labels
+}
@@ -2,16 +2,22 @@ package cmd
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"gh-smart-commit/pkg/cache"
 	"gh-smart-commit/pkg/git"
 	"gh-smart-commit/pkg/ollama"
 	"gh-smart-commit/pkg/prompt"
 	"gh-smart-commit/pkg/ui"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
 )
 
@@ -40,22 +46,208 @@ func init() {
 	lintSuggestionsCmd.Flags().Bool("unstaged", false, "Analyze unstaged changes")
 	lintSuggestionsCmd.Flags().String("severity", "all", "Filter by severity: all, high, medium, low")
 	lintSuggestionsCmd.Flags().Int("max-suggestions", 10, "Maximum number of suggestions to display")
+	lintSuggestionsCmd.Flags().Int("max-diff-lines", 500, "Maximum diff lines to include in prompt")
+	lintSuggestionsCmd.Flags().String("fail-on", "none", "Exit with status 1 if any suggestion is at or above this severity: high, medium, low, none")
+	lintSuggestionsCmd.Flags().String("output", "text", "Output format: text, json or github (alias --format)")
+	lintSuggestionsCmd.Flags().SetNormalizeFunc(func(f *pflag.FlagSet, name string) pflag.NormalizedName {
+		if name == "format" {
+			name = "output"
+		}
+		return pflag.NormalizedName(name)
+	})
+	lintSuggestionsCmd.Flags().String("focus", "", "Only review hunks whose changed lines match this regular expression")
+	lintSuggestionsCmd.Flags().Int("context-lines", 3, "Lines of unchanged context around each change (0 = changed lines only)")
+	lintSuggestionsCmd.Flags().String("webhook", "", "POST the suggestions as JSON to this URL")
+	lintSuggestionsCmd.Flags().Bool("group-by-severity", false, "Group suggestions under HIGH, MEDIUM and LOW headings, most severe first")
+	lintSuggestionsCmd.Flags().Bool("reverse", false, "Reverse the display order, e.g. lowest severity first with --group-by-severity")
+	lintSuggestionsCmd.Flags().Bool("print-prompt", false, "Print the prompt that would be sent to the model and exit")
+	lintSuggestionsCmd.Flags().Bool("json-schema", false, "Request structured JSON output and validate it against the suggestions schema")
+	lintSuggestionsCmd.Flags().Bool("watch", false, "Re-run whenever the analyzed changes change, until Ctrl-C")
+	lintSuggestionsCmd.Flags().Bool("no-cache", false, "Ask the model again instead of reusing the answer cached for the same changes")
+	lintSuggestionsCmd.Flags().String("range", "", "Review the changes in a commit range <from>..<to> instead of staged or unstaged work")
 }
 
+const (
+	// watchPollInterval is how often --watch checks the diff for changes
+	watchPollInterval = time.Second
+	// watchDebounce is how long the diff must stay unchanged before a re-run
+	watchDebounce = 2 * time.Second
+	// reviewLanguages is how many of the diff's languages get a checklist
+	reviewLanguages = 2
+	// lintCacheTTL is how long a model answer is reused for the same prompt
+	lintCacheTTL = time.Hour
+)
+
 func runLintSuggestions(cmd *cobra.Command, args []string) error {
-	ctx := context.Background()
+	ctx := cmd.Context()
+
+	if watch, _ := cmd.Flags().GetBool("watch"); watch {
+		return watchLintSuggestions(ctx, cmd, args)
+	}
+	return lintSuggestionsOnce(ctx, cmd, args)
+}
+
+// watchLintSuggestions re-runs the analysis each time the diff settles after
+// a change, clearing the screen first. Ctrl-C cancels ctx and stops watching
+func watchLintSuggestions(ctx context.Context, cmd *cobra.Command, args []string) error {
+	outputFormat, _ := cmd.Flags().GetString("output")
+	printPromptOnly, _ := cmd.Flags().GetBool("print-prompt")
+	unstaged, _ := cmd.Flags().GetBool("unstaged")
+	commitRange, _ := cmd.Flags().GetString("range")
+
+	if outputFormat != "text" || printPromptOnly || ui.IsQuiet() || !ui.IsTerminal() {
+		ui.ShowError("--watch needs an interactive terminal and text output")
+		return fmt.Errorf("--watch needs an interactive terminal and text output")
+	}
+	if commitRange != "" {
+		ui.ShowError("--watch cannot be combined with --range")
+		return fmt.Errorf("--watch cannot be combined with --range")
+	}
+
+	// The staged flag defaults to true, so --unstaged decides which diff to watch
+	repo := git.NewLocalRepo(".")
+	fingerprint := func() (string, error) {
+		if unstaged {
+			return repo.GetUnstagedDiffContext(ctx, 0)
+		}
+		return repo.GetStagedDiffContext(ctx, 0)
+	}
+
+	run := func() {
+		ui.ClearScreen()
+		// Failures are already reported; keep watching for the next change
+		_ = lintSuggestionsOnce(ctx, cmd, args)
+		if ctx.Err() == nil {
+			ui.ShowInfo("Watching for changes, press Ctrl-C to stop")
+		}
+	}
+
+	current, err := fingerprint()
+	if err != nil {
+		ui.ShowError("Failed to read diff: " + err.Error())
+		return err
+	}
+	debouncer := &changeDebouncer{quiet: watchDebounce, last: current, pending: current}
+	run()
+
+	ticker := time.NewTicker(watchPollInterval)
+	defer ticker.Stop()
 
+	for {
+		select {
+		case <-ctx.Done():
+			ui.Print("\n")
+			ui.ShowInfo("Stopped watching")
+			return nil
+		case now := <-ticker.C:
+			current, err := fingerprint()
+			if err != nil {
+				// A failed poll, e.g. during a checkout, is retried on the next tick
+				continue
+			}
+			if debouncer.observe(current, now) {
+				run()
+			}
+		}
+	}
+}
+
+// parseCommitRange splits a "<from>..<to>" range. As in git, an omitted end
+// means HEAD.
+func parseCommitRange(value string) (from, to string, err error) {
+	if strings.Contains(value, "...") {
+		return "", "", fmt.Errorf("symmetric ranges (%s) are not supported; use <from>..<to>", value)
+	}
+
+	from, to, found := strings.Cut(value, "..")
+	if !found {
+		return "", "", fmt.Errorf("expected <from>..<to>, got %q", value)
+	}
+	if from == "" {
+		from = "HEAD"
+	}
+	if to == "" {
+		to = "HEAD"
+	}
+	return from, to, nil
+}
+
+// changeDebouncer turns a stream of diff fingerprints into re-runs, firing
+// once a new fingerprint has stayed the same for quiet
+type changeDebouncer struct {
+	quiet     time.Duration
+	last      string // fingerprint of the last run
+	pending   string // most recently observed fingerprint
+	changedAt time.Time
+}
+
+// observe records fingerprint as seen at now and reports whether to re-run
+func (d *changeDebouncer) observe(fingerprint string, now time.Time) bool {
+	if fingerprint != d.pending {
+		d.pending = fingerprint
+		d.changedAt = now
+		return false
+	}
+
+	if d.pending == d.last || now.Sub(d.changedAt) < d.quiet {
+		return false
+	}
+	d.last = d.pending
+	return true
+}
+
+// lintSuggestionsOnce analyzes the diff once and prints the suggestions
+func lintSuggestionsOnce(ctx context.Context, cmd *cobra.Command, args []string) error {
 	// Get flags
 	analyzeStaged, _ := cmd.Flags().GetBool("staged")
 	analyzeUnstaged, _ := cmd.Flags().GetBool("unstaged")
 	severityFilter, _ := cmd.Flags().GetString("severity")
 	maxSuggestions, _ := cmd.Flags().GetInt("max-suggestions")
+	maxDiffLines, _ := cmd.Flags().GetInt("max-diff-lines")
+	contextLines, _ := cmd.Flags().GetInt("context-lines")
+	noCache, _ := cmd.Flags().GetBool("no-cache")
+	failOn, _ := cmd.Flags().GetString("fail-on")
+	outputFormat, _ := cmd.Flags().GetString("output")
+	focus, _ := cmd.Flags().GetString("focus")
+	jsonSchema, _ := cmd.Flags().GetBool("json-schema")
+	printPromptOnly, _ := cmd.Flags().GetBool("print-prompt")
+	groupBySeverity, _ := cmd.Flags().GetBool("group-by-severity")
+	reverse, _ := cmd.Flags().GetBool("reverse")
+	webhookURL, _ := cmd.Flags().GetString("webhook")
+	commitRange, _ := cmd.Flags().GetString("range")
 	verbose := viper.GetBool("verbose")
 
 	// Validate flags
 	if !analyzeStaged && !analyzeUnstaged {
 		analyzeStaged = true // Default to staged if neither specified
 	}
+	if contextLines < 0 {
+		ui.ShowError("--context-lines must not be negative")
+		return fmt.Errorf("invalid context lines: %d", contextLines)
+	}
+	failOn = strings.ToLower(failOn)
+	if _, ok := failOnThresholds[failOn]; !ok {
+		ui.ShowError("--fail-on must be one of: high, medium, low, none")
+		return fmt.Errorf("invalid --fail-on value: %s", failOn)
+	}
+	var focusPattern *regexp.Regexp
+	if focus != "" {
+		compiled, err := regexp.Compile(focus)
+		if err != nil {
+			ui.ShowError("Invalid --focus pattern: " + err.Error())
+			return err
+		}
+		focusPattern = compiled
+	}
+	switch outputFormat {
+	case "text":
+	case "json", "github":
+		// Keep stdout clean for machine-readable output
+		ui.SetQuiet(true)
+	default:
+		ui.ShowError("--output must be text, json or github")
+		return fmt.Errorf("invalid output format: %s", outputFormat)
+	}
 
 	// Initialize Git repository
 	repo := git.NewLocalRepo(".")
@@ -75,15 +267,28 @@ func runLintSuggestions(cmd *cobra.Command, args []string) error {
 	var diff string
 	var diffType string
 
-	if analyzeStaged {
-		diff, err = repo.GetStagedDiff(ctx)
+	if commitRange != "" {
+		from, to, err := parseCommitRange(commitRange)
+		if err != nil {
+			ui.ShowError("Invalid --range: " + err.Error())
+			return err
+		}
+
+		diff, err = repo.GetRangeDiffContext(ctx, from, to, contextLines)
+		if err != nil {
+			ui.ShowError("Failed to get range diff: " + err.Error())
+			return err
+		}
+		diffType = commitRange
+	} else if analyzeStaged {
+		diff, err = repo.GetStagedDiffContext(ctx, contextLines)
 		if err != nil {
 			ui.ShowError("Failed to get staged diff: " + err.Error())
 			return err
 		}
 		diffType = "staged"
 	} else {
-		diff, err = repo.GetUnstagedDiff(ctx)
+		diff, err = repo.GetUnstagedDiffContext(ctx, contextLines)
 		if err != nil {
 			ui.ShowError("Failed to get unstaged diff: " + err.Error())
 			return err
@@ -92,7 +297,10 @@ func runLintSuggestions(cmd *cobra.Command, args []string) error {
 	}
 
 	if strings.TrimSpace(diff) == "" {
-		if analyzeStaged {
+		if commitRange != "" {
+			ui.ShowWarning(fmt.Sprintf("No changes in %s", commitRange))
+			return fmt.Errorf("no changes in range %s", commitRange)
+		} else if analyzeStaged {
 			ui.ShowWarning("No staged changes found. Please stage your changes with 'git add' first")
 			return fmt.Errorf("no staged changes found")
 		} else {
@@ -101,6 +309,31 @@ func runLintSuggestions(cmd *cobra.Command, args []string) error {
 		}
 	}
 
+	// Binary and generated files only add noise to the prompt
+	diff, err = filterPromptDiff(diff)
+	if err != nil {
+		ui.ShowError(err.Error())
+		return err
+	}
+
+	// Keep only the hunks the user asked to focus on
+	if focusPattern != nil {
+		diff = git.FilterHunks(diff, focusPattern)
+		if diff == "" {
+			ui.ShowWarning(fmt.Sprintf("No %s changes match --focus %q", diffType, focus))
+			return fmt.Errorf("no changes match focus pattern")
+		}
+	}
+
+	// Review points for the two most common languages, from every changed
+	// file before truncation can drop some
+	languages := git.DetectLanguages(git.DiffFiles(diff), reviewLanguages)
+
+	// Truncate diff if too long
+	if maxDiffLines > 0 {
+		diff = git.TruncateDiff(diff, maxDiffLines)
+	}
+
 	// Get repository context
 	repoName, _ := repo.GetRepoName(ctx)
 	branch, _ := repo.GetCurrentBranch(ctx)
@@ -108,21 +341,26 @@ func runLintSuggestions(cmd *cobra.Command, args []string) error {
 	// Show context info if verbose
 	contextFormatter := ui.NewContextFormatter()
 	if info := contextFormatter.FormatRepoInfo(repoName, branch, verbose); info != "" {
-		fmt.Print(info)
+		ui.Print(info)
 	}
 
 	if verbose {
 		diffLines := len(strings.Split(diff, "\n"))
 		ui.ShowInfo(fmt.Sprintf("Analyzing %s changes (%d lines)", diffType, diffLines))
 		ui.ShowInfo(fmt.Sprintf("Severity filter: %s", severityFilter))
+		if len(languages) > 0 {
+			ui.ShowInfo(fmt.Sprintf("Reviewing as %s", strings.Join(languages, " and ")))
+		}
 	}
 
 	// Build prompt
-	builder := prompt.NewBuilder()
+	builder := newPromptBuilder()
 	promptCtx := prompt.Context{
-		Repo:   repoName,
-		Branch: branch,
-		Diff:   diff,
+		Repo:       repoName,
+		Branch:     branch,
+		Diff:       diff,
+		Checklists: prompt.ChecklistsFor(languages),
+		Vars:       promptVars,
 	}
 
 	systemPrompt, userPrompt, err := builder.Build("lint-suggestions", promptCtx)
@@ -131,90 +369,122 @@ func runLintSuggestions(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	if verbose {
-		ui.ShowInfo("Sending request to Ollama...")
-	}
-
-	// Create Ollama client
-	ollamaHost := viper.GetString("ollama.host")
-	if !strings.HasPrefix(ollamaHost, "http") {
-		ollamaHost = "http://" + ollamaHost
+	if jsonSchema {
+		systemPrompt += "\n\n" + prompt.SuggestionsJSONInstruction
 	}
 
-	client := ollama.NewClient(ollamaHost)
-
-	// Test connection
-	if err := client.Ping(ctx); err != nil {
-		ui.ShowError(fmt.Sprintf("Failed to connect to Ollama at %s: %s", ollamaHost, err.Error()))
-		return err
+	if printPromptOnly {
+		printPrompt(systemPrompt, userPrompt)
+		return nil
 	}
 
 	// Prepare chat request
-	chatReq := ollama.ChatRequest{
-		Model: viper.GetString("ollama.model"),
-		Messages: []ollama.Message{
-			{Role: "system", Content: systemPrompt},
-			{Role: "user", Content: userPrompt},
-		},
-		Options: ollama.Options{
-			Temperature: float32(viper.GetFloat64("ollama.temperature")),
-		},
+	cfg := commandChatConfig("lint-suggestions")
+	chatReq := cfg.request(systemPrompt, userPrompt)
+	if jsonSchema {
+		chatReq.Format = "json"
 	}
 
-	// Create beautiful streaming spinner
-	spinner := ui.NewStreamingSpinner(fmt.Sprintf("🔍 Analyzing %s changes for improvements", diffType))
-	spinner.Start()
-
-	respChan, errChan := client.Chat(ctx, chatReq)
-
-	var responseBuilder strings.Builder
-	var streamErr error
-
-	for {
-		select {
-		case resp, ok := <-respChan:
-			if !ok {
-				goto StreamComplete
+	// The raw answer is cached so --severity and the other display flags can
+	// be changed without asking the model again
+	cacheInstance := repoCache(ctx, repo)
+	cacheKey := lintCacheKey(chatReq)
+
+	var raw string
+	cached := false
+	if !noCache {
+		if value, found, err := cacheInstance.Get(cacheKey); err == nil && found {
+			raw, cached = value, true
+			if verbose {
+				ui.ShowInfo("Using cached suggestions (--no-cache to ask again)")
 			}
-			spinner.Update()
-			responseBuilder.WriteString(resp.Message.Content)
-
-		case err := <-errChan:
-			streamErr = err
-			goto StreamComplete
-
-		case <-ctx.Done():
-			return ctx.Err()
 		}
 	}
 
-StreamComplete:
-	spinner.Stop()
+	var client ChatClient
+	if !cached {
+		if verbose {
+			ui.ShowInfo("Sending request to Ollama...")
+		}
 
-	if streamErr != nil {
-		ui.ShowError("Failed to generate suggestions: " + streamErr.Error())
-		return streamErr
+		connected, resolved, err := connectCommand(ctx, cfg)
+		if err != nil {
+			return err
+		}
+		client = connected
+		if resolved.Model != chatReq.Model {
+			chatReq.Model = resolved.Model
+			cacheKey = lintCacheKey(chatReq)
+		}
+
+		raw, err = requestSuggestions(ctx, client, chatReq, diffType)
+		if err != nil {
+			if ctx.Err() == nil {
+				ui.ShowError("Failed to generate suggestions: " + err.Error())
+			}
+			return err
+		}
 	}
 
-	response := strings.TrimSpace(responseBuilder.String())
-	if response == "" {
+	if prompt.StripReasoning(raw) == "" {
 		ui.ShowWarning("No suggestions generated")
 		return fmt.Errorf("no suggestions generated")
 	}
 
-	// Parse suggestions
-	suggestions := parseSuggestions(response)
+	suggestions, accepted := readSuggestions(ctx, client, chatReq, raw, jsonSchema, verbose)
+	if !cached && !noCache && accepted != "" {
+		if err := cacheInstance.Set(cacheKey, accepted, lintCacheTTL); err != nil && verbose {
+			ui.ShowWarning("Failed to cache result: " + err.Error())
+		}
+	}
 
 	// Filter by severity
 	filteredSuggestions := filterSuggestionsBySeverity(suggestions, severityFilter)
 
+	if groupBySeverity {
+		sortBySeverity(filteredSuggestions)
+	}
+
 	// Limit suggestions
 	if len(filteredSuggestions) > maxSuggestions {
 		filteredSuggestions = filteredSuggestions[:maxSuggestions]
 	}
 
+	// Reversing last keeps the same suggestions, only flipping their order
+	if reverse {
+		reverseSuggestions(filteredSuggestions)
+	}
+
+	payload := suggestionsPayload{
+		Event:       "lint-suggestions",
+		Repo:        repoName,
+		Branch:      branch,
+		Model:       chatReq.Model,
+		DiffType:    diffType,
+		Counts:      severityCounts(filteredSuggestions),
+		Suggestions: filteredSuggestions,
+	}
+	sendWebhook(ctx, webhookURL, payload)
+
+	// The gate looks at every parsed suggestion so display filters cannot hide a finding
+	failErr := checkFailOn(suggestions, failOn)
+	if failErr != nil {
+		cmd.SilenceUsage = true
+	}
+
+	if outputFormat == "json" {
+		data, err := json.MarshalIndent(payload, "", "  ")
+		if err != nil {
+			ui.ShowError("Failed to encode suggestions: " + err.Error())
+			return err
+		}
+		fmt.Println(string(data))
+		return failErr
+	}
+
 	// Display suggestions beautifully
 	formatter := ui.NewSuggestionFormatter()
+	formatter.GroupBySeverity = groupBySeverity
 
 	// Convert to UI suggestions format
 	uiSuggestions := make([]ui.Suggestion, len(filteredSuggestions))
@@ -224,9 +494,21 @@ StreamComplete:
 			Title:       s.Title,
 			Description: s.Description,
 			Number:      s.Number,
+			File:        s.File,
+			Line:        s.Line,
 		}
 	}
 
+	if outputFormat == "github" {
+		fmt.Print(formatter.FormatGitHub(uiSuggestions))
+		return failErr
+	}
+
+	if ui.IsQuiet() {
+		fmt.Print(formatter.FormatPlain(uiSuggestions))
+		return failErr
+	}
+
 	output := formatter.FormatSuggestionsList(uiSuggestions, diffType, len(suggestions))
 	fmt.Print(output)
 
@@ -235,15 +517,59 @@ StreamComplete:
 		ui.ShowInfo(fmt.Sprintf("Showing only %s severity suggestions", strings.ToUpper(severityFilter)))
 	}
 
-	return nil
+	if failErr != nil {
+		ui.ShowError(failErr.Error())
+	}
+
+	return failErr
 }
 
 // Suggestion represents a code improvement suggestion
 type Suggestion struct {
-	Severity    string
-	Title       string
-	Description string
-	Number      int
+	Severity    string `json:"severity"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Number      int    `json:"number"`
+	File        string `json:"file,omitempty"`
+	Line        int    `json:"line,omitempty"`
+}
+
+// locationPattern matches a "file:line" anchor such as "cmd/root.go:42"
+var locationPattern = regexp.MustCompile(`([\w./-]+):(\d+)`)
+
+// parseLocation finds the first file:line anchor in text. It returns the
+// text with a leading anchor removed, so titles read naturally.
+func parseLocation(text string) (file string, line int, rest string) {
+	for _, m := range locationPattern.FindAllStringSubmatchIndex(text, -1) {
+		candidate := text[m[2]:m[3]]
+		// Require a path-like name so times such as 10:30 are not anchors
+		if !strings.ContainsAny(candidate, "./") {
+			continue
+		}
+
+		line, _ = strconv.Atoi(text[m[4]:m[5]])
+		rest = text
+		if strings.TrimSpace(text[:m[0]]) == "" {
+			rest = strings.TrimLeft(text[m[1]:], " :-–—")
+		}
+		return candidate, line, rest
+	}
+
+	return "", 0, text
+}
+
+// fallbackSeverityPattern matches a leading unbracketed severity such as
+// "High: ...", "Low - ..." or a markdown-bolded "**Medium**"
+var fallbackSeverityPattern = regexp.MustCompile(`(?i)^(?:[-*]\s+)?(?:\*\*(high|medium|low):?\*\*:?|(high|medium|low)(?::|\s*-\s))\s*`)
+
+// lintCacheKey derives the cache key for a review from the model, the output
+// format and the prompts, which include the diff
+func lintCacheKey(req ollama.ChatRequest) string {
+	components := []string{"lint-suggestions", req.Model, req.Format}
+	for _, msg := range req.Messages {
+		components = append(components, msg.Role, msg.Content)
+	}
+	return cache.GenerateCacheKey(components...)
 }
 
 // parseSuggestions parses the AI response into structured suggestions
@@ -273,12 +599,21 @@ func parseSuggestions(response string) []Suggestion {
 			severity := strings.TrimSpace(strings.ToUpper(matches[2]))
 			title := strings.TrimSpace(matches[3])
 
+			file, lineNumber, title := parseLocation(title)
+
 			currentSuggestion = &Suggestion{
 				Number:   number,
 				Severity: severity,
 				Title:    title,
+				File:     file,
+				Line:     lineNumber,
 			}
 		} else if currentSuggestion != nil && line != "" {
+			// Pick up a location mentioned in the description
+			if currentSuggestion.File == "" {
+				currentSuggestion.File, currentSuggestion.Line, _ = parseLocation(line)
+			}
+
 			// Add to description of current suggestion
 			if currentSuggestion.Description == "" {
 				currentSuggestion.Description = line
@@ -316,11 +651,20 @@ func parseSuggestions(response string) []Suggestion {
 			line = regexp.MustCompile(`^\d+\.\s*`).ReplaceAllString(line, "")
 			line = regexp.MustCompile(`\[(?:HIGH|MEDIUM|LOW)\]\s*`).ReplaceAllString(line, "")
 
+			// "High: ...", "Low - ..." and "**Medium** ..." styles
+			if matches := fallbackSeverityPattern.FindStringSubmatch(line); matches != nil {
+				severity = strings.ToUpper(matches[1] + matches[2])
+				line = strings.TrimSpace(line[len(matches[0]):])
+			}
+
 			if line != "" {
+				file, lineNumber, title := parseLocation(line)
 				suggestions = append(suggestions, Suggestion{
 					Number:   number,
 					Severity: severity,
-					Title:    line,
+					Title:    title,
+					File:     file,
+					Line:     lineNumber,
 				})
 				number++
 			}
@@ -330,6 +674,156 @@ func parseSuggestions(response string) []Suggestion {
 	return suggestions
 }
 
+// requestSuggestions streams the lint request and returns the raw answer
+func requestSuggestions(ctx context.Context, client ChatClient, chatReq ollama.ChatRequest, diffType string) (string, error) {
+	// Create beautiful streaming spinner
+	spinner := ui.NewStreamingSpinner(ui.WithEmoji("lint", fmt.Sprintf("Analyzing %s changes for improvements", diffType)))
+	spinner.Start()
+	defer spinner.Stop()
+
+	return streamChat(ctx, client, "lint-suggestions", chatReq, func(string) { spinner.Update() })
+}
+
+// readSuggestions parses the suggestions in raw. With jsonSchema an answer
+// that fails validation is retried once through client, which is nil for a
+// cached answer, before falling back to text parsing. It also returns the
+// answer the suggestions came from, or "" when it failed validation and
+// shouldn't be cached.
+func readSuggestions(ctx context.Context, client ChatClient, chatReq ollama.ChatRequest, raw string, jsonSchema, verbose bool) ([]Suggestion, string) {
+	response := prompt.StripReasoning(raw)
+	if !jsonSchema {
+		return parseSuggestions(response), raw
+	}
+
+	accepted := raw
+	suggestions, err := structuredSuggestions(response, func(cause error) (string, error) {
+		if verbose {
+			ui.ShowWarning("Structured response rejected, retrying: " + cause.Error())
+		}
+		if client == nil {
+			return "", fmt.Errorf("the cached answer can't be retried; run with --no-cache")
+		}
+		retryReq := chatReq
+		retryReq.Messages = append(append([]ollama.Message{}, chatReq.Messages...),
+			ollama.Message{Role: "assistant", Content: response},
+			ollama.Message{Role: "user", Content: prompt.StrictJSONInstruction(prompt.SuggestionsJSONInstruction, cause)},
+		)
+		retryResponse, err := client.ChatComplete(ctx, retryReq)
+		logExchange("lint-suggestions", retryReq, retryResponse, err)
+		accepted = retryResponse
+		return retryResponse, err
+	})
+	if err != nil {
+		if verbose {
+			ui.ShowWarning("Falling back to text parsing: " + err.Error())
+		}
+		// A cached answer that fails validation could never be retried
+		return suggestions, ""
+	}
+	return suggestions, accepted
+}
+
+// structuredSuggestions decodes a JSON-mode response against the suggestions schema.
+// On a mismatch it retries once with a stricter instruction and, if that also
+// fails, falls back to text parsing of the original response. The returned
+// error is the last schema error when the fallback was used.
+func structuredSuggestions(response string, retry func(cause error) (string, error)) ([]Suggestion, error) {
+	var schema prompt.SuggestionsSchema
+	err := prompt.DecodeStructured(response, &schema)
+
+	if err != nil && retry != nil {
+		if retried, retryErr := retry(err); retryErr == nil {
+			schema = prompt.SuggestionsSchema{}
+			err = prompt.DecodeStructured(retried, &schema)
+		}
+	}
+
+	if err != nil {
+		return parseSuggestions(response), err
+	}
+
+	suggestions := make([]Suggestion, len(schema.Suggestions))
+	for i, s := range schema.Suggestions {
+		suggestions[i] = Suggestion{
+			Number:      i + 1,
+			Severity:    strings.ToUpper(strings.TrimSpace(s.Severity)),
+			Title:       strings.TrimSpace(s.Title),
+			Description: strings.TrimSpace(s.Description),
+			File:        strings.TrimSpace(s.File),
+			Line:        s.Line,
+		}
+	}
+
+	return suggestions, nil
+}
+
+// errSeverityThreshold is returned when --fail-on finds a suggestion at or above its level
+var errSeverityThreshold = errors.New("suggestions at or above the --fail-on severity were found")
+
+// severityRanks orders severities from least to most severe
+var severityRanks = map[string]int{
+	"LOW":    1,
+	"MEDIUM": 2,
+	"HIGH":   3,
+}
+
+// severityCounts counts suggestions per severity. HIGH, MEDIUM and LOW are
+// always present, so consumers can read them without checking.
+func severityCounts(suggestions []Suggestion) map[string]int {
+	counts := make(map[string]int, len(severityRanks))
+	for severity := range severityRanks {
+		counts[severity] = 0
+	}
+	for _, suggestion := range suggestions {
+		counts[suggestion.Severity]++
+	}
+	return counts
+}
+
+// sortBySeverity orders suggestions from most to least severe, keeping the
+// model's order within a severity. Unknown severities sort last.
+func sortBySeverity(suggestions []Suggestion) {
+	sort.SliceStable(suggestions, func(i, j int) bool {
+		return severityRanks[suggestions[i].Severity] > severityRanks[suggestions[j].Severity]
+	})
+}
+
+// reverseSuggestions reverses suggestions in place
+func reverseSuggestions(suggestions []Suggestion) {
+	for i, j := 0, len(suggestions)-1; i < j; i, j = i+1, j-1 {
+		suggestions[i], suggestions[j] = suggestions[j], suggestions[i]
+	}
+}
+
+// failOnThresholds maps --fail-on values to the minimum failing rank; 0 disables the gate
+var failOnThresholds = map[string]int{
+	"none":   0,
+	"low":    severityRanks["LOW"],
+	"medium": severityRanks["MEDIUM"],
+	"high":   severityRanks["HIGH"],
+}
+
+// checkFailOn returns errSeverityThreshold if any suggestion meets the --fail-on level
+func checkFailOn(suggestions []Suggestion, failOn string) error {
+	threshold := failOnThresholds[failOn]
+	if threshold == 0 {
+		return nil
+	}
+
+	count := 0
+	for _, suggestion := range suggestions {
+		if severityRanks[suggestion.Severity] >= threshold {
+			count++
+		}
+	}
+
+	if count > 0 {
+		return fmt.Errorf("%w (%d found)", errSeverityThreshold, count)
+	}
+
+	return nil
+}
+
 // filterSuggestionsBySeverity filters suggestions by severity level
 func filterSuggestionsBySeverity(suggestions []Suggestion, severityFilter string) []Suggestion {
 	if severityFilter == "all" {
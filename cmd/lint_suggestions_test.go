@@ -1,8 +1,14 @@
 package cmd
 
 import (
+	"context"
+	"errors"
 	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
+
+	"gh-smart-commit/pkg/ollama"
 )
 
 func TestParseSuggestions(t *testing.T) {
@@ -135,3 +141,303 @@ func TestParseSuggestionsNoMatches(t *testing.T) {
 		t.Error("Expected fallback parsing to create at least one suggestion")
 	}
 }
+
+func TestParseSuggestionsFallbackSeverities(t *testing.T) {
+	response := "High: do X\n**Medium** do Y\n- low - tidy Z\nLow-level detail stays medium"
+	suggestions := parseSuggestions(response)
+
+	expected := []struct {
+		severity string
+		title    string
+	}{
+		{"HIGH", "do X"},
+		{"MEDIUM", "do Y"},
+		{"LOW", "tidy Z"},
+		{"MEDIUM", "Low-level detail stays medium"},
+	}
+
+	if len(suggestions) != len(expected) {
+		t.Fatalf("Expected %d suggestions, got %d: %+v", len(expected), len(suggestions), suggestions)
+	}
+	for i, want := range expected {
+		if suggestions[i].Severity != want.severity || suggestions[i].Title != want.title {
+			t.Errorf("Suggestion %d: expected [%s] %q, got [%s] %q", i, want.severity, want.title, suggestions[i].Severity, suggestions[i].Title)
+		}
+	}
+}
+
+func TestSortAndReverseSuggestions(t *testing.T) {
+	suggestions := []Suggestion{
+		{Severity: "LOW", Title: "a"},
+		{Severity: "HIGH", Title: "b"},
+		{Severity: "MEDIUM", Title: "c"},
+		{Severity: "HIGH", Title: "d"},
+	}
+
+	sortBySeverity(suggestions)
+	if got := suggestionTitles(suggestions); got != "b d c a" {
+		t.Errorf("Expected grouped order 'b d c a', got %q", got)
+	}
+
+	reverseSuggestions(suggestions)
+	if got := suggestionTitles(suggestions); got != "a c d b" {
+		t.Errorf("Expected reversed order 'a c d b', got %q", got)
+	}
+}
+
+func suggestionTitles(suggestions []Suggestion) string {
+	titles := make([]string, len(suggestions))
+	for i, s := range suggestions {
+		titles[i] = s.Title
+	}
+	return strings.Join(titles, " ")
+}
+
+func TestStructuredSuggestionsValid(t *testing.T) {
+	response := `{"suggestions": [{"severity": "high", "title": "Validate input", "description": "Check for nil"}]}`
+
+	retried := false
+	suggestions, err := structuredSuggestions(response, func(cause error) (string, error) {
+		retried = true
+		return "", nil
+	})
+
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if retried {
+		t.Error("Expected no retry for a valid response")
+	}
+	if len(suggestions) != 1 || suggestions[0].Severity != "HIGH" {
+		t.Errorf("Expected one HIGH suggestion, got %+v", suggestions)
+	}
+}
+
+func TestStructuredSuggestionsRetry(t *testing.T) {
+	suggestions, err := structuredSuggestions(`{"suggestions": [`, func(cause error) (string, error) {
+		return `{"suggestions": [{"severity": "LOW", "title": "Rename variable", "description": ""}]}`, nil
+	})
+
+	if err != nil {
+		t.Fatalf("Unexpected error after successful retry: %v", err)
+	}
+	if len(suggestions) != 1 || suggestions[0].Title != "Rename variable" {
+		t.Errorf("Expected suggestion from retried response, got %+v", suggestions)
+	}
+}
+
+func TestStructuredSuggestionsFallback(t *testing.T) {
+	response := "1. [HIGH] Add error handling\n   Check the error from Close."
+
+	calls := 0
+	suggestions, err := structuredSuggestions(response, func(cause error) (string, error) {
+		calls++
+		return `{"suggestions": "not a list"}`, nil
+	})
+
+	if err == nil {
+		t.Error("Expected schema error when falling back to text parsing")
+	}
+	if calls != 1 {
+		t.Errorf("Expected exactly one retry, got %d", calls)
+	}
+	if len(suggestions) != 1 || suggestions[0].Severity != "HIGH" {
+		t.Errorf("Expected text-parsed HIGH suggestion, got %+v", suggestions)
+	}
+}
+
+func TestParseSuggestionsLocations(t *testing.T) {
+	response := `1. [HIGH] cmd/root.go:42 Handle the error returned by Close
+   The deferred Close discards its error.
+
+2. [MEDIUM] Cache the compiled regex
+   Move the regexp.MustCompile call in pkg/prompt/builder.go:310 to package level.
+
+3. [LOW] Run the linter before 10:30 standup`
+
+	suggestions := parseSuggestions(response)
+	if len(suggestions) != 3 {
+		t.Fatalf("Expected 3 suggestions, got %d", len(suggestions))
+	}
+
+	if suggestions[0].File != "cmd/root.go" || suggestions[0].Line != 42 {
+		t.Errorf("Expected cmd/root.go:42, got %s:%d", suggestions[0].File, suggestions[0].Line)
+	}
+	if suggestions[0].Title != "Handle the error returned by Close" {
+		t.Errorf("Expected anchor to be stripped from title, got '%s'", suggestions[0].Title)
+	}
+
+	if suggestions[1].File != "pkg/prompt/builder.go" || suggestions[1].Line != 310 {
+		t.Errorf("Expected location from description, got %s:%d", suggestions[1].File, suggestions[1].Line)
+	}
+
+	if suggestions[2].File != "" {
+		t.Errorf("Expected no location for a time of day, got %s:%d", suggestions[2].File, suggestions[2].Line)
+	}
+}
+
+func TestSeverityCounts(t *testing.T) {
+	counts := severityCounts([]Suggestion{{Severity: "HIGH"}, {Severity: "LOW"}, {Severity: "HIGH"}})
+
+	want := map[string]int{"HIGH": 2, "MEDIUM": 0, "LOW": 1}
+	if len(counts) != len(want) {
+		t.Errorf("Expected %v, got %v", want, counts)
+	}
+	for severity, n := range want {
+		if counts[severity] != n {
+			t.Errorf("Expected %d %s, got %d", n, severity, counts[severity])
+		}
+	}
+}
+
+func TestCheckFailOn(t *testing.T) {
+	suggestions := []Suggestion{
+		{Severity: "LOW", Title: "a"},
+		{Severity: "MEDIUM", Title: "b"},
+	}
+
+	tests := []struct {
+		failOn  string
+		wantErr bool
+	}{
+		{"none", false},
+		{"high", false},
+		{"medium", true},
+		{"low", true},
+	}
+
+	for _, tt := range tests {
+		err := checkFailOn(suggestions, tt.failOn)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("checkFailOn(%q) error = %v, wantErr %v", tt.failOn, err, tt.wantErr)
+		}
+		if err != nil && !errors.Is(err, errSeverityThreshold) {
+			t.Errorf("Expected errSeverityThreshold, got %v", err)
+		}
+	}
+}
+
+func TestChangeDebouncer(t *testing.T) {
+	start := time.Now()
+	d := &changeDebouncer{quiet: 2 * time.Second, last: "a", pending: "a"}
+
+	steps := []struct {
+		fingerprint string
+		after       time.Duration
+		want        bool
+	}{
+		{"a", 1 * time.Second, false}, // nothing changed
+		{"b", 2 * time.Second, false}, // change seen, not settled yet
+		{"c", 3 * time.Second, false}, // still changing
+		{"c", 4 * time.Second, false}, // settled for 1s
+		{"c", 5 * time.Second, true},  // settled for 2s
+		{"c", 9 * time.Second, false}, // already ran for c
+		{"a", 10 * time.Second, false},
+		{"a", 12 * time.Second, true}, // reverting is a change too
+	}
+
+	for _, step := range steps {
+		if got := d.observe(step.fingerprint, start.Add(step.after)); got != step.want {
+			t.Errorf("observe(%q) at +%s = %v, expected %v", step.fingerprint, step.after, got, step.want)
+		}
+	}
+}
+
+func TestParseCommitRange(t *testing.T) {
+	tests := []struct {
+		value    string
+		from, to string
+		wantErr  bool
+	}{
+		{"main..feature", "main", "feature", false},
+		{"origin/main..", "origin/main", "HEAD", false},
+		{"..v1.2.0", "HEAD", "v1.2.0", false},
+		{"main", "", "", true},
+		{"main...feature", "", "", true},
+	}
+
+	for _, tt := range tests {
+		from, to, err := parseCommitRange(tt.value)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseCommitRange(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			continue
+		}
+		if from != tt.from || to != tt.to {
+			t.Errorf("parseCommitRange(%q) = %q, %q, expected %q, %q", tt.value, from, to, tt.from, tt.to)
+		}
+	}
+}
+
+func TestLintCacheKey(t *testing.T) {
+	req := func(diff, format string) ollama.ChatRequest {
+		return ollama.ChatRequest{
+			Model:    "test-model",
+			Format:   format,
+			Messages: []ollama.Message{{Role: "system", Content: "review"}, {Role: "user", Content: diff}},
+		}
+	}
+
+	if lintCacheKey(req("+a", "")) != lintCacheKey(req("+a", "")) {
+		t.Error("Expected the same changes to reuse the cached answer")
+	}
+	if lintCacheKey(req("+a", "")) == lintCacheKey(req("+b", "")) {
+		t.Error("Expected different changes to get different keys")
+	}
+	if lintCacheKey(req("+a", "")) == lintCacheKey(req("+a", "json")) {
+		t.Error("Expected text and JSON answers to be cached separately")
+	}
+}
+
+func TestLintCachesOnlyValidStructuredAnswers(t *testing.T) {
+	newTestRepo(t)
+	chats := newFakeOllama(t, "not json")
+
+	flags := map[string]string{"json-schema": "true"}
+	for i := 0; i < 2; i++ {
+		if err := runCommandWith(t, lintSuggestionsCmd, runLintSuggestions, flags, ""); err != nil {
+			t.Fatalf("Lint failed: %v", err)
+		}
+	}
+	// Each run asks once and retries once; a cached invalid answer would
+	// have skipped the model on the second run
+	if got := atomic.LoadInt32(chats); got != 4 {
+		t.Errorf("Expected the invalid answer to be asked for again, got %d chats", got)
+	}
+
+	chats = newFakeOllama(t, `{"suggestions":[{"severity":"LOW","title":"Add a doc comment","description":"main has none"}]}`)
+	for i := 0; i < 2; i++ {
+		if err := runCommandWith(t, lintSuggestionsCmd, runLintSuggestions, flags, ""); err != nil {
+			t.Fatalf("Lint failed: %v", err)
+		}
+	}
+	if got := atomic.LoadInt32(chats); got != 1 {
+		t.Errorf("Expected the valid answer to be reused, got %d chats", got)
+	}
+}
+
+func TestReadSuggestions(t *testing.T) {
+	valid := `{"suggestions":[{"severity":"high","title":"Check the error","description":"Close can fail"}]}`
+	req := ollama.ChatRequest{Messages: []ollama.Message{{Role: "system", Content: "system"}, {Role: "user", Content: "user"}}}
+
+	client := &fakeChatClient{answers: []string{valid}}
+	suggestions, accepted := readSuggestions(context.Background(), client, req, "not json", true, false)
+	if len(suggestions) != 1 || suggestions[0].Severity != "HIGH" {
+		t.Errorf("Expected the retried suggestion, got %+v", suggestions)
+	}
+	if accepted != valid {
+		t.Errorf("Expected the retry's answer to be the one cached, got %q", accepted)
+	}
+	if len(client.requests) != 1 || client.requests[0].Messages[2].Content != "not json" {
+		t.Errorf("Expected one retry that includes the rejected answer, got %+v", client.requests)
+	}
+
+	// A cached answer has no client to retry with
+	if _, accepted := readSuggestions(context.Background(), nil, req, "not json", true, false); accepted != "" {
+		t.Errorf("Expected an invalid answer not to be cached, got %q", accepted)
+	}
+
+	suggestions, accepted = readSuggestions(context.Background(), nil, req, "1. [LOW] Add a doc comment", false, false)
+	if len(suggestions) != 1 || accepted != "1. [LOW] Add a doc comment" {
+		t.Errorf("Expected the text answer to be parsed and cached, got %+v (%q)", suggestions, accepted)
+	}
+}
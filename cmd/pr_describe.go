@@ -0,0 +1,188 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"gh-smart-commit/pkg/git"
+	"gh-smart-commit/pkg/prompt"
+	"gh-smart-commit/pkg/ui"
+)
+
+// prDescribeCmd represents the pr-describe command
+var prDescribeCmd = &cobra.Command{
+	Use:   "pr-describe",
+	Short: "Generate a pull request title and body for the current branch",
+	Long: `Analyze the commits and diff unique to the current branch and generate a
+pull request title plus a markdown body with Summary, Changes and Testing
+sections.
+
+The output is ready to paste into a pull request. Use --json to get
+{"title": ..., "body": ...} for scripting, e.g.:
+
+  gh-smart-commit pr-describe --json > pr.json
+  gh pr create --title "$(jq -r .title pr.json)" --body "$(jq -r .body pr.json)"`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runPRDescribe(cmd, args)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(prDescribeCmd)
+
+	// Command-specific flags
+	prDescribeCmd.Flags().String("base-branch", "main", "Base branch the pull request targets")
+	prDescribeCmd.Flags().Int("max-diff-lines", 500, "Maximum diff lines to include in prompt")
+	prDescribeCmd.Flags().Bool("json", false, "Print {\"title\", \"body\"} as JSON")
+}
+
+// prDescription is the --json output of pr-describe
+type prDescription struct {
+	Title string `json:"title"`
+	Body  string `json:"body"`
+}
+
+func runPRDescribe(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+
+	// Get flags
+	baseBranch, _ := cmd.Flags().GetString("base-branch")
+	maxDiffLines, _ := cmd.Flags().GetInt("max-diff-lines")
+	asJSON, _ := cmd.Flags().GetBool("json")
+	verbose := viper.GetBool("verbose")
+
+	if asJSON {
+		// Keep stdout clean for the JSON document
+		ui.SetQuiet(true)
+	}
+
+	// Initialize Git repository
+	repo := git.NewLocalRepo(".")
+
+	// Check if we're in a Git repository
+	isGit, err := repo.IsInsideWorkTree(ctx)
+	if err != nil {
+		ui.ShowError("Failed to check if inside Git repository: " + err.Error())
+		return err
+	}
+	if !isGit {
+		ui.ShowError("Not inside a Git repository")
+		return fmt.Errorf("not inside a Git repository")
+	}
+
+	repoName, _ := repo.GetRepoName(ctx)
+	currentBranch, _ := repo.GetCurrentBranch(ctx)
+
+	if currentBranch == baseBranch {
+		ui.ShowError(fmt.Sprintf("Already on %s; switch to the feature branch or pass --base-branch", baseBranch))
+		return fmt.Errorf("current branch is the base branch")
+	}
+
+	// Get the commits unique to this branch
+	commits, err := repo.GetCommitsInRange(ctx, baseBranch, "HEAD")
+	if err != nil {
+		ui.ShowError("Failed to get branch commits: " + err.Error())
+		return err
+	}
+
+	if len(commits) == 0 {
+		ui.ShowWarning(fmt.Sprintf("No commits on %s that are not on %s", currentBranch, baseBranch))
+		return fmt.Errorf("no commits ahead of %s", baseBranch)
+	}
+
+	diff, err := repo.GetBranchDiff(ctx, baseBranch, "HEAD")
+	if err != nil {
+		ui.ShowError("Failed to get branch diff: " + err.Error())
+		return err
+	}
+
+	if maxDiffLines > 0 {
+		diff = git.TruncateDiff(diff, maxDiffLines)
+	}
+
+	// Show context info if verbose
+	contextFormatter := ui.NewContextFormatter()
+	if info := contextFormatter.FormatRepoInfo(repoName, currentBranch, verbose); info != "" {
+		ui.Print(info)
+	}
+
+	if verbose {
+		ui.ShowInfo(fmt.Sprintf("Describing %d commits ahead of %s", len(commits), baseBranch))
+	}
+
+	// Build prompt
+	builder := newPromptBuilder()
+	promptCtx := prompt.Context{
+		Repo:       repoName,
+		Branch:     currentBranch,
+		BaseBranch: baseBranch,
+		Commits:    commits,
+		Diff:       diff,
+		Vars:       promptVars,
+	}
+
+	systemPrompt, userPrompt, err := builder.Build("pr-describe", promptCtx)
+	if err != nil {
+		ui.ShowError("Failed to build prompt: " + err.Error())
+		return err
+	}
+
+	if verbose {
+		ui.ShowInfo("Sending request to Ollama...")
+	}
+
+	client, cfg, err := connectCommand(ctx, commandChatConfig("pr-describe"))
+	if err != nil {
+		return err
+	}
+
+	title, body, err := generatePRDescription(ctx, client, cfg, systemPrompt, userPrompt)
+	if err != nil {
+		if ctx.Err() == nil {
+			ui.ShowError("Failed to generate pull request description: " + err.Error())
+		}
+		return err
+	}
+	if title == "" {
+		ui.ShowWarning("No pull request description generated")
+		return fmt.Errorf("no pull request description generated")
+	}
+
+	if asJSON {
+		data, err := json.MarshalIndent(prDescription{Title: title, Body: body}, "", "  ")
+		if err != nil {
+			ui.ShowError("Failed to encode description: " + err.Error())
+			return err
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	// The markdown itself is printed unstyled so it can be copied as-is
+	ui.Print(ui.NewPRFormatter().FormatHeader(currentBranch, baseBranch))
+	fmt.Println(title)
+	fmt.Println()
+	fmt.Println(body)
+
+	return nil
+}
+
+// generatePRDescription asks the model for the pull request the prompts
+// describe and splits the answer into its title and body
+func generatePRDescription(ctx context.Context, client ChatClient, cfg chatConfig, systemPrompt, userPrompt string) (title, body string, err error) {
+	// Create beautiful streaming spinner
+	spinner := ui.NewStreamingSpinner(ui.WithEmoji("pr", "Describing pull request"))
+	spinner.Start()
+	defer spinner.Stop()
+
+	response, err := streamChat(ctx, client, "pr-describe", cfg.request(systemPrompt, userPrompt), func(string) { spinner.Update() })
+	if err != nil {
+		return "", "", err
+	}
+	title, body = prompt.SplitPRDescription(response)
+	return title, body, nil
+}
@@ -1,16 +1,43 @@
 package cmd
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
+	"os/signal"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
+
+	"gh-smart-commit/pkg/cache"
+	"gh-smart-commit/pkg/git"
+	"gh-smart-commit/pkg/ollama"
+	"gh-smart-commit/pkg/prompt"
+	"gh-smart-commit/pkg/ui"
 )
 
+// repoConfigFileName is the per-repository config file at the work tree root
+const repoConfigFileName = ".gh-smart-commit.yaml"
+
 var (
 	cfgFile string
 	version = "dev" // will be set by goreleaser
+
+	// repoConfig holds the settings read from the repository config file, if any
+	repoConfig *viper.Viper
+
+	// promptVars holds the --var values passed to every prompt template
+	promptVars map[string]string
+
+	// profileConfig holds the settings of the profile selected with --profile, if any
+	profileConfig *viper.Viper
 )
 
 // rootCmd represents the base command when called without any subcommands
@@ -25,9 +52,35 @@ the Ollama model is downloaded.`,
 	Version: version,
 }
 
+// interruptGracePeriod is how long a cancelled command gets to clean up
+// before the process exits anyway, e.g. when it is waiting for input
+const interruptGracePeriod = 2 * time.Second
+
 // Execute adds all child commands to the root command and sets flags appropriately.
+// Ctrl-C or SIGTERM cancels the context passed to every command, which aborts
+// in-flight model requests.
 func Execute() error {
-	return rootCmd.Execute()
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// After the first signal, restore the default handling so a second
+	// Ctrl-C kills the process, and don't hang on a pending prompt
+	go func() {
+		<-ctx.Done()
+		stop()
+		time.Sleep(interruptGracePeriod)
+		os.Exit(130)
+	}()
+
+	err := rootCmd.ExecuteContext(ctx)
+
+	// The error shows only the server's message; the full body can help too
+	var apiErr *ollama.APIError
+	if errors.As(err, &apiErr) && apiErr.Message != "" && viper.GetBool("verbose") {
+		ui.ShowInfo("Server response: " + apiErr.Body)
+	}
+
+	return err
 }
 
 func init() {
@@ -35,16 +88,39 @@ func init() {
 
 	// Global flags
 	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.config/gh-smart-commit.yaml)")
-	rootCmd.PersistentFlags().String("ollama-host", "127.0.0.1:11434", "Ollama server host:port")
+	rootCmd.PersistentFlags().String("ollama-host", "127.0.0.1:11434", "Ollama server host:port, or a comma-separated list tried in order")
 	rootCmd.PersistentFlags().String("model", "llama3.1:8b", "Ollama model to use")
 	rootCmd.PersistentFlags().Float64("temperature", 0.3, "Model temperature (0.0-1.0)")
 	rootCmd.PersistentFlags().Bool("verbose", false, "Enable verbose output")
+	rootCmd.PersistentFlags().Bool("quiet", false, "Print only the result; send progress and prompts to stderr")
+	rootCmd.PersistentFlags().String("log-file", "", "Append prompts and raw model responses as JSON lines to this file")
+	rootCmd.PersistentFlags().String("color", ui.ColorAuto, "When to color output: auto, always or never")
+	rootCmd.PersistentFlags().Bool("no-emoji", false, "Replace emoji with ASCII markers, or leave out decorative ones, but keep colors, for terminals that can't show them")
+	rootCmd.PersistentFlags().StringArray("var", nil, "Set a template variable as key=value, available as {{.Vars.key}} (repeatable)")
+	rootCmd.PersistentFlags().String("profile", "", "Apply the settings under profiles.<name> in the config file")
+	rootCmd.PersistentFlags().Int("git-concurrency", runtime.NumCPU(), "Maximum number of git processes to run at once")
 
 	// Bind flags to viper
 	viper.BindPFlag("ollama.host", rootCmd.PersistentFlags().Lookup("ollama-host"))
 	viper.BindPFlag("ollama.model", rootCmd.PersistentFlags().Lookup("model"))
 	viper.BindPFlag("ollama.temperature", rootCmd.PersistentFlags().Lookup("temperature"))
 	viper.BindPFlag("verbose", rootCmd.PersistentFlags().Lookup("verbose"))
+	viper.BindPFlag("quiet", rootCmd.PersistentFlags().Lookup("quiet"))
+	viper.BindPFlag("debug.log_file", rootCmd.PersistentFlags().Lookup("log-file"))
+	viper.BindPFlag("git.concurrency", rootCmd.PersistentFlags().Lookup("git-concurrency"))
+	viper.BindPFlag("color", rootCmd.PersistentFlags().Lookup("color"))
+	viper.BindPFlag("profile", rootCmd.PersistentFlags().Lookup("profile"))
+
+	viper.SetDefault("api.kind", ollama.APIKindOllama)
+	viper.SetDefault("date.format", ui.DateShort)
+	viper.SetDefault("output.emoji", true)
+	viper.SetDefault("ollama.embed_model", ollama.DefaultEmbedModel)
+	viper.SetDefault("ollama.context_window", defaultContextWindow)
+	viper.SetDefault("ollama.stall_timeout", ollama.DefaultStallTimeout)
+	viper.SetDefault("diff.generated", git.DefaultGeneratedPatterns)
+	viper.SetDefault("bash.tree_ignore", defaultTreeIgnore)
+	viper.SetDefault("commit.types", prompt.DefaultCommitTypes)
+	viper.SetDefault("commit.verb_enforcement", verbEnforcementWarn)
 }
 
 // initConfig reads in config file and ENV variables if set.
@@ -67,10 +143,190 @@ func initConfig() {
 
 	// Environment variables
 	viper.SetEnvPrefix("GH_SMART_COMMIT")
+	viper.SetEnvKeyReplacer(strings.NewReplacer(".", "_", "-", "_"))
 	viper.AutomaticEnv()
 
 	// Read config file if it exists
 	if err := viper.ReadInConfig(); err == nil && viper.GetBool("verbose") {
 		fmt.Fprintf(os.Stderr, "Using config file: %s\n", viper.ConfigFileUsed())
 	}
+
+	// Settings shared in the repository override the global file
+	if path := repoConfigPath(); path != "" {
+		fileConfig, ignored, err := mergeRepoConfig(viper.GetViper(), path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading %s: %v\n", path, err)
+		} else {
+			repoConfig = fileConfig
+			if len(ignored) > 0 {
+				fmt.Fprintf(os.Stderr, "Warning: ignoring settings in %s that only the global config may set: %s\n", path, strings.Join(ignored, ", "))
+			}
+			if viper.GetBool("verbose") {
+				fmt.Fprintf(os.Stderr, "Using repository config file: %s\n", path)
+			}
+		}
+	}
+
+	// A profile overrides both files; explicit flags and env vars still win
+	if name := viper.GetString("profile"); name != "" {
+		profile, err := applyProfile(viper.GetViper(), name)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		profileConfig = profile
+		if viper.GetBool("verbose") {
+			fmt.Fprintf(os.Stderr, "Using profile: %s\n", name)
+		}
+	}
+
+	ui.SetQuiet(viper.GetBool("quiet"))
+	if err := ui.SetColorMode(viper.GetString("color")); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	noEmoji, _ := rootCmd.PersistentFlags().GetBool("no-emoji")
+	ui.SetEmoji(viper.GetBool("output.emoji") && !noEmoji)
+	if err := ui.SetDateFormat(viper.GetString("date.format")); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	git.SetConcurrency(viper.GetInt("git.concurrency"))
+
+	pairs, _ := rootCmd.PersistentFlags().GetStringArray("var")
+	vars, err := parseTemplateVars(pairs)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	promptVars = vars
+}
+
+// parseTemplateVars turns key=value pairs into a map. A later pair overrides
+// an earlier one with the same key.
+func parseTemplateVars(pairs []string) (map[string]string, error) {
+	vars := make(map[string]string, len(pairs))
+	for _, pair := range pairs {
+		key, value, ok := strings.Cut(pair, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid --var %q: expected key=value", pair)
+		}
+		vars[key] = value
+	}
+	return vars, nil
+}
+
+// repoCache opens the cache in repo's git directory, falling back to ./.git
+// when git can't report it
+func repoCache(ctx context.Context, repo git.Repository) *cache.Cache {
+	if c, err := cache.NewCacheForRepo(ctx, repo); err == nil {
+		return c
+	}
+	return cache.NewCache(".")
+}
+
+// newPromptBuilder returns a prompt builder with the configured
+// prompt.system_prefix and prompt.system_suffix
+func newPromptBuilder() *prompt.Builder {
+	builder := prompt.NewBuilder()
+	builder.SetSystemText(viper.GetString("prompt.system_prefix"), viper.GetString("prompt.system_suffix"))
+	return builder
+}
+
+// repoConfigPath returns the .gh-smart-commit.yaml at the root of the
+// current repository, or "" when there is none
+func repoConfigPath() string {
+	root, err := git.NewLocalRepo(".").GetTopLevel(context.Background())
+	if err != nil {
+		return ""
+	}
+
+	path := filepath.Join(root, repoConfigFileName)
+	if _, err := os.Stat(path); err != nil {
+		return ""
+	}
+	return path
+}
+
+// repoConfigKeys are the settings a repository config file may set: a
+// cloned repository is not trusted, so anything that picks the host the
+// diff is sent to, its credentials, redaction or files written is left to
+// the global config. An entry ending in "." allows every key under it.
+var repoConfigKeys = []string{
+	"commit.",
+	"commands.",
+	"diff.generated",
+	"diff.redact_patterns",
+	"smart-commit.",
+	"lint-suggestions.",
+	"branch-describe.",
+	"tag-suggest.",
+	"bash.tree_ignore",
+	"ollama.model",
+	"ollama.temperature",
+	"date.format",
+}
+
+// repoConfigAllows reports whether a repository config file may set key
+func repoConfigAllows(key string) bool {
+	for _, allowed := range repoConfigKeys {
+		if key == allowed || (strings.HasSuffix(allowed, ".") && strings.HasPrefix(key, allowed)) {
+			return true
+		}
+	}
+	return false
+}
+
+// mergeRepoConfig reads the repository config file at path and merges the
+// settings it may set over v. It returns those settings and the keys it
+// ignored. The global file stays the one `config set` writes to.
+func mergeRepoConfig(v *viper.Viper, path string) (*viper.Viper, []string, error) {
+	fileConfig := viper.New()
+	fileConfig.SetConfigFile(path)
+	fileConfig.SetConfigType("yaml")
+	if err := fileConfig.ReadInConfig(); err != nil {
+		return nil, nil, err
+	}
+
+	settings := viper.New()
+	var ignored []string
+	for _, key := range fileConfig.AllKeys() {
+		if repoConfigAllows(key) {
+			settings.Set(key, fileConfig.Get(key))
+		} else {
+			ignored = append(ignored, key)
+		}
+	}
+	sort.Strings(ignored)
+
+	// merged as config rather than set, so InConfig reports the repo's keys
+	allowed := viper.New()
+	if err := allowed.MergeConfigMap(settings.AllSettings()); err != nil {
+		return nil, nil, err
+	}
+	if err := v.MergeConfigMap(allowed.AllSettings()); err != nil {
+		return nil, nil, err
+	}
+	return allowed, ignored, nil
+}
+
+// applyProfile merges the settings under profiles.<name> over the config
+// files read into v and returns them. Because they are merged as config,
+// flags and environment variables keep precedence over the profile.
+func applyProfile(v *viper.Viper, name string) (*viper.Viper, error) {
+	// viper lower-cases map keys, so profiles are matched case-insensitively
+	key := "profiles." + strings.ToLower(name)
+	if !v.IsSet(key) {
+		return nil, fmt.Errorf("unknown profile: %s", name)
+	}
+
+	profile := viper.New()
+	if err := profile.MergeConfigMap(v.GetStringMap(key)); err != nil {
+		return nil, fmt.Errorf("failed to read profile %s: %w", name, err)
+	}
+	if err := v.MergeConfigMap(profile.AllSettings()); err != nil {
+		return nil, fmt.Errorf("failed to apply profile %s: %w", name, err)
+	}
+	return profile, nil
 }
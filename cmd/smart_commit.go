@@ -3,14 +3,17 @@ package cmd
 import (
 	"bufio"
 	"context"
+	"errors"
 	"fmt"
 	"os"
-	"os/exec"
+	"regexp"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 
+	"gh-smart-commit/pkg/cache"
 	"gh-smart-commit/pkg/git"
 	"gh-smart-commit/pkg/ollama"
 	"gh-smart-commit/pkg/prompt"
@@ -39,18 +42,134 @@ func init() {
 	// Command-specific flags
 	smartCommitCmd.Flags().Bool("auto-commit", false, "Automatically commit with generated message (no confirmation)")
 	smartCommitCmd.Flags().Bool("dry-run", false, "Show generated message without committing")
+	smartCommitCmd.Flags().String("out", "", "Write the message to this file instead of committing, e.g. for git commit -e -F <file>")
+	smartCommitCmd.Flags().Bool("overwrite", false, "Let --out overwrite a file that already holds a message")
+	smartCommitCmd.Flags().BoolP("all", "a", false, "Stage changes to tracked files first, like git commit -a (untracked files are left alone)")
+	smartCommitCmd.Flags().BoolP("interactive", "i", false, "Pick which staged files to keep before generating; the rest are unstaged")
 	smartCommitCmd.Flags().Int("max-diff-lines", 500, "Maximum diff lines to include in prompt")
+	smartCommitCmd.Flags().Bool("summarize-overflow", false, "Summarize hunks beyond --max-diff-lines with an extra model call instead of cutting them")
+	smartCommitCmd.Flags().Bool("chunk", false, "Split a diff over --max-diff-lines into chunks of files, summarize each with its own model call and write the message from the summaries")
+	smartCommitCmd.Flags().Bool("deterministic", false, "Use temperature 0 and a fixed seed, and reuse cached messages for identical diffs")
+	smartCommitCmd.Flags().String("webhook", "", "POST the generated message as JSON to this URL")
+	smartCommitCmd.Flags().Bool("force", false, "Generate a message even while a merge or rebase is in progress")
+	smartCommitCmd.Flags().Bool("incremental", false, "Only describe changes staged since the last --incremental commit on this branch")
+	smartCommitCmd.Flags().Bool("reset-incremental", false, "Clear the --incremental marker for this branch and exit")
+	smartCommitCmd.Flags().Bool("amend", false, "Amend HEAD, offering to keep its message if it still fits")
+	smartCommitCmd.Flags().Bool("show-hook-output", false, "Show everything git and its hooks print while committing")
+	smartCommitCmd.Flags().Bool("print-prompt", false, "Print the prompt that would be sent to the model and exit")
+	smartCommitCmd.Flags().Bool("body", false, "Generate a wrapped body explaining why, after the subject line")
+	viper.BindPFlag("commit.body", smartCommitCmd.Flags().Lookup("body"))
+	smartCommitCmd.Flags().StringArray("coauthor", nil, "Append a Co-authored-by trailer for \"Name <email>\" (repeatable)")
+	smartCommitCmd.Flags().Bool("warn-untracked", true, "Warn about untracked files that won't be part of the commit")
+	viper.BindPFlag("commit.warn_untracked", smartCommitCmd.Flags().Lookup("warn-untracked"))
+	smartCommitCmd.Flags().Bool("similar-examples", false, "Show the model the most similar past commit messages as style examples (uses ollama.embed_model)")
+	viper.BindPFlag("commit.similar_examples", smartCommitCmd.Flags().Lookup("similar-examples"))
+	smartCommitCmd.Flags().Bool("conventional", false, "Generate a conventional commit message using a type from commit.types")
+	viper.BindPFlag("commit.conventional", smartCommitCmd.Flags().Lookup("conventional"))
+	smartCommitCmd.Flags().Bool("strict", false, "Regenerate once and then fail when the type is not in commit.types (implies --conventional)")
+	smartCommitCmd.Flags().Bool("keep-partial", true, "Offer the complete lines of a response cut off by a connection error instead of failing")
+	viper.BindPFlag("commit.keep_partial", smartCommitCmd.Flags().Lookup("keep-partial"))
+	smartCommitCmd.Flags().Bool("no-verify", false, "Skip the pre-commit and commit-msg hooks (ignored with --dry-run)")
+	smartCommitCmd.Flags().Int("retries", 0, "Regenerate up to N times when the message fails validation, e.g. a subject over 72 characters")
+	viper.BindPFlag("commit.retries", smartCommitCmd.Flags().Lookup("retries"))
+	smartCommitCmd.Flags().Int("max-files", 0, "Describe the change by directory instead of line by line when more files than this are staged (0 = no limit)")
+	viper.BindPFlag("commit.max_files", smartCommitCmd.Flags().Lookup("max-files"))
+	smartCommitCmd.Flags().Bool("show-stat", false, "Show a summary of the staged files before generating (always shown with --verbose)")
+	smartCommitCmd.Flags().Bool("ignore-whitespace", false, "Leave whitespace-only changes out of the diff sent to the model")
+	smartCommitCmd.Flags().Bool("include-stat-in-body", false, "Append the staged diff stat to the commit body, before any trailers")
+	viper.BindPFlag("commit.include_stat_in_body", smartCommitCmd.Flags().Lookup("include-stat-in-body"))
+	smartCommitCmd.Flags().StringArray("context-file", nil, "Show the model this file for context, e.g. where a symbol used in the diff is defined (repeatable)")
+	smartCommitCmd.Flags().Bool("gitmoji", false, "Start the subject with a gitmoji for the kind of change, e.g. ✨ or 🐛")
+	smartCommitCmd.Flags().String("guidance", "", "Extra instructions for the model, e.g. \"mention the performance impact\"")
 }
 
 func runSmartCommit(cmd *cobra.Command, args []string) error {
-	ctx := context.Background()
+	ctx := cmd.Context()
 
 	// Get flags
 	autoCommit, _ := cmd.Flags().GetBool("auto-commit")
 	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	outFile, _ := cmd.Flags().GetString("out")
+	stageAll, _ := cmd.Flags().GetBool("all")
+	interactive, _ := cmd.Flags().GetBool("interactive")
 	maxDiffLines, _ := cmd.Flags().GetInt("max-diff-lines")
+	summarize, _ := cmd.Flags().GetBool("summarize-overflow")
+	chunk, _ := cmd.Flags().GetBool("chunk")
+	force, _ := cmd.Flags().GetBool("force")
+	overwrite, _ := cmd.Flags().GetBool("overwrite")
+	deterministic, _ := cmd.Flags().GetBool("deterministic")
+	webhookURL, _ := cmd.Flags().GetString("webhook")
+	incremental, _ := cmd.Flags().GetBool("incremental")
+	resetIncremental, _ := cmd.Flags().GetBool("reset-incremental")
+	showHookOutput, _ := cmd.Flags().GetBool("show-hook-output")
+	amend, _ := cmd.Flags().GetBool("amend")
+	printPromptOnly, _ := cmd.Flags().GetBool("print-prompt")
+	withBody := viper.GetBool("commit.body")
+	coauthorFlags, _ := cmd.Flags().GetStringArray("coauthor")
+	conventional := viper.GetBool("commit.conventional")
+	strict, _ := cmd.Flags().GetBool("strict")
+	gitmoji, _ := cmd.Flags().GetBool("gitmoji")
+	showStat, _ := cmd.Flags().GetBool("show-stat")
+	noVerify, _ := cmd.Flags().GetBool("no-verify")
+	ignoreWhitespace, _ := cmd.Flags().GetBool("ignore-whitespace")
+	maxFiles := viper.GetInt("commit.max_files")
+	includeStat := viper.GetBool("commit.include_stat_in_body")
+	contextFilePaths, _ := cmd.Flags().GetStringArray("context-file")
+	guidance, _ := cmd.Flags().GetString("guidance")
 	verbose := viper.GetBool("verbose")
 
+	// Co-authors from the config come first, then any given on the command line
+	coauthors := append(viper.GetStringSlice("commit.coauthors"), coauthorFlags...)
+	for _, coauthor := range coauthors {
+		if _, err := prompt.ParseCoAuthor(coauthor); err != nil {
+			ui.ShowError(err.Error())
+			return err
+		}
+	}
+
+	if gitmoji && (conventional || strict) {
+		ui.ShowError("--gitmoji cannot be combined with --conventional or --strict")
+		return fmt.Errorf("--gitmoji cannot be combined with --conventional or --strict")
+	}
+
+	// Only conventional messages carry a type to check
+	var commitTypes []string
+	if conventional || strict {
+		commitTypes = configuredCommitTypes()
+	}
+
+	// Natural-language messages can be held to an approved set of leading verbs
+	var allowedVerbs []string
+	verbMode := strings.ToLower(viper.GetString("commit.verb_enforcement"))
+	if commitTypes == nil && !gitmoji {
+		allowedVerbs = viper.GetStringSlice("commit.allowed_verbs")
+	}
+	if len(allowedVerbs) > 0 {
+		if _, err := parseVerbEnforcement(verbMode); err != nil {
+			ui.ShowError("Invalid commit.verb_enforcement: " + err.Error())
+			return err
+		}
+	}
+
+	rules := messageRules{
+		Types:       commitTypes,
+		Strict:      strict,
+		Verbs:       allowedVerbs,
+		VerbMode:    verbMode,
+		Retries:     viper.GetInt("commit.retries"),
+		KeepPartial: viper.GetBool("commit.keep_partial"),
+	}
+	if rules.Retries < 0 {
+		ui.ShowError("--retries must not be negative")
+		return fmt.Errorf("invalid number of retries: %d", rules.Retries)
+	}
+
+	transform, err := messageTransformConfig()
+	if err != nil {
+		ui.ShowError("Invalid commit.prefix_from_branch.pattern: " + err.Error())
+		return err
+	}
+
 	// Initialize Git repository
 	repo := git.NewLocalRepo(".")
 
@@ -65,31 +184,276 @@ func runSmartCommit(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("not inside a Git repository")
 	}
 
-	// Get staged diff
-	diff, err := repo.GetStagedDiff(ctx)
-	if err != nil {
-		ui.ShowError("Failed to get staged diff: " + err.Error())
-		return err
+	// Refuse to generate a fresh message in the middle of a merge or rebase
+	if state, err := repo.RepoState(ctx); err == nil && state != git.StateClean {
+		if !force {
+			ui.ShowError(fmt.Sprintf("A %s is in progress. Finish or abort it first, or use --force", state))
+			return fmt.Errorf("%s in progress", state)
+		}
+		ui.ShowWarning(fmt.Sprintf("A %s is in progress; continuing because of --force", state))
+	}
+
+	// Get repository context
+	repoName, _ := repo.GetRepoName(ctx)
+	branch, _ := repo.GetCurrentBranch(ctx)
+
+	cacheInstance := repoCache(ctx, repo)
+	marker := incrementalMarkerName(branch)
+
+	if resetIncremental {
+		if err := cacheInstance.ClearMarker(marker); err != nil {
+			ui.ShowError("Failed to clear incremental marker: " + err.Error())
+			return err
+		}
+		ui.ShowSuccess("Incremental marker cleared")
+		return nil
+	}
+
+	if chunk && summarize {
+		ui.ShowError("--chunk cannot be combined with --summarize-overflow")
+		return fmt.Errorf("--chunk cannot be combined with --summarize-overflow")
+	}
+
+	if amend && incremental {
+		ui.ShowError("--amend cannot be combined with --incremental")
+		return fmt.Errorf("--amend cannot be combined with --incremental")
+	}
+
+	// The amended commit's own files can't be deselected by unstaging
+	if amend && interactive {
+		ui.ShowError("--amend cannot be combined with --interactive")
+		return fmt.Errorf("--amend cannot be combined with --interactive")
+	}
+
+	if interactive && !ui.IsInteractive() {
+		ui.ShowError("--interactive needs a terminal to ask which files to keep")
+		return fmt.Errorf("--interactive needs a terminal")
+	}
+
+	// Refuse before generating, not after, to overwrite a message kept in --out
+	if outFile != "" && !overwrite {
+		if err := checkMessageFile(outFile); err != nil {
+			ui.ShowError(err.Error())
+			return err
+		}
+	}
+
+	// Like git commit -a, pick up edits to tracked files that weren't staged.
+	// Unless a commit is made, the index goes back to how it was, so dry
+	// runs and cancelled prompts leave nothing staged behind.
+	restoreIndex := false
+	if stageAll {
+		indexTree, err := repo.GetIndexTree(ctx)
+		if err != nil {
+			ui.ShowError("Failed to save the index: " + err.Error())
+			return err
+		}
+		staged, err := repo.StageTrackedChanges(ctx)
+		if err != nil {
+			ui.ShowError("Failed to stage tracked changes: " + err.Error())
+			return err
+		}
+		if len(staged) > 0 {
+			ui.ShowInfo(fmt.Sprintf("Staged %d tracked %s: %s", len(staged), pluralFiles(len(staged)), fileList(staged)))
+			restoreIndex = true
+			defer func() {
+				if !restoreIndex {
+					return
+				}
+				if err := repo.RestoreIndex(context.WithoutCancel(ctx), indexTree); err != nil {
+					ui.ShowWarning("Failed to unstage the changes --all staged: " + err.Error())
+				}
+			}()
+		}
+	}
+
+	// Scope the commit before the model sees it
+	if interactive {
+		unstaged, err := chooseStagedFiles(ctx, repo, os.Stdin)
+		if len(unstaged) > 0 {
+			ui.ShowInfo(fmt.Sprintf("Unstaged %d %s: %s", len(unstaged), pluralFiles(len(unstaged)), fileList(unstaged)))
+		}
+		if err != nil {
+			ui.ShowError("Failed to pick staged files: " + err.Error())
+			return err
+		}
+	}
+
+	// In incremental mode, diff against the tree recorded by the last run
+	var since string
+	if incremental {
+		if tree, found, err := cacheInstance.GetMarker(marker); err == nil && found {
+			since = tree
+		}
+	}
+
+	var diff string
+	if since != "" {
+		diff, err = repo.GetStagedDiffSince(ctx, since)
+		if err != nil {
+			ui.ShowWarning("Incremental marker is no longer valid; using the full staged diff")
+			since = ""
+		} else if verbose {
+			ui.ShowInfo(fmt.Sprintf("Describing changes staged since tree %.7s", since))
+		}
+	}
+
+	// When amending, describe HEAD's changes together with anything staged
+	var current git.Commit
+	if amend {
+		current, err = repo.GetCommit(ctx, "HEAD")
+		if err != nil {
+			ui.ShowError("Nothing to amend: " + err.Error())
+			return err
+		}
+
+		diff, err = amendDiff(ctx, repo)
+		if err != nil {
+			ui.ShowError("Failed to get amend diff: " + err.Error())
+			return err
+		}
+	} else if since == "" {
+		diff, err = repo.GetStagedDiffWithOpts(ctx, git.DiffOptions{IgnoreWhitespace: ignoreWhitespace})
+		if err != nil {
+			ui.ShowError("Failed to get staged diff: " + err.Error())
+			return err
+		}
+
+		// A pure reformat has nothing left without whitespace; describe it as is
+		if ignoreWhitespace && strings.TrimSpace(diff) == "" {
+			diff, err = repo.GetStagedDiff(ctx)
+			if err != nil {
+				ui.ShowError("Failed to get staged diff: " + err.Error())
+				return err
+			}
+			if strings.TrimSpace(diff) != "" {
+				ui.ShowInfo("The staged changes are whitespace-only; using the full diff")
+			}
+		}
 	}
 
 	if strings.TrimSpace(diff) == "" {
+		if since != "" {
+			ui.ShowWarning("Nothing new has been staged since the last incremental run")
+			return fmt.Errorf("no changes staged since last incremental run")
+		}
 		ui.ShowWarning("No staged changes found. Please stage your changes with 'git add' first")
 		return fmt.Errorf("no staged changes found")
 	}
 
-	// Truncate diff if too long
-	if maxDiffLines > 0 {
+	// A last look at what's staged before waiting on the model. Amend and
+	// incremental runs describe a different diff, so they skip it.
+	if (showStat || verbose) && !amend && since == "" {
+		if stat, err := repo.GetStagedDiffStat(ctx); err != nil {
+			ui.ShowWarning("Failed to get diff stat: " + err.Error())
+		} else {
+			ui.Print(ui.NewContextFormatter().FormatDiffStat(stat))
+		}
+	}
+
+	// Don't regenerate a message that still describes the amended commit
+	if amend && currentMessageFits(current.FullMessage(), diff) {
+		keep, err := offerKeepMessage(current.FullMessage(), autoCommit)
+		if err != nil {
+			ui.ShowError("Failed to read user input: " + err.Error())
+			return err
+		}
+
+		if keep {
+			if dryRun {
+				ui.ShowInfo("Dry run mode - keeping the current message, not amending")
+				return nil
+			}
+			output, err := repo.Amend(ctx, "", git.CommitOptions{NoVerify: noVerify})
+			return reportCommit(output, err, showHookOutput)
+		}
+	}
+
+	// Name every area the change touches, so the message doesn't only
+	// describe the largest one. Amend and incremental runs describe a
+	// different diff than what's staged, so they read the files from it.
+	var files []string
+	if !amend && since == "" {
+		files, err = repo.GetStagedFiles(ctx)
+		if err != nil && verbose {
+			ui.ShowWarning("Failed to list staged files: " + err.Error())
+		}
+	} else {
+		files = git.DiffFiles(diff)
+	}
+	areas := changedAreas(files)
+
+	// Binary and generated files only add noise to the prompt; describe
+	// asset changes in words instead
+	assets := git.DescribeAssets(diff)
+	diff, err = filterPromptDiff(diff)
+	if err != nil {
+		ui.ShowError(err.Error())
+		return err
+	}
+
+	// Line by line, hundreds of files make for a vague message; say where
+	// the changes are instead
+	if maxFiles > 0 {
+		if files := git.DiffFiles(diff); len(files) > maxFiles {
+			diff = fileGroupSummary(files)
+			if verbose {
+				ui.ShowInfo(fmt.Sprintf("%d files changed, over --max-files %d; describing them by directory", len(files), maxFiles))
+			}
+		}
+	}
+
+	// Fit the diff into the budget, by summarizing or by truncating
+	overBudget := maxDiffLines > 0 && strings.Count(diff, "\n") > maxDiffLines
+	if overBudget && (summarize || chunk) && printPromptOnly {
+		ui.ShowWarning("--print-prompt skips the extra summary calls; showing the truncated diff")
+	}
+
+	if overBudget && chunk && !printPromptOnly {
+		diff, err = chunkSummaries(ctx, diff, maxDiffLines, deterministic)
+		if err != nil {
+			ui.ShowError("Failed to summarize diff chunks: " + err.Error())
+			return err
+		}
+	} else if overBudget && summarize && !printPromptOnly {
+		diff, err = summarizeOverflow(ctx, diff, maxDiffLines, deterministic)
+		if err != nil {
+			ui.ShowError("Failed to summarize diff overflow: " + err.Error())
+			return err
+		}
+	} else if maxDiffLines > 0 {
 		diff = git.TruncateDiff(diff, maxDiffLines)
 	}
 
-	// Get repository context
-	repoName, _ := repo.GetRepoName(ctx)
-	branch, _ := repo.GetCurrentBranch(ctx)
+	// Past messages for similar changes show the model the repository's style
+	var examples []string
+	if viper.GetBool("commit.similar_examples") {
+		if printPromptOnly {
+			ui.ShowWarning("--print-prompt skips the similar commit lookup")
+		} else if examples, err = similarCommitExamples(ctx, repo, cacheInstance, diff); err != nil {
+			ui.ShowWarning("Failed to find similar commits: " + err.Error())
+		} else if verbose {
+			ui.ShowInfo(fmt.Sprintf("Using %d similar commit messages as examples", len(examples)))
+		}
+	}
+
+	// Files the diff refers to, e.g. where a called function is defined
+	extraFiles, skippedFiles, err := readContextFiles(contextFilePaths, viper.GetInt("ollama.context_window"))
+	if err == nil {
+		extraFiles, err = redactContextFiles(extraFiles)
+	}
+	if err != nil {
+		ui.ShowError(err.Error())
+		return err
+	}
+	for _, path := range skippedFiles {
+		ui.ShowWarning(fmt.Sprintf("Left out --context-file %s: no room left in the context window (ollama.context_window)", path))
+	}
 
 	// Show context info if verbose
 	contextFormatter := ui.NewContextFormatter()
 	if info := contextFormatter.FormatRepoInfo(repoName, branch, verbose); info != "" {
-		fmt.Print(info)
+		ui.Print(info)
 	}
 
 	if verbose {
@@ -98,149 +462,644 @@ func runSmartCommit(cmd *cobra.Command, args []string) error {
 	}
 
 	// Build prompt
-	builder := prompt.NewBuilder()
+	builder := newPromptBuilder()
 	promptCtx := prompt.Context{
 		Repo:   repoName,
 		Branch: branch,
 		Diff:   diff,
-		Rules: []string{
+		Rules: verbRules([]string{
 			"Commit title max 72 chars",
 			"Use imperative mood",
 			"Follow Conventional Commits standard",
-		},
+		}, allowedVerbs),
+		Body:         withBody,
+		Assets:       assets,
+		Types:        commitTypes,
+		Examples:     examples,
+		Vars:         promptVars,
+		ChangedAreas: areas,
+		ExtraFiles:   extraFiles,
+	}
+	if gitmoji {
+		promptCtx.Gitmojis = prompt.Gitmojis
 	}
 
-	systemPrompt, userPrompt, err := builder.Build("smart-commit", promptCtx)
+	// Ollama silently drops the start of a prompt that overflows the context
+	// window, which loses the instructions; shrink the diff instead
+	window := viper.GetInt("ollama.context_window")
+	systemPrompt, userPrompt, fittedLines, err := fitPromptToWindow(builder, "smart-commit", promptCtx, window)
 	if err != nil {
 		ui.ShowError("Failed to build prompt: " + err.Error())
 		return err
 	}
-
-	if verbose {
-		ui.ShowInfo("Sending request to Ollama...")
-	}
-
-	// Create Ollama client
-	ollamaHost := viper.GetString("ollama.host")
-	if !strings.HasPrefix(ollamaHost, "http") {
-		ollamaHost = "http://" + ollamaHost
+	if fittedLines > 0 {
+		ui.ShowWarning(fmt.Sprintf("Diff cut to %d lines to fit the %d-token context window (ollama.context_window); try --chunk for large changes", fittedLines, window))
 	}
+	userPrompt = withGuidance(userPrompt, guidance)
 
-	client := ollama.NewClient(ollamaHost)
-
-	// Test connection
-	if err := client.Ping(ctx); err != nil {
-		ui.ShowError(fmt.Sprintf("Failed to connect to Ollama at %s: %s", ollamaHost, err.Error()))
-		return err
+	if printPromptOnly {
+		printPrompt(systemPrompt, userPrompt)
+		return nil
 	}
 
 	// Prepare chat request
 	chatReq := ollama.ChatRequest{
-		Model: viper.GetString("ollama.model"),
+		Model: commandModel("smart-commit"),
 		Messages: []ollama.Message{
 			{Role: "system", Content: systemPrompt},
 			{Role: "user", Content: userPrompt},
 		},
 		Options: ollama.Options{
-			Temperature: float32(viper.GetFloat64("ollama.temperature")),
+			Temperature: commandTemperature("smart-commit"),
+			NumCtx:      viper.GetInt("ollama.context_window"),
 		},
+		Think: thinkOption(),
 	}
 
-	// Create beautiful streaming spinner
-	spinner := ui.NewStreamingSpinner("🤖 Generating commit message")
-	spinner.Start()
+	// In deterministic mode the same prompt always maps to the same cached message
+	var message string
+	var cacheKey string
+	if deterministic {
+		applyDeterministic(&chatReq)
+		cacheKey = deterministicCacheKey(chatReq)
 
-	respChan, errChan := client.Chat(ctx, chatReq)
+		if cached, found, err := cacheInstance.Get(cacheKey); err == nil && found {
+			message = cached
+			if verbose {
+				ui.ShowInfo("Using cached deterministic message")
+			}
+		}
+	}
 
-	var commitMessage strings.Builder
-	var streamErr error
+	var client *ollama.FailoverClient
+	if message == "" {
+		if verbose {
+			ui.ShowInfo("Sending request to Ollama...")
+		}
 
-	for {
-		select {
-		case resp, ok := <-respChan:
-			if !ok {
-				// Channel closed, we're done
-				goto StreamComplete
+		var model string
+		client, model, err = connectSmartCommit(ctx, chatReq.Model)
+		if err != nil {
+			return err
+		}
+		if model != chatReq.Model {
+			chatReq.Model = model
+			if deterministic {
+				cacheKey = deterministicCacheKey(chatReq)
 			}
-			spinner.Update()
-			commitMessage.WriteString(resp.Message.Content)
+		}
 
-		case err := <-errChan:
-			streamErr = err
-			goto StreamComplete
+		message, err = generateSmartCommit(ctx, client, chatReq, rules)
+		var partialErr *partialResponseError
+		if errors.As(err, &partialErr) {
+			// Never commit a cut-off message without asking
+			ui.ShowWarning("The " + partialErr.Error() + "; showing the complete lines received (--keep-partial=false to fail instead)")
+			autoCommit = false
+			err = nil
+		}
+		if err != nil {
+			ui.ShowError("Failed to generate commit message: " + err.Error())
+			return err
+		}
 
-		case <-ctx.Done():
-			return ctx.Err()
+		if deterministic && partialErr == nil && message != "" && rules.accepts(message) {
+			if err := cacheInstance.Set(cacheKey, message, deterministicCacheTTL); err != nil && verbose {
+				ui.ShowWarning("Failed to cache result: " + err.Error())
+			}
 		}
 	}
 
-StreamComplete:
-	spinner.Stop()
+	// finishMessage checks and completes a generated message; regenerated
+	// messages go through it again
+	finishMessage := func(message string) (string, error) {
+		if message == "" {
+			ui.ShowError("Generated commit message is empty")
+			return "", fmt.Errorf("generated commit message is empty")
+		}
+
+		// Models often pick a plausible but wrong emoji; fix the ones we can tell
+		if gitmoji {
+			message = prompt.NormalizeGitmoji(message)
+			if err := prompt.ValidateGitmoji(message); err != nil {
+				ui.ShowWarning("Validation warning: " + err.Error())
+			}
+		}
+
+		if commitTypes != nil {
+			if err := prompt.ValidateCommitType(message, commitTypes); err != nil {
+				if strict {
+					ui.ShowError("Rejected commit message \"" + strings.SplitN(message, "\n", 2)[0] + "\": " + err.Error())
+					return "", err
+				}
+				ui.ShowWarning("Validation warning: " + err.Error())
+			}
+		}
+
+		if len(allowedVerbs) > 0 {
+			if err := prompt.ValidateVerb(message, allowedVerbs); err != nil {
+				if verbMode != verbEnforcementWarn {
+					ui.ShowError("Rejected commit message \"" + strings.SplitN(message, "\n", 2)[0] + "\": " + err.Error())
+					return "", err
+				}
+				ui.ShowWarning("Validation warning: " + err.Error())
+			}
+		}
+
+		// The ticket prefix goes on after the type and verb checks, which look at
+		// the start of the subject, but before the length check so it counts
+		message = prompt.TransformMessage(message, branch, transform)
 
-	if streamErr != nil {
-		ui.ShowError("Failed to generate commit message: " + streamErr.Error())
-		return streamErr
+		// Validate the message
+		if err := prompt.ValidateCommitMessage(message); err != nil {
+			ui.ShowWarning("Validation warning: " + err.Error())
+		}
+
+		// The stat describes what is staged, which isn't the whole amended commit
+		if includeStat && amend {
+			ui.ShowWarning("--include-stat-in-body is ignored with --amend")
+		} else if includeStat {
+			stat, err := repo.GetStagedDiffStat(ctx)
+			if err != nil {
+				ui.ShowWarning("Failed to get diff stat: " + err.Error())
+			} else {
+				message = prompt.AppendDiffStat(message, stat)
+			}
+		}
+
+		// Trailers are appended after validation so they never count against the subject line
+		message, err := prompt.AppendCoAuthors(message, coauthors)
+		if err != nil {
+			ui.ShowError(err.Error())
+			return "", err
+		}
+		return message, nil
 	}
 
-	// Clean up the generated message
-	message := prompt.SanitizeCommitMessage(commitMessage.String())
+	message, err = finishMessage(message)
+	if err != nil {
+		return err
+	}
+	showGeneratedMessage(message)
 
-	if message == "" {
-		ui.ShowError("Generated commit message is empty")
-		return fmt.Errorf("generated commit message is empty")
+	// The webhook gets the message that is used, so it goes out once the
+	// user has stopped regenerating
+	notifyWebhook := func() {
+		sendWebhook(ctx, webhookURL, commitMessagePayload{
+			Event:   "commit-message",
+			Repo:    repoName,
+			Branch:  branch,
+			Model:   chatReq.Model,
+			Message: message,
+		})
 	}
 
-	// Validate the message
-	if err := prompt.ValidateCommitMessage(message); err != nil {
-		ui.ShowWarning("Validation warning: " + err.Error())
+	// New files are easy to forget to stage; point them out before committing
+	if viper.GetBool("commit.warn_untracked") {
+		if untracked, err := repo.GetUntrackedFiles(ctx); err == nil && len(untracked) > 0 {
+			ui.ShowWarning(untrackedWarning(untracked))
+		}
 	}
 
-	// Display the generated message beautifully
-	formatter := ui.NewCommitMessageFormatter()
-	fmt.Print(formatter.FormatGenerated(message))
+	if outFile != "" {
+		notifyWebhook()
+		if err := os.WriteFile(outFile, []byte(message+"\n"), 0644); err != nil {
+			ui.ShowError("Failed to write message: " + err.Error())
+			return err
+		}
+		ui.ShowSuccess(fmt.Sprintf("Message written to %s - not committing", outFile))
+		return nil
+	}
 
 	if dryRun {
+		notifyWebhook()
 		ui.ShowInfo("Dry run mode - not committing")
 		return nil
 	}
 
-	// Ask for confirmation unless auto-commit is enabled
-	if !autoCommit {
-		fmt.Print(formatter.FormatConfirmation())
-		reader := bufio.NewReader(os.Stdin)
-		response, err := reader.ReadString('\n')
+	// Ask for confirmation unless auto-commit is enabled. "r" asks for
+	// guidance and regenerates, as often as the user likes.
+	formatter := ui.NewCommitMessageFormatter()
+	reader := bufio.NewReader(os.Stdin)
+	for !autoCommit {
+		ui.Print(formatter.FormatConfirmation())
+		answer, err := askCommitAnswer(reader)
 		if err != nil {
 			ui.ShowError("Failed to read user input: " + err.Error())
 			return err
 		}
-
-		response = strings.ToLower(strings.TrimSpace(response))
-		if response != "y" && response != "yes" {
+		if answer == answerCommit {
+			break
+		}
+		if answer == answerCancel {
 			ui.ShowInfo("Commit cancelled")
 			return nil
 		}
+
+		ui.Print(formatter.FormatGuidancePrompt())
+		extra, err := askGuidance(reader)
+		if err != nil {
+			ui.ShowError("Failed to read user input: " + err.Error())
+			return err
+		}
+
+		// A cached deterministic message was shown without connecting
+		if client == nil {
+			client, chatReq.Model, err = connectSmartCommit(ctx, chatReq.Model)
+			if err != nil {
+				return err
+			}
+		}
+
+		// Later guidance builds on earlier guidance, so keep the conversation
+		chatReq = guidanceRequest(chatReq, message, extra)
+		regenerated, err := generateSmartCommit(ctx, client, chatReq, rules)
+		var partialErr *partialResponseError
+		if errors.As(err, &partialErr) {
+			ui.ShowWarning("The " + partialErr.Error() + "; showing the complete lines received (--keep-partial=false to fail instead)")
+			err = nil
+		}
+		if err != nil {
+			ui.ShowError("Failed to generate commit message: " + err.Error())
+			return err
+		}
+
+		message, err = finishMessage(regenerated)
+		if err != nil {
+			return err
+		}
+		showGeneratedMessage(message)
 	}
+	notifyWebhook()
 
 	// Commit the changes
 	if verbose {
 		ui.ShowInfo("Committing changes...")
 	}
 
-	commitCmd := fmt.Sprintf(`git commit -m %q`, message)
-	if err := runShellCommand(ctx, commitCmd); err != nil {
+	commitOpts := git.CommitOptions{NoVerify: noVerify}
+	var output string
+	if amend {
+		output, err = repo.Amend(ctx, message, commitOpts)
+	} else {
+		output, err = repo.Commit(ctx, message, commitOpts)
+	}
+
+	if err := reportCommit(output, err, showHookOutput); err != nil {
+		return err
+	}
+	restoreIndex = false
+
+	// Record the committed tree so the next incremental run starts from
+	// here. Dry runs, cancelled prompts and failed commits leave it alone.
+	if incremental {
+		if tree, err := repo.GetIndexTree(ctx); err == nil {
+			if err := cacheInstance.SetMarker(marker, tree); err != nil && verbose {
+				ui.ShowWarning("Failed to record incremental marker: " + err.Error())
+			}
+		}
+	}
+	return nil
+}
+
+// connectSmartCommit connects to the configured Ollama host and returns the
+// client with the model to use, which differs from model when it isn't
+// installed and the user picks another
+func connectSmartCommit(ctx context.Context, model string) (*ollama.FailoverClient, string, error) {
+	client := newOllamaClient()
+
+	// Test connection
+	if err := connectOllama(ctx, client); err != nil {
+		return nil, "", err
+	}
+
+	model, err := ensureModel(ctx, client, model)
+	if err != nil {
+		ui.ShowError(err.Error())
+		return nil, "", err
+	}
+	return client, model, nil
+}
+
+// showGeneratedMessage displays the generated message beautifully, or just
+// the message in quiet mode
+func showGeneratedMessage(message string) {
+	if ui.IsQuiet() {
+		fmt.Println(message)
+		return
+	}
+	fmt.Print(ui.NewCommitMessageFormatter().FormatGenerated(message))
+}
+
+// checkMessageFile fails when path already holds a message, so --out doesn't
+// overwrite one without --overwrite. A missing or blank file is fine.
+func checkMessageFile(path string) error {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	if strings.TrimSpace(string(data)) != "" {
+		return fmt.Errorf("%s is not empty; use --overwrite to replace it", path)
+	}
+	return nil
+}
+
+// listedFilesMax caps how many files a message names
+const listedFilesMax = 5
+
+// fileList names up to listedFilesMax files and counts the rest
+func fileList(files []string) string {
+	names := files
+	if len(names) > listedFilesMax {
+		names = names[:listedFilesMax]
+	}
+
+	list := strings.Join(names, ", ")
+	if more := len(files) - len(names); more > 0 {
+		list += fmt.Sprintf(" and %d more", more)
+	}
+	return list
+}
+
+// pluralFiles returns "file" or "files" for n files
+func pluralFiles(n int) string {
+	if n == 1 {
+		return "file"
+	}
+	return "files"
+}
+
+// untrackedWarning lists untracked files that the commit will not include
+func untrackedWarning(files []string) string {
+	noun := "file is"
+	if len(files) != 1 {
+		noun = "files are"
+	}
+	return fmt.Sprintf("%d untracked %s not part of this commit: %s (git add them or use --warn-untracked=false)", len(files), noun, fileList(files))
+}
+
+// messageTransformConfig reads commit.prefix_from_branch. No pattern means
+// messages are left as generated.
+func messageTransformConfig() (prompt.TransformConfig, error) {
+	cfg := prompt.TransformConfig{
+		PrefixFormat: viper.GetString("commit.prefix_from_branch.format"),
+	}
+	pattern := viper.GetString("commit.prefix_from_branch.pattern")
+	if pattern == "" {
+		return cfg, nil
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return cfg, err
+	}
+	cfg.TicketPattern = re
+	return cfg, nil
+}
+
+// configuredCommitTypes returns the allowed conventional commit types from
+// commit.types, falling back to the defaults when the list is empty
+func configuredCommitTypes() []string {
+	var types []string
+	for _, t := range viper.GetStringSlice("commit.types") {
+		if t = strings.TrimSpace(t); t != "" {
+			types = append(types, t)
+		}
+	}
+	if len(types) == 0 {
+		return prompt.DefaultCommitTypes
+	}
+	return types
+}
+
+// Modes for commit.verb_enforcement
+const (
+	verbEnforcementWarn   = "warn"   // show a warning and continue
+	verbEnforcementRetry  = "retry"  // ask the model once more, then reject
+	verbEnforcementReject = "reject" // refuse the message straight away
+)
+
+// parseVerbEnforcement accepts the commit.verb_enforcement modes
+func parseVerbEnforcement(value string) (interface{}, error) {
+	switch value {
+	case verbEnforcementWarn, verbEnforcementRetry, verbEnforcementReject:
+		return value, nil
+	default:
+		return nil, fmt.Errorf("expected %s, %s or %s, got %q", verbEnforcementWarn, verbEnforcementRetry, verbEnforcementReject, value)
+	}
+}
+
+// verbRules adds an instruction to start with one of verbs to rules
+func verbRules(rules, verbs []string) []string {
+	if len(verbs) == 0 {
+		return rules
+	}
+	return append(rules, "Start the message with one of: "+strings.Join(verbs, ", "))
+}
+
+// messageRules are the checks a generated message is held to, resolved from
+// the flags and config
+type messageRules struct {
+	Types       []string // conventional commit types; only enforced with Strict
+	Strict      bool
+	Verbs       []string // allowed leading verbs; empty allows any
+	VerbMode    string   // what happens when the verb isn't allowed
+	Retries     int      // regenerations allowed for a message failing ValidateCommitMessage
+	KeepPartial bool     // use the complete lines that arrived before a stream error
+}
+
+// partialResponseError reports that generation failed part way and the
+// message was made from what arrived before the failure
+type partialResponseError struct {
+	Err error
+}
+
+func (e *partialResponseError) Error() string {
+	return "response cut off: " + e.Err.Error()
+}
+
+func (e *partialResponseError) Unwrap() error {
+	return e.Err
+}
+
+// validationProblem returns why message fails ValidateCommitMessage, or nil.
+// Natural-language messages have no type, so a missing one isn't a problem.
+func (r messageRules) validationProblem(message string) error {
+	err := prompt.ValidateCommitMessage(message)
+	if errors.Is(err, prompt.ErrMissingType) && len(r.Types) == 0 {
+		return nil
+	}
+	return err
+}
+
+// accepts reports whether message passes the checks that can reject it
+func (r messageRules) accepts(message string) bool {
+	if r.Strict && prompt.ValidateCommitType(message, r.Types) != nil {
+		return false
+	}
+	if len(r.Verbs) > 0 && r.VerbMode != verbEnforcementWarn && prompt.ValidateVerb(message, r.Verbs) != nil {
+		return false
+	}
+	return true
+}
+
+// generateSmartCommit generates and sanitizes a commit message, giving the
+// model one chance to fix a type or verb that rules don't allow
+func generateSmartCommit(ctx context.Context, client ChatClient, chatReq ollama.ChatRequest, rules messageRules) (string, error) {
+	generated, err := generateCommitMessage(ctx, client, chatReq)
+	logExchange("smart-commit", chatReq, generated, err)
+	if err != nil {
+		// A dropped connection often comes after a complete subject line
+		if rules.KeepPartial && ctx.Err() == nil {
+			if partial := prompt.SanitizeCommitMessage(prompt.CompleteLines(generated)); partial != "" {
+				return partial, &partialResponseError{Err: err}
+			}
+		}
+		return "", err
+	}
+	message := prompt.SanitizeCommitMessage(generated)
+
+	if rules.Strict {
+		if reason := prompt.ValidateCommitType(message, rules.Types); reason != nil {
+			generated, err = regenerateWithCorrection(ctx, client, chatReq, generated, reason,
+				"Rewrite the commit message so it starts with one of the allowed types.")
+			if err != nil {
+				return "", err
+			}
+			message = prompt.SanitizeCommitMessage(generated)
+		}
+	}
+	if len(rules.Verbs) > 0 && rules.VerbMode == verbEnforcementRetry {
+		if reason := prompt.ValidateVerb(message, rules.Verbs); reason != nil {
+			generated, err = regenerateWithCorrection(ctx, client, chatReq, generated, reason,
+				"Rewrite the commit message so its first word is one of: "+strings.Join(rules.Verbs, ", ")+".")
+			if err != nil {
+				return "", err
+			}
+			message = prompt.SanitizeCommitMessage(generated)
+		}
+	}
+
+	// Too long or badly formed messages get up to rules.Retries more tries
+	verbose := viper.GetBool("verbose")
+	for attempt := 1; attempt <= rules.Retries; attempt++ {
+		reason := rules.validationProblem(message)
+		if reason == nil {
+			break
+		}
+		if verbose {
+			ui.ShowInfo(fmt.Sprintf("Attempt %d of %d: %s", attempt, rules.Retries+1, strings.SplitN(message, "\n", 2)[0]))
+		}
+
+		generated, err = regenerateWithCorrection(ctx, client, chatReq, generated, reason,
+			"Rewrite the commit message with a subject line under 72 characters and a blank line before any body.")
+		if err != nil {
+			return "", err
+		}
+		message = prompt.SanitizeCommitMessage(generated)
+	}
+
+	return message, nil
+}
+
+// regenerateWithCorrection asks the model to rewrite its answer, continuing
+// the original conversation with the reason it was rejected and an
+// instruction. It returns the raw response.
+func regenerateWithCorrection(ctx context.Context, client ChatClient, chatReq ollama.ChatRequest, generated string, reason error, instruction string) (string, error) {
+	ui.ShowWarning("Regenerating: " + reason.Error())
+
+	retryReq := chatReq
+	retryReq.Messages = append(append([]ollama.Message{}, chatReq.Messages...),
+		ollama.Message{Role: "assistant", Content: generated},
+		ollama.Message{Role: "user", Content: fmt.Sprintf("%s. %s Output the commit message only:", reason, instruction)},
+	)
+
+	regenerated, err := generateCommitMessage(ctx, client, retryReq)
+	logExchange("smart-commit", retryReq, regenerated, err)
+	return regenerated, err
+}
+
+// reportCommit shows the outcome of a commit or amend, including hook output
+func reportCommit(output string, err error, showHookOutput bool) error {
+	formatter := ui.NewCommitMessageFormatter()
+
+	if err != nil {
+		if output != "" {
+			ui.Print(formatter.FormatGitOutput(output, true))
+		}
+
+		var hookErr *git.HookError
+		if errors.As(err, &hookErr) {
+			ui.ShowError(fmt.Sprintf("Commit rejected by %s hook; fix the issues above and run again", strings.Join(hookErr.Hooks, "/")))
+			return err
+		}
+
 		ui.ShowError("Failed to commit: " + err.Error())
 		return err
 	}
 
+	if showHookOutput {
+		if output != "" {
+			ui.Print(formatter.FormatGitOutput(output, false))
+		}
+	} else if warnings := hookWarnings(output); warnings != "" {
+		ui.ShowWarning(warnings)
+	}
+
 	ui.ShowSuccess("Changes committed successfully!")
 	return nil
 }
 
-// runShellCommand executes a shell command
-func runShellCommand(ctx context.Context, command string) error {
-	args := []string{"-c", command}
-	cmd := exec.CommandContext(ctx, "sh", args...)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	return cmd.Run()
+// hookWarnings returns the lines of git commit output that look like
+// warnings, so they stay visible without --show-hook-output
+func hookWarnings(output string) string {
+	var warnings []string
+	for _, line := range strings.Split(output, "\n") {
+		if strings.Contains(strings.ToLower(line), "warning") {
+			warnings = append(warnings, strings.TrimSpace(line))
+		}
+	}
+	return strings.Join(warnings, "\n")
+}
+
+// generateCommitMessage streams a chat request and returns the raw response.
+// On an error the response received so far is returned with it.
+func generateCommitMessage(ctx context.Context, client ChatClient, chatReq ollama.ChatRequest) (string, error) {
+	// Create beautiful streaming spinner
+	spinner := ui.NewStreamingSpinner(ui.WithEmoji("commit", "Generating commit message"))
+	spinner.Start()
+	defer spinner.Stop()
+
+	respChan, errChan := client.Chat(ctx, chatReq)
+	return ollama.CollectStream(ctx, respChan, errChan, func(string) { spinner.Update() })
+}
+
+// deterministicSeed is the fixed sampling seed used by --deterministic
+const deterministicSeed = 42
+
+// deterministicCacheTTL keeps deterministic messages across CI runs
+const deterministicCacheTTL = 30 * 24 * time.Hour
+
+// applyDeterministic pins the sampling options so identical prompts yield identical output
+func applyDeterministic(req *ollama.ChatRequest) {
+	req.Options.Temperature = 0
+	req.Options.Seed = deterministicSeed
+}
+
+// deterministicCacheKey derives the cache key for a deterministic request
+// from everything that influences the model's output
+func deterministicCacheKey(req ollama.ChatRequest) string {
+	components := []string{"smart-commit", req.Model, fmt.Sprintf("%d", req.Options.Seed)}
+	for _, msg := range req.Messages {
+		components = append(components, msg.Role, msg.Content)
+	}
+	return cache.GenerateCacheKey(components...)
+}
+
+// incrementalMarkerName returns the cache marker that holds the staged tree
+// recorded by the last --incremental run on branch
+func incrementalMarkerName(branch string) string {
+	return "incremental|" + branch
 }
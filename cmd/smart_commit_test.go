@@ -0,0 +1,663 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"gh-smart-commit/pkg/git"
+	"gh-smart-commit/pkg/ollama"
+	"gh-smart-commit/pkg/prompt"
+)
+
+func newDeterministicRequest(diff string) ollama.ChatRequest {
+	req := ollama.ChatRequest{
+		Model: "test-model",
+		Messages: []ollama.Message{
+			{Role: "system", Content: "system prompt"},
+			{Role: "user", Content: "Diff:\n" + diff},
+		},
+		Options: ollama.Options{Temperature: 0.7},
+	}
+	applyDeterministic(&req)
+	return req
+}
+
+func TestApplyDeterministic(t *testing.T) {
+	req := newDeterministicRequest("+a")
+
+	if req.Options.Temperature != 0 {
+		t.Errorf("Expected temperature 0, got %v", req.Options.Temperature)
+	}
+	if req.Options.Seed != deterministicSeed {
+		t.Errorf("Expected seed %d, got %d", deterministicSeed, req.Options.Seed)
+	}
+}
+
+func TestDeterministicCacheKey(t *testing.T) {
+	key1 := deterministicCacheKey(newDeterministicRequest("+a"))
+	key2 := deterministicCacheKey(newDeterministicRequest("+a"))
+	key3 := deterministicCacheKey(newDeterministicRequest("+b"))
+
+	if key1 != key2 {
+		t.Error("Expected identical requests to produce identical cache keys")
+	}
+	if key1 == key3 {
+		t.Error("Expected different diffs to produce different cache keys")
+	}
+}
+
+func TestDeterministicOutputIsReused(t *testing.T) {
+	dir := newTestRepo(t)
+	chats := newFakeOllama(t, "Fix parser in lexer.go", "Rewrite the lexer")
+	setConfig(t, "commit.warn_untracked", false)
+
+	out := filepath.Join(dir, "message.txt")
+	flags := map[string]string{"deterministic": "true", "out": out, "overwrite": "true"}
+	var messages []string
+	for i := 0; i < 2; i++ {
+		if err := runSmartCommitWith(t, flags, ""); err != nil {
+			t.Fatalf("Run %d failed: %v", i+1, err)
+		}
+		data, err := os.ReadFile(out)
+		if err != nil {
+			t.Fatal(err)
+		}
+		messages = append(messages, string(data))
+	}
+
+	// The fake answers differently the second time, so a second model call
+	// would change the message
+	if messages[0] != "Fix parser in lexer.go\n" || messages[1] != messages[0] {
+		t.Errorf("Expected identical output for identical input, got %q", messages)
+	}
+	if got := atomic.LoadInt32(chats); got != 1 {
+		t.Errorf("Expected one model call with the second run served from cache, got %d", got)
+	}
+}
+
+func TestGenerateCommitMessageCancelled(t *testing.T) {
+	aborted := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Stream one chunk, then hang like a slow model until the client goes away
+		resp, _ := json.Marshal(ollama.ChatResponse{Message: ollama.Message{Content: "Fix"}})
+		w.Write(append(resp, '\n'))
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+		close(aborted)
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	_, err := generateCommitMessage(ctx, ollama.NewClient(server.URL), newDeterministicRequest("+a"))
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected context.Canceled, got %v", err)
+	}
+
+	select {
+	case <-aborted:
+	case <-time.After(2 * time.Second):
+		t.Error("Expected the HTTP request to be aborted after cancellation")
+	}
+}
+
+func TestAmendKeepsFittingMessage(t *testing.T) {
+	diff := "diff --git a/pkg/auth/token.go b/pkg/auth/token.go\n--- a/pkg/auth/token.go\n+++ b/pkg/auth/token.go\n@@ -1 +1 @@\n-a\n+b\n"
+
+	if !currentMessageFits("fix(auth): guard token refresh", diff) {
+		t.Error("Expected a valid message naming the changed package to fit")
+	}
+	if currentMessageFits("feat: add dark mode", diff) {
+		t.Error("Expected a message unrelated to the diff not to fit")
+	}
+
+	for _, answer := range []string{"\n", "k\n", "KEEP\n", ""} {
+		keep, err := askKeepMessage(strings.NewReader(answer))
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if !keep {
+			t.Errorf("Expected answer %q to keep the message", answer)
+		}
+	}
+
+	if keep, _ := askKeepMessage(strings.NewReader("r\n")); keep {
+		t.Error("Expected 'r' to regenerate")
+	}
+}
+
+func TestUntrackedWarning(t *testing.T) {
+	tests := []struct {
+		files []string
+		want  string
+	}{
+		{[]string{"notes.md"}, "1 untracked file is not part of this commit: notes.md ("},
+		{[]string{"a", "b"}, "2 untracked files are not part of this commit: a, b ("},
+		{[]string{"a", "b", "c", "d", "e", "f", "g"}, "7 untracked files are not part of this commit: a, b, c, d, e and 2 more ("},
+	}
+
+	for _, tt := range tests {
+		if got := untrackedWarning(tt.files); !strings.HasPrefix(got, tt.want) {
+			t.Errorf("untrackedWarning(%v) = %q, expected prefix %q", tt.files, got, tt.want)
+		}
+	}
+}
+
+func TestCheckMessageFile(t *testing.T) {
+	dir := t.TempDir()
+	blank := filepath.Join(dir, "blank")
+	kept := filepath.Join(dir, "kept")
+	os.WriteFile(blank, []byte("\n  \n"), 0644)
+	os.WriteFile(kept, []byte("Fix parser crash\n"), 0644)
+
+	if err := checkMessageFile(filepath.Join(dir, "missing")); err != nil {
+		t.Errorf("Expected a missing file to be writable, got %v", err)
+	}
+	if err := checkMessageFile(blank); err != nil {
+		t.Errorf("Expected a blank file to be writable, got %v", err)
+	}
+	if err := checkMessageFile(kept); err == nil || !strings.Contains(err.Error(), "--overwrite") {
+		t.Errorf("Expected a non-empty file to need --overwrite, got %v", err)
+	}
+}
+
+func TestRegenerateWithCorrection(t *testing.T) {
+	var got ollama.ChatRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&got)
+		resp, _ := json.Marshal(ollama.ChatResponse{Message: ollama.Message{Content: "Add retry to client.go"}, Done: true})
+		w.Write(append(resp, '\n'))
+	}))
+	defer server.Close()
+
+	verbs := []string{"Add", "Fix"}
+	reason := prompt.ValidateVerb("Implement retry in client.go", verbs)
+	req := newDeterministicRequest("+a")
+
+	regenerated, err := regenerateWithCorrection(context.Background(), ollama.NewClient(server.URL), req,
+		"Implement retry in client.go", reason, "Rewrite the commit message so its first word is one of: Add, Fix.")
+	if err != nil {
+		t.Fatalf("regenerateWithCorrection failed: %v", err)
+	}
+
+	if err := prompt.ValidateVerb(regenerated, verbs); err != nil {
+		t.Errorf("Expected the regenerated message to pass, got %v", err)
+	}
+	if len(got.Messages) != 4 || got.Messages[2].Role != "assistant" {
+		t.Fatalf("Expected the original conversation plus the rejected answer and a correction, got %+v", got.Messages)
+	}
+	if !strings.Contains(got.Messages[3].Content, `starts with "Implement"`) || !strings.Contains(got.Messages[3].Content, "one of: Add, Fix") {
+		t.Errorf("Expected the correction to explain the rejection, got %q", got.Messages[3].Content)
+	}
+	if len(req.Messages) != 2 {
+		t.Error("Expected the original request to be left untouched")
+	}
+}
+
+func TestGenerateSmartCommit(t *testing.T) {
+	answers := []string{
+		"Here is the commit message:\n```\nAdd parser for config files\n```",
+		"feat(config): add parser for config files",
+	}
+	var requests []ollama.ChatRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req ollama.ChatRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		requests = append(requests, req)
+
+		resp, _ := json.Marshal(ollama.ChatResponse{Message: ollama.Message{Content: answers[len(requests)-1]}, Done: true})
+		w.Write(append(resp, '\n'))
+	}))
+	defer server.Close()
+
+	rules := messageRules{Types: prompt.DefaultCommitTypes, Strict: true}
+	message, err := generateSmartCommit(context.Background(), ollama.NewClient(server.URL), newDeterministicRequest("+a"), rules)
+	if err != nil {
+		t.Fatalf("generateSmartCommit failed: %v", err)
+	}
+
+	if message != "feat(config): add parser for config files" {
+		t.Errorf("Expected the corrected message, got %q", message)
+	}
+	if len(requests) != 2 {
+		t.Fatalf("Expected one retry after the missing type, got %d requests", len(requests))
+	}
+	if got := requests[1].Messages[2].Content; got != answers[0] {
+		t.Errorf("Expected the raw rejected answer in the retry, got %q", got)
+	}
+	if !rules.accepts(message) {
+		t.Error("Expected the corrected message to be accepted")
+	}
+}
+
+func TestGenerateSmartCommitRetries(t *testing.T) {
+	long := "Add " + strings.Repeat("very ", 15) + "long subject"
+	answers := []string{long, long, "Add short subject"}
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		resp, _ := json.Marshal(ollama.ChatResponse{Message: ollama.Message{Content: answers[requests-1]}, Done: true})
+		w.Write(append(resp, '\n'))
+	}))
+	defer server.Close()
+	client := ollama.NewClient(server.URL)
+
+	message, err := generateSmartCommit(context.Background(), client, newDeterministicRequest("+a"), messageRules{Retries: 1})
+	if err != nil {
+		t.Fatalf("generateSmartCommit failed: %v", err)
+	}
+	if message != long || requests != 2 {
+		t.Errorf("Expected to give up after one retry, got %q after %d requests", message, requests)
+	}
+
+	requests = 1
+	message, err = generateSmartCommit(context.Background(), client, newDeterministicRequest("+a"), messageRules{Retries: 3})
+	if err != nil {
+		t.Fatalf("generateSmartCommit failed: %v", err)
+	}
+	if message != "Add short subject" || requests != 3 {
+		t.Errorf("Expected to stop once the message passes, got %q after %d requests", message, requests)
+	}
+}
+
+func TestGenerateSmartCommitKeepsPartial(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Send most of the message, then drop the connection
+		resp, _ := json.Marshal(ollama.ChatResponse{Message: ollama.Message{Content: "Fix parser crash on empty input\n\nThe lexer read past"}})
+		w.Write(append(resp, '\n'))
+		w.(http.Flusher).Flush()
+		panic(http.ErrAbortHandler)
+	}))
+	defer server.Close()
+	client := ollama.NewClient(server.URL)
+
+	message, err := generateSmartCommit(context.Background(), client, newDeterministicRequest("+a"), messageRules{KeepPartial: true})
+	var partialErr *partialResponseError
+	if !errors.As(err, &partialErr) {
+		t.Fatalf("Expected a partial response error, got %v", err)
+	}
+	if message != "Fix parser crash on empty input" {
+		t.Errorf("Expected the complete subject line, got %q", message)
+	}
+
+	if _, err := generateSmartCommit(context.Background(), client, newDeterministicRequest("+a"), messageRules{}); err == nil || errors.As(err, &partialErr) {
+		t.Errorf("Expected a plain error without KeepPartial, got %v", err)
+	}
+}
+
+func TestMessageRulesAccepts(t *testing.T) {
+	tests := []struct {
+		rules   messageRules
+		message string
+		want    bool
+	}{
+		{messageRules{}, "Add parser", true},
+		{messageRules{Types: []string{"feat"}}, "Add parser", true},
+		{messageRules{Types: []string{"feat"}, Strict: true}, "Add parser", false},
+		{messageRules{Verbs: []string{"Fix"}, VerbMode: verbEnforcementWarn}, "Add parser", true},
+		{messageRules{Verbs: []string{"Fix"}, VerbMode: verbEnforcementReject}, "Add parser", false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.rules.accepts(tt.message); got != tt.want {
+			t.Errorf("%+v accepts(%q) = %v, expected %v", tt.rules, tt.message, got, tt.want)
+		}
+	}
+}
+
+func TestParseVerbEnforcement(t *testing.T) {
+	for _, mode := range []string{"warn", "retry", "reject"} {
+		if _, err := parseVerbEnforcement(mode); err != nil {
+			t.Errorf("Expected %q to be accepted, got %v", mode, err)
+		}
+	}
+	if _, err := parseVerbEnforcement("strict"); err == nil {
+		t.Error("Expected an unknown mode to be rejected")
+	}
+}
+
+func TestGuidance(t *testing.T) {
+	if got := withGuidance("Diff:\n+a", "  "); got != "Diff:\n+a" {
+		t.Errorf("Expected blank guidance to leave the prompt alone, got %q", got)
+	}
+	if got := withGuidance("Diff:\n+a", "mention the perf aspect"); !strings.HasSuffix(got, "\n\nAdditional guidance from the user: mention the perf aspect") {
+		t.Errorf("Expected the guidance after the prompt, got %q", got)
+	}
+
+	req := newDeterministicRequest("+a")
+	refined := guidanceRequest(req, "Add cache", "mention the perf aspect")
+	if len(refined.Messages) != 4 || refined.Messages[2].Content != "Add cache" || !strings.Contains(refined.Messages[3].Content, "mention the perf aspect") {
+		t.Errorf("Expected the shown message and the guidance to continue the conversation, got %+v", refined.Messages)
+	}
+	if len(req.Messages) != 2 {
+		t.Error("Expected the original request to be left untouched")
+	}
+
+	answers := map[string]commitAnswer{
+		"y\n":          answerCommit,
+		"YES\n":        answerCommit,
+		"r\n":          answerRegenerate,
+		"regenerate\n": answerRegenerate,
+		"\n":           answerCancel,
+		"":             answerCancel,
+	}
+	for input, want := range answers {
+		got, err := askCommitAnswer(bufio.NewReader(strings.NewReader(input)))
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if got != want {
+			t.Errorf("Expected answer %q to give %d, got %d", input, want, got)
+		}
+	}
+
+	// The guidance is read from the same reader as the answer before it
+	reader := bufio.NewReader(strings.NewReader("r\nshorter subject\n"))
+	askCommitAnswer(reader)
+	if got, _ := askGuidance(reader); got != "shorter subject" {
+		t.Errorf("Expected the guidance line, got %q", got)
+	}
+}
+
+func TestStagedFileLabels(t *testing.T) {
+	labels := stagedFileLabels([]git.FileStat{
+		{Path: "cmd/root.go", Additions: 12, Deletions: 3},
+		{Path: "logo.png", Binary: true},
+	})
+
+	want := []string{"cmd/root.go  +12 -3", "logo.png     binary"}
+	for i := range want {
+		if labels[i] != want[i] {
+			t.Errorf("Expected %q, got %q", want[i], labels[i])
+		}
+	}
+}
+
+// newTestRepo creates a git repository with one commit and a staged change
+// in a temporary directory, and makes it the working directory for the test
+func newTestRepo(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	gitIn(t, dir, "init", "-q")
+	gitIn(t, dir, "config", "user.name", "Test")
+	gitIn(t, dir, "config", "user.email", "test@example.com")
+	gitIn(t, dir, "config", "commit.gpgsign", "false")
+
+	writeTestFile(t, filepath.Join(dir, "main.go"), "package main\n")
+	gitIn(t, dir, "add", "main.go")
+	gitIn(t, dir, "commit", "-q", "-m", "Initial commit")
+
+	writeTestFile(t, filepath.Join(dir, "main.go"), "package main\n\nfunc main() {}\n")
+	gitIn(t, dir, "add", "main.go")
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+	return dir
+}
+
+// gitIn runs git in dir and returns its trimmed output
+func gitIn(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %s failed: %v\n%s", strings.Join(args, " "), err, out)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+func writeTestFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// newFakeOllama serves the endpoints smart-commit uses, answering every
+// chat with message, and points ollama.host at it. It returns a counter of
+// chat requests.
+func newFakeOllama(t *testing.T, messages ...string) *int32 {
+	t.Helper()
+	var chats int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/version":
+			w.Write([]byte(`{"version":"0.3.12"}`))
+		case "/api/tags":
+			w.Write([]byte(`{"models":[]}`))
+		case "/api/chat":
+			// Each chat gets the next message; the last one repeats
+			n := int(atomic.AddInt32(&chats, 1))
+			message := messages[len(messages)-1]
+			if n <= len(messages) {
+				message = messages[n-1]
+			}
+			resp, _ := json.Marshal(ollama.ChatResponse{Message: ollama.Message{Content: message}, Done: true})
+			w.Write(append(resp, '\n'))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	setConfig(t, "ollama.host", server.URL)
+	setConfig(t, "ollama.hosts", nil)
+	return &chats
+}
+
+// runSmartCommitWith runs smart-commit with flags set and stdin answering
+// its questions. The flags are reset when it returns.
+func runSmartCommitWith(t *testing.T, flags map[string]string, stdin string) error {
+	t.Helper()
+	return runCommandWith(t, smartCommitCmd, runSmartCommit, flags, stdin)
+}
+
+// runCommandWith runs cmd through run with flags set and stdin answering
+// its questions. The flags are reset when it returns.
+func runCommandWith(t *testing.T, cmd *cobra.Command, run func(*cobra.Command, []string) error, flags map[string]string, stdin string) error {
+	t.Helper()
+	for name, value := range flags {
+		f := cmd.Flags().Lookup(name)
+		if f == nil {
+			t.Fatalf("Unknown flag --%s", name)
+		}
+		if err := f.Value.Set(value); err != nil {
+			t.Fatal(err)
+		}
+		f.Changed = true
+		defer func() {
+			f.Value.Set(f.DefValue)
+			f.Changed = false
+		}()
+	}
+
+	input := filepath.Join(t.TempDir(), "stdin")
+	writeTestFile(t, input, stdin)
+	file, err := os.Open(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	oldStdin := os.Stdin
+	os.Stdin = file
+	defer func() { os.Stdin = oldStdin }()
+
+	cmd.SetContext(context.Background())
+	return run(cmd, nil)
+}
+
+func TestIncrementalMarkerOnlyMovesOnCommit(t *testing.T) {
+	newTestRepo(t)
+	newFakeOllama(t, "Add main function")
+	setConfig(t, "commit.warn_untracked", false)
+
+	ctx := context.Background()
+	repo := git.NewLocalRepo(".")
+	marker := incrementalMarkerName(gitIn(t, ".", "branch", "--show-current"))
+	markerSet := func() bool {
+		_, found, _ := repoCache(ctx, repo).GetMarker(marker)
+		return found
+	}
+
+	if err := runSmartCommitWith(t, map[string]string{"incremental": "true", "dry-run": "true"}, ""); err != nil {
+		t.Fatalf("Dry run failed: %v", err)
+	}
+	if markerSet() {
+		t.Error("Expected a dry run to leave the incremental marker alone")
+	}
+
+	if err := runSmartCommitWith(t, map[string]string{"incremental": "true"}, "n\n"); err != nil {
+		t.Fatalf("Cancelled run failed: %v", err)
+	}
+	if markerSet() {
+		t.Error("Expected a cancelled commit to leave the incremental marker alone")
+	}
+
+	if err := runSmartCommitWith(t, map[string]string{"incremental": "true"}, "y\n"); err != nil {
+		t.Fatalf("Commit failed: %v", err)
+	}
+	tree, found, _ := repoCache(ctx, repo).GetMarker(marker)
+	if !found || tree != gitIn(t, ".", "rev-parse", "HEAD^{tree}") {
+		t.Errorf("Expected the marker to hold the committed tree, got %q (found %v)", tree, found)
+	}
+}
+
+func TestRegenerateRetriesAndNotifiesOnce(t *testing.T) {
+	newTestRepo(t)
+	chats := newFakeOllama(t, "feat: add main function", "Added main function", "fix: add main function")
+	setConfig(t, "commit.warn_untracked", false)
+
+	var payloads []commitMessagePayload
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload commitMessagePayload
+		json.NewDecoder(r.Body).Decode(&payload)
+		payloads = append(payloads, payload)
+	}))
+	defer hook.Close()
+
+	// The regenerated message has no type, so --strict has to correct it
+	// instead of giving up
+	err := runSmartCommitWith(t, map[string]string{"strict": "true", "webhook": hook.URL}, "r\nit is a bug fix\ny\n")
+	if err != nil {
+		t.Fatalf("Commit failed: %v", err)
+	}
+	if got := atomic.LoadInt32(chats); got != 3 {
+		t.Errorf("Expected 3 chats, got %d", got)
+	}
+	if subject := gitIn(t, ".", "log", "-1", "--format=%s"); subject != "fix: add main function" {
+		t.Errorf("Expected the corrected message to be committed, got %q", subject)
+	}
+	if len(payloads) != 1 || payloads[0].Message != "fix: add main function" {
+		t.Errorf("Expected one webhook with the committed message, got %+v", payloads)
+	}
+}
+
+func TestStageAllLeavesIndexAloneWithoutCommit(t *testing.T) {
+	dir := newTestRepo(t)
+	newFakeOllama(t, "Update main and readme")
+	setConfig(t, "commit.warn_untracked", false)
+
+	// A tracked file with an unstaged edit, which only --all picks up
+	writeTestFile(t, filepath.Join(dir, "README.md"), "# project\n")
+	gitIn(t, ".", "add", "README.md")
+	gitIn(t, ".", "commit", "-q", "-m", "Add readme", "--", "README.md")
+	writeTestFile(t, filepath.Join(dir, "README.md"), "# changed\n")
+	staged := gitIn(t, ".", "diff", "--cached", "--name-only")
+
+	if err := runSmartCommitWith(t, map[string]string{"all": "true", "dry-run": "true"}, ""); err != nil {
+		t.Fatalf("Dry run failed: %v", err)
+	}
+	if got := gitIn(t, ".", "diff", "--cached", "--name-only"); got != staged {
+		t.Errorf("Expected a dry run to leave %q staged, got %q", staged, got)
+	}
+
+	if err := runSmartCommitWith(t, map[string]string{"all": "true"}, "n\n"); err != nil {
+		t.Fatalf("Cancelled run failed: %v", err)
+	}
+	if got := gitIn(t, ".", "diff", "--cached", "--name-only"); got != staged {
+		t.Errorf("Expected a cancelled commit to leave %q staged, got %q", staged, got)
+	}
+
+	if err := runSmartCommitWith(t, map[string]string{"all": "true"}, "y\n"); err != nil {
+		t.Fatalf("Commit failed: %v", err)
+	}
+	if got := gitIn(t, ".", "show", "--name-only", "--format=", "HEAD"); got != "README.md\nmain.go" {
+		t.Errorf("Expected the commit to include README.md and main.go, got %q", got)
+	}
+}
+
+func TestOutNeedsOverwrite(t *testing.T) {
+	dir := newTestRepo(t)
+	newFakeOllama(t, "Add main function")
+	setConfig(t, "commit.warn_untracked", false)
+
+	out := filepath.Join(dir, "message.txt")
+	writeTestFile(t, out, "Keep this message\n")
+
+	// --force is only about merges and rebases
+	if err := runSmartCommitWith(t, map[string]string{"out": out, "force": "true"}, ""); err == nil {
+		t.Error("Expected --force alone not to overwrite a kept message")
+	}
+
+	if err := runSmartCommitWith(t, map[string]string{"out": out, "overwrite": "true"}, ""); err != nil {
+		t.Fatalf("Overwrite failed: %v", err)
+	}
+	if data, _ := os.ReadFile(out); string(data) != "Add main function\n" {
+		t.Errorf("Expected the new message in the file, got %q", data)
+	}
+}
+
+func TestSmartCommitSendsContextWindow(t *testing.T) {
+	newTestRepo(t)
+	setConfig(t, "commit.warn_untracked", false)
+	setConfig(t, "ollama.context_window", 16384)
+
+	var numCtx int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/version":
+			w.Write([]byte(`{"version":"0.3.12"}`))
+		case "/api/tags":
+			w.Write([]byte(`{"models":[]}`))
+		case "/api/chat":
+			var req ollama.ChatRequest
+			json.NewDecoder(r.Body).Decode(&req)
+			atomic.StoreInt32(&numCtx, int32(req.Options.NumCtx))
+			resp, _ := json.Marshal(ollama.ChatResponse{Message: ollama.Message{Content: "Add main function"}, Done: true})
+			w.Write(append(resp, '\n'))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+	setConfig(t, "ollama.host", server.URL)
+	setConfig(t, "ollama.hosts", nil)
+
+	if err := runSmartCommitWith(t, map[string]string{"dry-run": "true"}, ""); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if got := atomic.LoadInt32(&numCtx); got != 16384 {
+		t.Errorf("Expected num_ctx 16384 from ollama.context_window, got %d", got)
+	}
+}
@@ -37,4 +37,4 @@ func runTagSuggest(cmd *cobra.Command, args []string) error {
 	fmt.Println("tag-suggest command executed (placeholder)")
 	// TODO: Implement in Phase 5
 	return nil
-} 
\ No newline at end of file
+}
@@ -0,0 +1,36 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"gh-smart-commit/pkg/prompt"
+)
+
+// templatesCmd represents the templates command
+var templatesCmd = &cobra.Command{
+	Use:   "templates",
+	Short: "Inspect the prompt templates",
+	Long: `Inspect the prompt templates used to talk to the model.
+
+Examples:
+  gh-smart-commit templates list`,
+}
+
+var templatesListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the registered prompt templates",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		for _, name := range prompt.NewBuilder().ListTemplates() {
+			fmt.Println(name)
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(templatesCmd)
+	templatesCmd.AddCommand(templatesListCmd)
+}
@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"gh-smart-commit/pkg/ui"
+)
+
+// warmupCmd represents the warmup command
+var warmupCmd = &cobra.Command{
+	Use:   "warmup [model...]",
+	Short: "Load models into memory ahead of time",
+	Long: `Ask Ollama to load models now, so the next command starts streaming right
+away instead of waiting for a cold model to load.
+
+Without arguments the configured model is loaded. Set ollama.keep_alive
+(e.g. "30m", or "-1" for forever) to keep it resident between commands.
+
+Examples:
+  gh-smart-commit warmup
+  gh-smart-commit warmup reviewer qwen2.5-coder:7b`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runWarmup(cmd, args)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(warmupCmd)
+}
+
+func runWarmup(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+
+	models := args
+	if len(models) == 0 {
+		models = []string{viper.GetString("ollama.model")}
+	}
+
+	client := newOllamaClient()
+
+	// Test connection
+	if err := connectOllama(ctx, client); err != nil {
+		return err
+	}
+
+	for _, model := range models {
+		model = ResolveModel(model)
+
+		spinner := ui.NewStreamingSpinner(fmt.Sprintf("Loading %s...", model))
+		spinner.Start()
+		err := client.Preload(ctx, model)
+		spinner.Stop()
+
+		if err != nil {
+			ui.ShowError(fmt.Sprintf("Failed to load %s: %s", model, err.Error()))
+			return err
+		}
+		ui.ShowSuccess(fmt.Sprintf("%s is loaded", model))
+	}
+
+	if keepAlive := viper.GetString("ollama.keep_alive"); keepAlive != "" {
+		ui.ShowInfo(fmt.Sprintf("Models stay loaded for %s after each request (ollama.keep_alive)", keepAlive))
+	}
+
+	return nil
+}
@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"context"
+
+	"github.com/spf13/viper"
+
+	"gh-smart-commit/pkg/ui"
+	"gh-smart-commit/pkg/webhook"
+)
+
+// commitMessagePayload is posted to --webhook by smart-commit. It only
+// carries generated output and repository identifiers, never configuration.
+type commitMessagePayload struct {
+	Event   string `json:"event"`
+	Repo    string `json:"repo"`
+	Branch  string `json:"branch"`
+	Model   string `json:"model"`
+	Message string `json:"message"`
+}
+
+// suggestionsPayload is posted to --webhook by lint-suggestions
+type suggestionsPayload struct {
+	Event       string         `json:"event"`
+	Repo        string         `json:"repo"`
+	Branch      string         `json:"branch"`
+	Model       string         `json:"model"`
+	DiffType    string         `json:"diff_type"`
+	Counts      map[string]int `json:"counts"` // Suggestions per severity
+	Suggestions []Suggestion   `json:"suggestions"`
+}
+
+// sendWebhook posts payload to url. Delivery failures are reported as
+// warnings and never fail the command.
+func sendWebhook(ctx context.Context, url string, payload interface{}) {
+	if url == "" {
+		return
+	}
+
+	if err := webhook.NewClient().Post(ctx, url, payload); err != nil {
+		ui.ShowWarning("Failed to deliver webhook: " + err.Error())
+		return
+	}
+
+	if viper.GetBool("verbose") {
+		ui.ShowInfo("Delivered results to webhook")
+	}
+}
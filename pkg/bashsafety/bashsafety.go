@@ -0,0 +1,126 @@
+// Package bashsafety flags shell commands that can destroy data or take
+// over the machine before they are executed.
+package bashsafety
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Level is how dangerous a command is
+type Level int
+
+const (
+	LevelSafe Level = iota
+	LevelDangerous
+)
+
+// String returns the level name
+func (l Level) String() string {
+	if l == LevelDangerous {
+		return "dangerous"
+	}
+	return "safe"
+}
+
+// rule flags a command when match returns true
+type rule struct {
+	reason string
+	match  func(command string) bool
+}
+
+// pattern builds a match function from a regular expression
+func pattern(expr string) func(string) bool {
+	re := regexp.MustCompile(expr)
+	return re.MatchString
+}
+
+// commandStart anchors a program name at the start of a command, a
+// pipeline stage, a subshell or a find -exec
+const commandStart = `(?:^|[;&|(]\s*|\$\(\s*|\x60\s*|\s-(?:exec|execdir|ok|okdir)\s+)`
+
+// commandWrappers skips programs that run the command that follows them,
+// along with their options and environment assignments
+const commandWrappers = `(?:(?:\S*/)?(?:sudo|env|command|nohup|xargs)(?:\s+(?:-\S+|\w+=\S*))*\s+)*`
+
+// program matches name run as a command, through any wrapper and by any path
+func program(name string) string {
+	return commandStart + commandWrappers + `(?:\S*/)?` + name
+}
+
+var rules = []rule{
+	{"recursive forced delete (rm -rf)", isForcedRecursiveRemove},
+	{"raw disk copy (dd)", pattern(program(`dd\s`))},
+	{"filesystem creation (mkfs)", pattern(`\bmkfs(?:\.\w+)?\b`)},
+	{"fork bomb", pattern(`:\(\)\s*\{\s*:\s*\|\s*:?\s*&\s*\}\s*;\s*:`)},
+	{"write to a device under /dev", writesToDevice},
+	{"pipes a download into a shell", pattern(`\b(?:curl|wget)\b[^|]*\|\s*(?:sudo\s+)?(?:ba|z|da|k|fi)?sh\b`)},
+	{"runs with root privileges (sudo)", pattern(program(`sudo\b`))},
+}
+
+// ClassifyCommandDanger returns LevelDangerous and the reasons when command
+// matches any known destructive pattern, and LevelSafe otherwise.
+func ClassifyCommandDanger(command string) (Level, []string) {
+	var reasons []string
+	for _, r := range rules {
+		if r.match(command) {
+			reasons = append(reasons, r.reason)
+		}
+	}
+
+	if len(reasons) == 0 {
+		return LevelSafe, nil
+	}
+	return LevelDangerous, reasons
+}
+
+// rmPattern captures the arguments of each rm invocation
+var rmPattern = regexp.MustCompile(program(`rm\s+([^;&|]*)`))
+
+// isForcedRecursiveRemove reports whether an rm invocation is both
+// recursive and forced, in any flag spelling
+func isForcedRecursiveRemove(command string) bool {
+	for _, match := range rmPattern.FindAllStringSubmatch(command, -1) {
+		recursive, force := false, false
+	args:
+		for _, arg := range strings.Fields(match[1]) {
+			switch {
+			case arg == "--":
+				break args // everything after -- is a file name
+			case arg == "--recursive":
+				recursive = true
+			case arg == "--force":
+				force = true
+			case strings.HasPrefix(arg, "-") && !strings.HasPrefix(arg, "--"):
+				recursive = recursive || strings.ContainsAny(arg, "rR")
+				force = force || strings.Contains(arg, "f")
+			}
+		}
+		if recursive && force {
+			return true
+		}
+	}
+	return false
+}
+
+// devicePattern captures the target of redirections and of= arguments under /dev
+var devicePattern = regexp.MustCompile(`(?:>>?|\bof=)\s*/dev/(\S+)`)
+
+// harmlessDevices are /dev targets that cannot damage anything
+var harmlessDevices = map[string]bool{
+	"null":   true,
+	"stdout": true,
+	"stderr": true,
+	"tty":    true,
+}
+
+// writesToDevice reports whether command redirects output to a device other
+// than /dev/null and the standard streams
+func writesToDevice(command string) bool {
+	for _, match := range devicePattern.FindAllStringSubmatch(command, -1) {
+		if !harmlessDevices[match[1]] && !strings.HasPrefix(match[1], "fd/") {
+			return true
+		}
+	}
+	return false
+}
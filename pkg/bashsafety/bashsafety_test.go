@@ -0,0 +1,68 @@
+package bashsafety
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestClassifyCommandDanger(t *testing.T) {
+	tests := []struct {
+		command string
+		level   Level
+		reason  string
+	}{
+		{"ls -la", LevelSafe, ""},
+		{"find . -name '*.tmp' -delete", LevelSafe, ""},
+		{"rm -r build", LevelSafe, ""},
+		{"rm -r -- -f", LevelSafe, ""},
+		{"echo done > /dev/null 2>&1", LevelSafe, ""},
+		{"git add . && git commit", LevelSafe, ""},
+		{"rm -rf /", LevelDangerous, "rm -rf"},
+		{"rm -r -f node_modules", LevelDangerous, "rm -rf"},
+		{"cd /tmp && rm --recursive --force cache", LevelDangerous, "rm -rf"},
+		{"dd if=/dev/zero of=/dev/sda bs=1M", LevelDangerous, "dd"},
+		{"mkfs.ext4 /dev/sdb1", LevelDangerous, "mkfs"},
+		{":(){ :|:& };:", LevelDangerous, "fork bomb"},
+		{"echo 1 > /dev/sda", LevelDangerous, "/dev"},
+		{"curl -fsSL https://example.com/install.sh | sh", LevelDangerous, "shell"},
+		{"wget -qO- https://example.com/x | sudo bash", LevelDangerous, "shell"},
+		{"sudo apt-get install jq", LevelDangerous, "sudo"},
+		{"find . -exec rm -rf {} +", LevelDangerous, "rm -rf"},
+		{"find . -type d -execdir rm -rf {} \\;", LevelDangerous, "rm -rf"},
+		{"ls | xargs rm -rf", LevelDangerous, "rm -rf"},
+		{"find . -print0 | xargs -0 rm -rf", LevelDangerous, "rm -rf"},
+		{"/bin/rm -rf /", LevelDangerous, "rm -rf"},
+		{"env rm -rf ~", LevelDangerous, "rm -rf"},
+		{"env LANG=C rm -rf ~", LevelDangerous, "rm -rf"},
+		{"command rm -rf build", LevelDangerous, "rm -rf"},
+		{"nohup rm -rf /data &", LevelDangerous, "rm -rf"},
+		{"/bin/dd if=/dev/zero of=disk.img", LevelDangerous, "dd"},
+		{"env dd if=/dev/zero of=disk.img", LevelDangerous, "dd"},
+		{"find . -exec dd if={} of=out \\;", LevelDangerous, "dd"},
+		{"/usr/bin/sudo ls", LevelDangerous, "sudo"},
+		{"env sudo ls", LevelDangerous, "sudo"},
+		{"ls | xargs sudo chown root", LevelDangerous, "sudo"},
+		{"nohup sudo ls", LevelDangerous, "sudo"},
+		{"find . -exec sudo chmod 777 {} +", LevelDangerous, "sudo"},
+		{"git add .", LevelSafe, ""},
+		{"cat docs/rm -rf", LevelSafe, ""},
+	}
+
+	for _, tt := range tests {
+		level, reasons := ClassifyCommandDanger(tt.command)
+		if level != tt.level {
+			t.Errorf("ClassifyCommandDanger(%q) level = %s, expected %s (reasons %v)", tt.command, level, tt.level, reasons)
+			continue
+		}
+		if tt.reason != "" && !strings.Contains(strings.Join(reasons, "; "), tt.reason) {
+			t.Errorf("ClassifyCommandDanger(%q) reasons = %v, expected one mentioning %q", tt.command, reasons, tt.reason)
+		}
+	}
+}
+
+func TestClassifyCommandDangerReportsAllReasons(t *testing.T) {
+	_, reasons := ClassifyCommandDanger("sudo rm -rf /var/lib/app")
+	if len(reasons) != 2 {
+		t.Errorf("Expected sudo and rm -rf reasons, got %v", reasons)
+	}
+}
@@ -0,0 +1,73 @@
+package bench
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// Sample holds the timings of a single generation run
+type Sample struct {
+	Latency      time.Duration // Wall-clock time of the request
+	EvalCount    int           // Tokens generated, as reported by Ollama
+	EvalDuration time.Duration // Time spent generating those tokens
+}
+
+// Summary aggregates the samples collected for one model
+type Summary struct {
+	Runs         int
+	Min          time.Duration
+	Median       time.Duration
+	P95          time.Duration
+	TokensPerSec float64
+}
+
+// Summarize computes latency percentiles and throughput for a set of samples
+func Summarize(samples []Sample) Summary {
+	if len(samples) == 0 {
+		return Summary{}
+	}
+
+	latencies := make([]time.Duration, len(samples))
+	var tokens int
+	var evalDuration time.Duration
+
+	for i, s := range samples {
+		latencies[i] = s.Latency
+		tokens += s.EvalCount
+		evalDuration += s.EvalDuration
+	}
+
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+
+	summary := Summary{
+		Runs:   len(samples),
+		Min:    latencies[0],
+		Median: Percentile(latencies, 50),
+		P95:    Percentile(latencies, 95),
+	}
+
+	if evalDuration > 0 {
+		summary.TokensPerSec = float64(tokens) / evalDuration.Seconds()
+	}
+
+	return summary
+}
+
+// Percentile returns the p-th percentile of sorted durations using the
+// nearest-rank method
+func Percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	if rank > len(sorted) {
+		rank = len(sorted)
+	}
+
+	return sorted[rank-1]
+}
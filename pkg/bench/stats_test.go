@@ -0,0 +1,56 @@
+package bench
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSummarize(t *testing.T) {
+	samples := []Sample{
+		{Latency: 300 * time.Millisecond, EvalCount: 30, EvalDuration: time.Second},
+		{Latency: 100 * time.Millisecond, EvalCount: 10, EvalDuration: time.Second},
+		{Latency: 200 * time.Millisecond, EvalCount: 20, EvalDuration: time.Second},
+	}
+
+	summary := Summarize(samples)
+
+	if summary.Runs != 3 {
+		t.Errorf("Expected 3 runs, got %d", summary.Runs)
+	}
+	if summary.Min != 100*time.Millisecond {
+		t.Errorf("Expected min 100ms, got %v", summary.Min)
+	}
+	if summary.Median != 200*time.Millisecond {
+		t.Errorf("Expected median 200ms, got %v", summary.Median)
+	}
+	if summary.P95 != 300*time.Millisecond {
+		t.Errorf("Expected p95 300ms, got %v", summary.P95)
+	}
+	if summary.TokensPerSec != 20 {
+		t.Errorf("Expected 20 tokens/sec, got %f", summary.TokensPerSec)
+	}
+}
+
+func TestSummarizeEmpty(t *testing.T) {
+	summary := Summarize(nil)
+	if summary.Runs != 0 || summary.TokensPerSec != 0 {
+		t.Errorf("Expected zero summary, got %+v", summary)
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	var sorted []time.Duration
+	for i := 1; i <= 20; i++ {
+		sorted = append(sorted, time.Duration(i)*time.Millisecond)
+	}
+
+	if got := Percentile(sorted, 95); got != 19*time.Millisecond {
+		t.Errorf("Expected p95 19ms, got %v", got)
+	}
+	if got := Percentile(sorted, 50); got != 10*time.Millisecond {
+		t.Errorf("Expected p50 10ms, got %v", got)
+	}
+	if got := Percentile(sorted, 0); got != 1*time.Millisecond {
+		t.Errorf("Expected p0 1ms, got %v", got)
+	}
+}
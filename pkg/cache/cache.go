@@ -1,6 +1,7 @@
 package cache
 
 import (
+	"context"
 	"crypto/sha256"
 	"encoding/json"
 	"fmt"
@@ -23,12 +24,31 @@ type Cache struct {
 	baseDir string
 }
 
-// NewCache creates a new cache instance
+// cacheDirName is the cache directory inside the git directory
+const cacheDirName = "gh-smart-commit-cache"
+
+// NewCache creates a new cache instance in the .git directory of the work
+// tree at gitDir. Prefer NewCacheForRepo, which also works when .git is a file.
 func NewCache(gitDir string) *Cache {
-	cacheDir := filepath.Join(gitDir, ".git", "gh-smart-commit-cache")
+	cacheDir := filepath.Join(gitDir, ".git", cacheDirName)
 	return &Cache{baseDir: cacheDir}
 }
 
+// GitDirLocator finds a repository's git directory
+type GitDirLocator interface {
+	GetGitDir(ctx context.Context) (string, error)
+}
+
+// NewCacheForRepo creates a cache in repo's git directory as reported by
+// git, so it works from subdirectories, linked worktrees and submodules
+func NewCacheForRepo(ctx context.Context, repo GitDirLocator) (*Cache, error) {
+	gitDir, err := repo.GetGitDir(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &Cache{baseDir: filepath.Join(gitDir, cacheDirName)}, nil
+}
+
 // Get retrieves a value from cache
 func (c *Cache) Get(key string) (string, bool, error) {
 	if err := c.ensureCacheDir(); err != nil {
@@ -46,13 +66,15 @@ func (c *Cache) Get(key string) (string, bool, error) {
 	}
 	defer file.Close()
 
+	// A damaged entry, e.g. from a crash before writes were atomic, is a
+	// miss; the next Set replaces it
 	var entry CacheEntry
 	if err := json.NewDecoder(file).Decode(&entry); err != nil {
-		return "", false, fmt.Errorf("failed to decode cache entry: %w", err)
+		return "", false, nil
 	}
 
-	// Check if expired
-	if time.Now().After(entry.ExpiresAt) {
+	// Check if expired; entries without an expiry never expire
+	if !entry.ExpiresAt.IsZero() && time.Now().After(entry.ExpiresAt) {
 		// Clean up expired entry
 		os.Remove(filePath)
 		return "", false, nil
@@ -67,27 +89,50 @@ func (c *Cache) Set(key, value string, ttl time.Duration) error {
 		return err
 	}
 
-	entry := CacheEntry{
+	return c.write(CacheEntry{
 		Key:       key,
 		Value:     value,
 		CreatedAt: time.Now(),
 		ExpiresAt: time.Now().Add(ttl),
-	}
+	})
+}
 
-	filePath := c.getFilePath(key)
-	file, err := os.Create(filePath)
+// write stores an entry under its key
+func (c *Cache) write(entry CacheEntry) error {
+	data, err := json.Marshal(entry)
 	if err != nil {
-		return fmt.Errorf("failed to create cache file: %w", err)
-	}
-	defer file.Close()
-
-	if err := json.NewEncoder(file).Encode(entry); err != nil {
 		return fmt.Errorf("failed to encode cache entry: %w", err)
 	}
 
+	if err := writeFileAtomic(c.getFilePath(entry.Key), append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write cache file: %w", err)
+	}
 	return nil
 }
 
+// writeFileAtomic writes data to a temporary file next to path and renames
+// it into place, so a concurrent reader sees either the old or the new
+// contents, never a partial write
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // no-op once renamed
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
 // Delete removes a value from cache
 func (c *Cache) Delete(key string) error {
 	filePath := c.getFilePath(key)
@@ -1,8 +1,11 @@
 package cache
 
 import (
+	"context"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 )
@@ -16,6 +19,29 @@ func TestNewCache(t *testing.T) {
 	}
 }
 
+// fakeGitDir reports a fixed git directory
+type fakeGitDir string
+
+func (f fakeGitDir) GetGitDir(ctx context.Context) (string, error) {
+	return string(f), nil
+}
+
+func TestNewCacheForRepo(t *testing.T) {
+	gitDir := filepath.Join(t.TempDir(), "main", ".git", "worktrees", "feature")
+
+	cache, err := NewCacheForRepo(context.Background(), fakeGitDir(gitDir))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if expected := filepath.Join(gitDir, "gh-smart-commit-cache"); cache.baseDir != expected {
+		t.Errorf("Expected baseDir %s, got %s", expected, cache.baseDir)
+	}
+
+	if err := cache.Set("key", "value", time.Hour); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+}
+
 func TestCacheSetAndGet(t *testing.T) {
 	// Create temporary directory for testing
 	tmpDir, err := os.MkdirTemp("", "cache-test")
@@ -69,6 +95,69 @@ func TestCacheGetNonExistent(t *testing.T) {
 	}
 }
 
+func TestCacheGetDamagedEntry(t *testing.T) {
+	cache := NewCache(t.TempDir())
+	if err := cache.ensureCacheDir(); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(cache.getFilePath("key"), []byte(`{"key": "key", "val`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, found, err := cache.Get("key")
+	if err != nil || found {
+		t.Errorf("Expected a damaged entry to be a miss, got found=%v err=%v", found, err)
+	}
+}
+
+func TestCacheConcurrentSet(t *testing.T) {
+	cache := NewCache(t.TempDir())
+	values := make([]string, 20)
+	for i := range values {
+		values[i] = strings.Repeat(string(rune('a'+i)), 64*1024)
+	}
+
+	var wg sync.WaitGroup
+	for _, value := range values {
+		wg.Add(1)
+		go func(value string) {
+			defer wg.Done()
+			for j := 0; j < 5; j++ {
+				if err := cache.Set("shared", value, time.Hour); err != nil {
+					t.Errorf("Set failed: %v", err)
+				}
+				if got, found, err := cache.Get("shared"); err != nil || (found && !contains(values, got)) {
+					t.Errorf("Read a corrupted entry: found=%v err=%v len=%d", found, err, len(got))
+				}
+			}
+		}(value)
+	}
+	wg.Wait()
+
+	got, found, err := cache.Get("shared")
+	if err != nil || !found || !contains(values, got) {
+		t.Fatalf("Expected one of the written values, got found=%v err=%v len=%d", found, err, len(got))
+	}
+
+	entries, err := os.ReadDir(cache.baseDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("Expected temporary files to be cleaned up, found %d files", len(entries))
+	}
+}
+
+// contains reports whether values holds s
+func contains(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
 func TestCacheExpiration(t *testing.T) {
 	tmpDir, err := os.MkdirTemp("", "cache-test")
 	if err != nil {
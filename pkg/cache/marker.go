@@ -0,0 +1,35 @@
+package cache
+
+import "time"
+
+// Markers are named values that never expire. They track state between runs,
+// such as the staged tree recorded by smart-commit --incremental, and are
+// only removed by ClearMarker or Clear.
+
+// markerKey namespaces marker names so they cannot collide with cache keys
+func markerKey(name string) string {
+	return "marker|" + name
+}
+
+// SetMarker stores a marker value
+func (c *Cache) SetMarker(name, value string) error {
+	if err := c.ensureCacheDir(); err != nil {
+		return err
+	}
+
+	return c.write(CacheEntry{
+		Key:       markerKey(name),
+		Value:     value,
+		CreatedAt: time.Now(),
+	})
+}
+
+// GetMarker retrieves a marker value
+func (c *Cache) GetMarker(name string) (string, bool, error) {
+	return c.Get(markerKey(name))
+}
+
+// ClearMarker removes a marker
+func (c *Cache) ClearMarker(name string) error {
+	return c.Delete(markerKey(name))
+}
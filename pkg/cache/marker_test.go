@@ -0,0 +1,37 @@
+package cache
+
+import (
+	"testing"
+)
+
+func TestMarkerSetGetClear(t *testing.T) {
+	cache := NewCache(t.TempDir())
+
+	if _, found, err := cache.GetMarker("incremental|main"); err != nil || found {
+		t.Fatalf("Expected no marker, got found=%v err=%v", found, err)
+	}
+
+	if err := cache.SetMarker("incremental|main", "abc123"); err != nil {
+		t.Fatalf("Failed to set marker: %v", err)
+	}
+
+	value, found, err := cache.GetMarker("incremental|main")
+	if err != nil || !found {
+		t.Fatalf("Expected marker, got found=%v err=%v", found, err)
+	}
+	if value != "abc123" {
+		t.Errorf("Expected marker value abc123, got %s", value)
+	}
+
+	// A cache entry with the same name must not collide with the marker
+	if _, found, _ := cache.Get("incremental|main"); found {
+		t.Error("Expected marker to be namespaced away from cache keys")
+	}
+
+	if err := cache.ClearMarker("incremental|main"); err != nil {
+		t.Fatalf("Failed to clear marker: %v", err)
+	}
+	if _, found, _ := cache.GetMarker("incremental|main"); found {
+		t.Error("Expected marker to be cleared")
+	}
+}
@@ -0,0 +1,151 @@
+package cache
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// The vector store keeps embeddings of recent commit messages so similar
+// past messages can be found without re-embedding the history on every run.
+// Each embedding model gets its own file, since vectors from different models
+// can't be compared.
+
+// Embedding is a commit message and its embedding vector
+type Embedding struct {
+	Hash    string    `json:"hash"`
+	Message string    `json:"message"`
+	Vector  []float32 `json:"vector"`
+}
+
+// VectorStore holds the commit message embeddings for one model
+type VectorStore struct {
+	path    string
+	Model   string      `json:"model"`
+	Entries []Embedding `json:"entries"`
+}
+
+// LoadVectors reads the vector store for model, returning an empty store
+// when none has been saved yet
+func (c *Cache) LoadVectors(model string) (*VectorStore, error) {
+	store := &VectorStore{
+		path:  filepath.Join(c.baseDir, fmt.Sprintf("vectors-%.16s.json", GenerateCacheKey(model))),
+		Model: model,
+	}
+
+	data, err := os.ReadFile(store.path)
+	if os.IsNotExist(err) {
+		return store, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read vector store: %w", err)
+	}
+
+	// A damaged store is rebuilt rather than failing the lookup
+	if err := json.Unmarshal(data, store); err != nil {
+		store.Model, store.Entries = model, nil
+	}
+	return store, nil
+}
+
+// Save writes the store back to the cache directory, atomically
+func (s *VectorStore) Save() error {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(s)
+	if err != nil {
+		return fmt.Errorf("failed to encode vector store: %w", err)
+	}
+
+	if err := writeFileAtomic(s.path, data); err != nil {
+		return fmt.Errorf("failed to write vector store: %w", err)
+	}
+	return nil
+}
+
+// Has reports whether the store holds an embedding for the commit hash
+func (s *VectorStore) Has(hash string) bool {
+	for _, e := range s.Entries {
+		if e.Hash == hash {
+			return true
+		}
+	}
+	return false
+}
+
+// Add stores the embedding of a commit message
+func (s *VectorStore) Add(hash, message string, vector []float32) {
+	s.Entries = append(s.Entries, Embedding{Hash: hash, Message: message, Vector: vector})
+}
+
+// Retain drops every entry whose hash is not in hashes, so the store only
+// grows as far as the history it is asked about
+func (s *VectorStore) Retain(hashes []string) {
+	keep := make(map[string]bool, len(hashes))
+	for _, h := range hashes {
+		keep[h] = true
+	}
+
+	kept := s.Entries[:0]
+	for _, e := range s.Entries {
+		if keep[e.Hash] {
+			kept = append(kept, e)
+		}
+	}
+	s.Entries = kept
+}
+
+// Nearest returns up to k entries most similar to query, most similar first
+func (s *VectorStore) Nearest(query []float32, k int) []Embedding {
+	type scored struct {
+		entry Embedding
+		score float64
+	}
+
+	candidates := make([]scored, 0, len(s.Entries))
+	for _, e := range s.Entries {
+		if len(e.Vector) != len(query) {
+			continue
+		}
+		candidates = append(candidates, scored{e, CosineSimilarity(query, e.Vector)})
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].score > candidates[j].score
+	})
+
+	if len(candidates) > k {
+		candidates = candidates[:k]
+	}
+
+	nearest := make([]Embedding, len(candidates))
+	for i, c := range candidates {
+		nearest[i] = c.entry
+	}
+	return nearest
+}
+
+// CosineSimilarity returns the cosine of the angle between a and b, or 0
+// when their lengths differ or either is a zero vector
+func CosineSimilarity(a, b []float32) float64 {
+	if len(a) != len(b) {
+		return 0
+	}
+
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
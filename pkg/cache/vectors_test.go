@@ -0,0 +1,77 @@
+package cache
+
+import (
+	"math"
+	"testing"
+)
+
+func TestCosineSimilarity(t *testing.T) {
+	tests := []struct {
+		a, b []float32
+		want float64
+	}{
+		{[]float32{1, 0}, []float32{2, 0}, 1},
+		{[]float32{1, 0}, []float32{0, 3}, 0},
+		{[]float32{1, 1}, []float32{-1, -1}, -1},
+		{[]float32{1, 0}, []float32{1, 0, 0}, 0},
+		{[]float32{0, 0}, []float32{1, 0}, 0},
+	}
+
+	for _, tt := range tests {
+		if got := CosineSimilarity(tt.a, tt.b); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("CosineSimilarity(%v, %v) = %v, expected %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestVectorStoreNearest(t *testing.T) {
+	store := &VectorStore{}
+	store.Add("a", "Fix login redirect", []float32{1, 0, 0})
+	store.Add("b", "Update README", []float32{0, 1, 0})
+	store.Add("c", "Fix logout redirect", []float32{0.9, 0.1, 0})
+	store.Add("d", "Other model", []float32{1, 0})
+
+	nearest := store.Nearest([]float32{1, 0.05, 0}, 2)
+	if len(nearest) != 2 {
+		t.Fatalf("Expected 2 entries, got %d", len(nearest))
+	}
+	if nearest[0].Hash != "a" || nearest[1].Hash != "c" {
+		t.Errorf("Expected a then c, got %s then %s", nearest[0].Hash, nearest[1].Hash)
+	}
+}
+
+func TestVectorStoreSaveLoadRetain(t *testing.T) {
+	cache := NewCache(t.TempDir())
+
+	store, err := cache.LoadVectors("nomic-embed-text")
+	if err != nil {
+		t.Fatalf("Failed to load empty store: %v", err)
+	}
+	if len(store.Entries) != 0 {
+		t.Fatalf("Expected an empty store, got %d entries", len(store.Entries))
+	}
+
+	store.Add("a", "Fix login", []float32{1, 2})
+	store.Add("b", "Add signup", []float32{3, 4})
+	store.Retain([]string{"b"})
+	if err := store.Save(); err != nil {
+		t.Fatalf("Failed to save store: %v", err)
+	}
+
+	loaded, err := cache.LoadVectors("nomic-embed-text")
+	if err != nil {
+		t.Fatalf("Failed to load store: %v", err)
+	}
+	if loaded.Has("a") || !loaded.Has("b") {
+		t.Errorf("Expected only b to be kept, got %v", loaded.Entries)
+	}
+	if loaded.Entries[0].Vector[1] != 4 {
+		t.Errorf("Expected vector to round-trip, got %v", loaded.Entries[0].Vector)
+	}
+
+	// Another model's vectors live in a separate store
+	other, err := cache.LoadVectors("all-minilm")
+	if err != nil || len(other.Entries) != 0 {
+		t.Errorf("Expected an empty store for another model, got %v (err %v)", other, err)
+	}
+}
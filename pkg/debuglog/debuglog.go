@@ -0,0 +1,83 @@
+package debuglog
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gh-smart-commit/pkg/ollama"
+)
+
+// Entry is a single model exchange written to the debug log
+type Entry struct {
+	Time         time.Time      `json:"time"`
+	Command      string         `json:"command"`
+	Model        string         `json:"model"`
+	Options      ollama.Options `json:"options"`
+	Format       string         `json:"format,omitempty"`
+	SystemPrompt string         `json:"system_prompt"`
+	UserPrompt   string         `json:"user_prompt"`
+	Messages     int            `json:"messages"`
+	Response     string         `json:"response"`
+	Error        string         `json:"error,omitempty"`
+}
+
+// NewEntry records a chat request and the raw response it produced. When
+// the request carries a conversation, the last user message is logged as
+// the user prompt.
+func NewEntry(command string, req ollama.ChatRequest, response string, err error) Entry {
+	entry := Entry{
+		Time:     time.Now(),
+		Command:  command,
+		Model:    req.Model,
+		Options:  req.Options,
+		Format:   req.Format,
+		Messages: len(req.Messages),
+		Response: response,
+	}
+
+	var system []string
+	for _, msg := range req.Messages {
+		switch msg.Role {
+		case "system":
+			system = append(system, msg.Content)
+		case "user":
+			entry.UserPrompt = msg.Content
+		}
+	}
+	entry.SystemPrompt = strings.Join(system, "\n\n")
+
+	if err != nil {
+		entry.Error = err.Error()
+	}
+
+	return entry
+}
+
+// Append writes entry as one JSON line to the file at path, creating the
+// file and its directory if needed
+func Append(path string, entry Entry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to marshal log entry: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create log directory: %w", err)
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open log file: %w", err)
+	}
+	defer file.Close()
+
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write log entry: %w", err)
+	}
+
+	return nil
+}
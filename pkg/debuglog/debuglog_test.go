@@ -0,0 +1,73 @@
+package debuglog
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gh-smart-commit/pkg/ollama"
+)
+
+func TestNewEntry(t *testing.T) {
+	req := ollama.ChatRequest{
+		Model: "llama3.1:8b",
+		Messages: []ollama.Message{
+			{Role: "system", Content: "be terse"},
+			{Role: "user", Content: "first"},
+			{Role: "assistant", Content: "reply"},
+			{Role: "user", Content: "second"},
+		},
+		Options: ollama.Options{Temperature: 0.3, Seed: 7},
+	}
+
+	entry := NewEntry("smart-commit", req, "feat: add x", errors.New("boom"))
+
+	if entry.SystemPrompt != "be terse" {
+		t.Errorf("Expected system prompt 'be terse', got %q", entry.SystemPrompt)
+	}
+	if entry.UserPrompt != "second" {
+		t.Errorf("Expected last user prompt 'second', got %q", entry.UserPrompt)
+	}
+	if entry.Messages != 4 {
+		t.Errorf("Expected 4 messages, got %d", entry.Messages)
+	}
+	if entry.Options.Seed != 7 {
+		t.Errorf("Expected seed 7, got %d", entry.Options.Seed)
+	}
+	if entry.Error != "boom" {
+		t.Errorf("Expected error 'boom', got %q", entry.Error)
+	}
+}
+
+func TestAppend(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs", "debug.jsonl")
+
+	for _, response := range []string{"one", "two"} {
+		if err := Append(path, Entry{Command: "bash", Response: response}); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("Failed to open log: %v", err)
+	}
+	defer file.Close()
+
+	var responses []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var entry Entry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			t.Fatalf("Invalid JSON line %q: %v", scanner.Text(), err)
+		}
+		responses = append(responses, entry.Response)
+	}
+
+	if len(responses) != 2 || responses[0] != "one" || responses[1] != "two" {
+		t.Errorf("Expected [one two], got %v", responses)
+	}
+}
@@ -0,0 +1,156 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// commitHooks are the client-side hooks that can reject a commit
+var commitHooks = []string{"pre-commit", "prepare-commit-msg", "commit-msg"}
+
+// HookError reports a commit rejected by a repository hook
+type HookError struct {
+	Hooks  []string
+	Output string
+	Err    error
+}
+
+func (e *HookError) Error() string {
+	return fmt.Sprintf("commit rejected by hook (%s): %v", strings.Join(e.Hooks, ", "), e.Err)
+}
+
+func (e *HookError) Unwrap() error {
+	return e.Err
+}
+
+// verifyHooks are the hooks git commit --no-verify skips
+var verifyHooks = map[string]bool{"pre-commit": true, "commit-msg": true}
+
+// CommitOptions adjusts how Commit and Amend run git commit
+type CommitOptions struct {
+	// NoVerify passes --no-verify, skipping the pre-commit and commit-msg hooks
+	NoVerify bool
+}
+
+// Commit records the staged changes with message and returns git's output,
+// including anything printed by hooks. A body after the subject line is
+// passed as a second -m paragraph. When a commit hook rejects the commit the
+// error is a *HookError.
+func (r *LocalRepo) Commit(ctx context.Context, message string, opts CommitOptions) (string, error) {
+	return r.commit(ctx, opts, messageArgs(message)...)
+}
+
+// Amend folds the staged changes into HEAD. An empty message keeps the
+// existing one. Output and errors are reported as for Commit.
+func (r *LocalRepo) Amend(ctx context.Context, message string, opts CommitOptions) (string, error) {
+	if message == "" {
+		return r.commit(ctx, opts, "--amend", "--no-edit")
+	}
+	return r.commit(ctx, opts, append([]string{"--amend"}, messageArgs(message)...)...)
+}
+
+// messageArgs turns a message into -m arguments, one for the subject and
+// one for the body
+func messageArgs(message string) []string {
+	parts := strings.SplitN(strings.TrimSpace(message), "\n", 2)
+	args := []string{"-m", strings.TrimSpace(parts[0])}
+	if len(parts) > 1 {
+		if body := strings.TrimSpace(parts[1]); body != "" {
+			args = append(args, "-m", body)
+		}
+	}
+	return args
+}
+
+// commit runs git commit with args, capturing its combined output
+func (r *LocalRepo) commit(ctx context.Context, opts CommitOptions, args ...string) (string, error) {
+	args = append([]string{"commit"}, args...)
+	if opts.NoVerify {
+		args = append(args, "--no-verify")
+	}
+
+	var output []byte
+	var err error
+	if combined, ok := r.runner.(CombinedRunner); ok {
+		output, err = combined.RunCombined(ctx, "git", args...)
+	} else {
+		output, err = r.git(ctx, args...)
+	}
+
+	out := strings.TrimRight(string(output), "\n")
+	if err == nil {
+		return out, nil
+	}
+
+	// Output() keeps stderr on the exit error when it wasn't captured
+	var exitErr *exec.ExitError
+	if out == "" && errors.As(err, &exitErr) {
+		out = strings.TrimRight(string(exitErr.Stderr), "\n")
+	}
+
+	if rejectedByHook(err, out) {
+		if hooks := r.installedCommitHooks(ctx, opts.NoVerify); len(hooks) > 0 {
+			return out, &HookError{Hooks: hooks, Output: out, Err: err}
+		}
+	}
+
+	return out, fmt.Errorf("failed to commit: %w", err)
+}
+
+// commitRefusals are the messages git commit fails with on its own after the
+// hooks have passed
+var commitRefusals = []string{
+	"nothing to commit",
+	"nothing added to commit",
+	"no changes added to commit",
+	"Aborting commit due to empty commit message",
+}
+
+// rejectedByHook reports whether a failed git commit may have been stopped by
+// a hook. A rejecting hook makes git exit 1 without a message of its own,
+// while git's own failures, such as a missing identity or a failed
+// signature, exit 128, and finding nothing to commit prints the status.
+func rejectedByHook(err error, output string) bool {
+	if exitCode(err) != 1 {
+		return false
+	}
+	for _, refusal := range commitRefusals {
+		if strings.Contains(output, refusal) {
+			return false
+		}
+	}
+	return true
+}
+
+// installedCommitHooks returns the executable commit hooks in the
+// repository's hooks directory, honouring core.hooksPath. With noVerify the
+// hooks git skips are left out.
+func (r *LocalRepo) installedCommitHooks(ctx context.Context, noVerify bool) []string {
+	output, err := r.git(ctx, "rev-parse", "--git-path", "hooks")
+	if err != nil {
+		return nil
+	}
+
+	dir := strings.TrimSpace(string(output))
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(r.workDir, dir)
+	}
+
+	var hooks []string
+	for _, name := range commitHooks {
+		if noVerify && verifyHooks[name] {
+			continue
+		}
+		info, err := os.Stat(filepath.Join(dir, name))
+		if err == nil && !info.IsDir() && info.Mode()&0111 != 0 {
+			hooks = append(hooks, name)
+		}
+	}
+
+	return hooks
+}
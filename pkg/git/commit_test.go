@@ -0,0 +1,176 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCommitReturnsOutput(t *testing.T) {
+	runner := &fakeRunner{outputs: map[string]string{
+		"git commit -m feat: add x": "lint ok\n[main abc1234] feat: add x\n",
+	}}
+	repo := NewLocalRepoWithRunner(".", runner)
+
+	output, err := repo.Commit(context.Background(), "feat: add x", CommitOptions{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if output != "lint ok\n[main abc1234] feat: add x" {
+		t.Errorf("Unexpected output: %q", output)
+	}
+}
+
+func TestCommitWithBody(t *testing.T) {
+	runner := &fakeRunner{outputs: map[string]string{
+		"git commit -m Fix race -m Explain why.\n\nCo-authored-by: Ann <ann@example.com>": "[main abc1234] Fix race\n",
+	}}
+	repo := NewLocalRepoWithRunner(".", runner)
+
+	if _, err := repo.Commit(context.Background(), "Fix race\n\nExplain why.\n\nCo-authored-by: Ann <ann@example.com>", CommitOptions{}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+}
+
+func TestCommitHookFailure(t *testing.T) {
+	dir := t.TempDir()
+	hooks := filepath.Join(dir, ".git", "hooks")
+	if err := os.MkdirAll(hooks, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(hooks, "pre-commit"), []byte("#!/bin/sh\nexit 1\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	// Sample hooks are not executable and must be ignored
+	if err := os.WriteFile(filepath.Join(hooks, "commit-msg"), []byte("#!/bin/sh\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	runner := &fakeRunner{
+		outputs: map[string]string{
+			"git commit -m fix: y":           "gofmt: main.go needs formatting\n",
+			"git rev-parse --git-path hooks": ".git/hooks\n",
+		},
+		errs: map[string]error{
+			"git commit -m fix: y": exitStatus(1),
+		},
+	}
+	repo := NewLocalRepoWithRunner(dir, runner)
+
+	output, err := repo.Commit(context.Background(), "fix: y", CommitOptions{})
+
+	var hookErr *HookError
+	if !errors.As(err, &hookErr) {
+		t.Fatalf("Expected *HookError, got %v", err)
+	}
+	if len(hookErr.Hooks) != 1 || hookErr.Hooks[0] != "pre-commit" {
+		t.Errorf("Expected [pre-commit], got %v", hookErr.Hooks)
+	}
+	if output != "gofmt: main.go needs formatting" {
+		t.Errorf("Unexpected output: %q", output)
+	}
+}
+
+func TestCommitFailureWithoutHooks(t *testing.T) {
+	runner := &fakeRunner{
+		outputs: map[string]string{
+			"git commit -m fix: y":           "nothing to commit\n",
+			"git rev-parse --git-path hooks": filepath.Join(t.TempDir(), "hooks") + "\n",
+		},
+		errs: map[string]error{
+			"git commit -m fix: y": exitStatus(1),
+		},
+	}
+	repo := NewLocalRepoWithRunner(".", runner)
+
+	_, err := repo.Commit(context.Background(), "fix: y", CommitOptions{})
+	if err == nil {
+		t.Fatal("Expected an error")
+	}
+
+	var hookErr *HookError
+	if errors.As(err, &hookErr) {
+		t.Errorf("Expected a plain error, got %v", err)
+	}
+}
+
+func TestCommitFailureNotFromHook(t *testing.T) {
+	dir := t.TempDir()
+	hooks := filepath.Join(dir, ".git", "hooks")
+	if err := os.MkdirAll(hooks, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(hooks, "pre-commit"), []byte("#!/bin/sh\nexit 0\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		output string
+		err    error
+	}{
+		{"nothing to commit", "On branch main\nnothing to commit, working tree clean\n", exitStatus(1)},
+		{"unknown identity", "Author identity unknown\n\nfatal: unable to auto-detect email address\n", exitStatus(128)},
+		{"signing failed", "error: gpg failed to sign the data\nfatal: failed to write commit object\n", exitStatus(128)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner := &fakeRunner{
+				outputs: map[string]string{
+					"git commit -m fix: y":           tt.output,
+					"git rev-parse --git-path hooks": ".git/hooks\n",
+				},
+				errs: map[string]error{"git commit -m fix: y": tt.err},
+			}
+			repo := NewLocalRepoWithRunner(dir, runner)
+
+			_, err := repo.Commit(context.Background(), "fix: y", CommitOptions{})
+			if err == nil {
+				t.Fatal("Expected an error")
+			}
+
+			var hookErr *HookError
+			if errors.As(err, &hookErr) {
+				t.Errorf("Expected a plain error, got %v", err)
+			}
+		})
+	}
+}
+
+func TestAmend(t *testing.T) {
+	runner := &fakeRunner{outputs: map[string]string{
+		"git commit --amend --no-edit":           "[main abc1234] Fix race\n",
+		"git commit --amend -m Fix race in auth": "[main def5678] Fix race in auth\n",
+	}}
+	repo := NewLocalRepoWithRunner(".", runner)
+
+	if _, err := repo.Amend(context.Background(), "", CommitOptions{}); err != nil {
+		t.Fatalf("Unexpected error keeping the message: %v", err)
+	}
+	if _, err := repo.Amend(context.Background(), "Fix race in auth", CommitOptions{}); err != nil {
+		t.Fatalf("Unexpected error replacing the message: %v", err)
+	}
+}
+
+func TestCommitNoVerify(t *testing.T) {
+	runner := &fakeRunner{outputs: map[string]string{
+		"git commit -m fix: y --no-verify":         "[main abc1234] fix: y\n",
+		"git commit --amend --no-edit --no-verify": "[main abc1234] fix: y\n",
+		"git commit --amend -m fix: z --no-verify": "[main def5678] fix: z\n",
+	}}
+	repo := NewLocalRepoWithRunner(".", runner)
+	opts := CommitOptions{NoVerify: true}
+
+	if _, err := repo.Commit(context.Background(), "fix: y", opts); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := repo.Amend(context.Background(), "", opts); err != nil {
+		t.Fatalf("Unexpected error keeping the message: %v", err)
+	}
+	if _, err := repo.Amend(context.Background(), "fix: z", opts); err != nil {
+		t.Fatalf("Unexpected error replacing the message: %v", err)
+	}
+}
@@ -0,0 +1,108 @@
+package git
+
+import (
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// languages maps file extensions to language names
+var languages = map[string]string{
+	".go":    "Go",
+	".py":    "Python",
+	".js":    "JavaScript",
+	".jsx":   "JavaScript",
+	".mjs":   "JavaScript",
+	".ts":    "TypeScript",
+	".tsx":   "TypeScript",
+	".rb":    "Ruby",
+	".rs":    "Rust",
+	".java":  "Java",
+	".kt":    "Kotlin",
+	".swift": "Swift",
+	".c":     "C",
+	".h":     "C",
+	".cc":    "C++",
+	".cpp":   "C++",
+	".hpp":   "C++",
+	".cs":    "C#",
+	".php":   "PHP",
+	".sh":    "Shell",
+	".sql":   "SQL",
+}
+
+// languageFiles maps lower-cased file names without a telling extension to
+// language names
+var languageFiles = map[string]string{
+	"dockerfile":    "Dockerfile",
+	"containerfile": "Dockerfile",
+	"makefile":      "Makefile",
+}
+
+// fileLanguage returns the language file is written in, or "" when unknown
+func fileLanguage(file string) string {
+	if language, ok := languages[strings.ToLower(path.Ext(file))]; ok {
+		return language
+	}
+
+	// Dockerfile.dev and the like are still Dockerfiles
+	base := strings.ToLower(path.Base(file))
+	if language, ok := languageFiles[strings.SplitN(base, ".", 2)[0]]; ok {
+		return language
+	}
+	return ""
+}
+
+// DetectLanguage returns the language most of files are written in, or ""
+// when none of them has a known extension. Ties go to the language seen first.
+func DetectLanguage(files []string) string {
+	if top := DetectLanguages(files, 1); len(top) > 0 {
+		return top[0]
+	}
+	return ""
+}
+
+// DetectLanguages returns up to n languages of files, the most common first.
+// Ties go to the language seen first.
+func DetectLanguages(files []string, n int) []string {
+	counts := make(map[string]int)
+	var order []string
+
+	for _, file := range files {
+		language := fileLanguage(file)
+		if language == "" {
+			continue
+		}
+		if counts[language] == 0 {
+			order = append(order, language)
+		}
+		counts[language]++
+	}
+
+	sort.SliceStable(order, func(i, j int) bool {
+		return counts[order[i]] > counts[order[j]]
+	})
+	if len(order) > n {
+		order = order[:n]
+	}
+	return order
+}
+
+// ticketPattern matches issue keys such as "PROJ-123" or a leading issue
+// number such as "123-fix-login" in branch names
+var ticketPattern = regexp.MustCompile(`([A-Z][A-Z0-9]+-\d+)|(?:^|/)(\d+)[-_]`)
+
+// TicketFromBranch extracts an issue reference from a branch name, e.g.
+// "PROJ-123" from "feature/PROJ-123-login" or "#42" from "42-fix-typo"
+func TicketFromBranch(branch string) string {
+	matches := ticketPattern.FindStringSubmatch(branch)
+	switch {
+	case matches == nil:
+		return ""
+	case matches[1] != "":
+		return matches[1]
+	default:
+		return "#" + matches[2]
+	}
+}
@@ -0,0 +1,57 @@
+package git
+
+import "testing"
+
+func TestDetectLanguage(t *testing.T) {
+	tests := []struct {
+		files    []string
+		expected string
+	}{
+		{[]string{"main.go", "cmd/root.go", "README.md"}, "Go"},
+		{[]string{"app.ts", "index.js", "util.ts"}, "TypeScript"},
+		{[]string{"README.md", "logo.png"}, ""},
+		{nil, ""},
+	}
+
+	for _, tt := range tests {
+		if got := DetectLanguage(tt.files); got != tt.expected {
+			t.Errorf("DetectLanguage(%v) = %q, expected %q", tt.files, got, tt.expected)
+		}
+	}
+}
+
+func TestDetectLanguages(t *testing.T) {
+	files := []string{"db/schema.sql", "main.go", "Dockerfile.dev", "cmd/root.go", "db/seed.sql", "util.go", "README.md"}
+
+	got := DetectLanguages(files, 2)
+	if len(got) != 2 || got[0] != "Go" || got[1] != "SQL" {
+		t.Errorf("Expected [Go SQL], got %v", got)
+	}
+
+	if got := DetectLanguages([]string{"Dockerfile", "build/Containerfile"}, 2); len(got) != 1 || got[0] != "Dockerfile" {
+		t.Errorf("Expected [Dockerfile], got %v", got)
+	}
+	if got := DetectLanguages([]string{"README.md"}, 2); len(got) != 0 {
+		t.Errorf("Expected no languages, got %v", got)
+	}
+}
+
+func TestTicketFromBranch(t *testing.T) {
+	tests := []struct {
+		branch   string
+		expected string
+	}{
+		{"feature/PROJ-123-login", "PROJ-123"},
+		{"ABC-7", "ABC-7"},
+		{"42-fix-typo", "#42"},
+		{"fix/108_nil_check", "#108"},
+		{"main", ""},
+		{"release-2", ""},
+	}
+
+	for _, tt := range tests {
+		if got := TicketFromBranch(tt.branch); got != tt.expected {
+			t.Errorf("TicketFromBranch(%q) = %q, expected %q", tt.branch, got, tt.expected)
+		}
+	}
+}
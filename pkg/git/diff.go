@@ -0,0 +1,448 @@
+package git
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// fileDiff is one file's section of a unified diff
+type fileDiff struct {
+	header []string   // "diff --git" line through the "+++" line
+	hunks  [][]string // each hunk starts with its "@@" line
+}
+
+// parseDiff splits a unified diff into per-file sections and hunks. Lines
+// before the first "diff --git" header are ignored.
+func parseDiff(diff string) []fileDiff {
+	var files []fileDiff
+	var current *fileDiff
+
+	for _, line := range strings.Split(diff, "\n") {
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			files = append(files, fileDiff{header: []string{line}})
+			current = &files[len(files)-1]
+		case current == nil:
+			continue
+		case strings.HasPrefix(line, "@@"):
+			current.hunks = append(current.hunks, []string{line})
+		case len(current.hunks) > 0:
+			last := len(current.hunks) - 1
+			current.hunks[last] = append(current.hunks[last], line)
+		default:
+			current.header = append(current.header, line)
+		}
+	}
+
+	return files
+}
+
+// String reassembles the file section
+func (f fileDiff) String() string {
+	lines := append([]string{}, f.header...)
+	for _, hunk := range f.hunks {
+		lines = append(lines, hunk...)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// FilterHunks keeps only the hunks with an added or removed line matching
+// pattern. Files left without hunks are dropped entirely.
+func FilterHunks(diff string, pattern *regexp.Regexp) string {
+	var kept []string
+
+	for _, file := range parseDiff(diff) {
+		var hunks [][]string
+		for _, hunk := range file.hunks {
+			if hunkMatches(hunk, pattern) {
+				hunks = append(hunks, hunk)
+			}
+		}
+
+		if len(hunks) > 0 {
+			file.hunks = hunks
+			kept = append(kept, strings.TrimRight(file.String(), "\n"))
+		}
+	}
+
+	if len(kept) == 0 {
+		return ""
+	}
+
+	return strings.Join(kept, "\n") + "\n"
+}
+
+// hunkMatches reports whether any changed line in hunk matches pattern
+func hunkMatches(hunk []string, pattern *regexp.Regexp) bool {
+	for _, line := range hunk[1:] {
+		if (strings.HasPrefix(line, "+") || strings.HasPrefix(line, "-")) && pattern.MatchString(line[1:]) {
+			return true
+		}
+	}
+	return false
+}
+
+// DefaultGeneratedPatterns are the globs FilterDiff treats as generated files
+var DefaultGeneratedPatterns = []string{
+	"*.pb.go",
+	"*_pb2.py",
+	"*.min.js",
+	"*.min.css",
+	"*.map",
+	"dist/**",
+	"vendor/**",
+	"package-lock.json",
+	"yarn.lock",
+	"go.sum",
+}
+
+// FilterOptions controls which files FilterDiff strips
+type FilterOptions struct {
+	// GeneratedPatterns are globs for generated files. A pattern without a
+	// slash matches the file name in any directory; "dir/**" matches
+	// everything below a dir directory.
+	GeneratedPatterns []string
+}
+
+// FilterDiff removes the contents of binary and generated files from a
+// diff. Each such file keeps its "diff --git" line and a one-line note so
+// the change itself is still visible.
+func FilterDiff(diff string, opts FilterOptions) string {
+	files := parseDiff(diff)
+	if len(files) == 0 {
+		return diff
+	}
+
+	sections := make([]string, 0, len(files))
+	for _, file := range files {
+		switch {
+		case file.isBinary():
+			sections = append(sections, file.header[0]+"\n(binary file, contents omitted)")
+		case isGenerated(file.path(), opts.GeneratedPatterns):
+			sections = append(sections, file.header[0]+"\n(generated file, contents omitted)")
+		default:
+			sections = append(sections, strings.TrimRight(file.String(), "\n"))
+		}
+	}
+
+	return strings.Join(sections, "\n") + "\n"
+}
+
+// DiffFiles returns the paths of the files a diff touches
+func DiffFiles(diff string) []string {
+	var files []string
+	for _, file := range parseDiff(diff) {
+		files = append(files, file.path())
+	}
+	return files
+}
+
+// path returns the file's path after the change, or before it for deletions
+func (f fileDiff) path() string {
+	for _, line := range f.header {
+		if strings.HasPrefix(line, "+++ b/") {
+			return strings.TrimPrefix(line, "+++ b/")
+		}
+	}
+
+	// No "+++" line for deletions and binary files; fall back to "diff --git a/x b/x"
+	header := strings.TrimPrefix(f.header[0], "diff --git ")
+	if i := strings.LastIndex(header, " b/"); i >= 0 {
+		return header[i+len(" b/"):]
+	}
+	return strings.TrimPrefix(header, "a/")
+}
+
+// isBinary reports whether git described the file as binary
+func (f fileDiff) isBinary() bool {
+	for _, line := range f.header {
+		if (strings.HasPrefix(line, "Binary files ") && strings.HasSuffix(line, " differ")) || line == "GIT binary patch" {
+			return true
+		}
+	}
+	return false
+}
+
+// isGenerated reports whether file matches any of patterns
+func isGenerated(file string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if matchGlob(pattern, file) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchGlob matches file against a gitignore-like glob
+func matchGlob(pattern, file string) bool {
+	if dir, ok := strings.CutSuffix(pattern, "/**"); ok {
+		return strings.HasPrefix(file, dir+"/") || strings.Contains(file, "/"+dir+"/")
+	}
+
+	if !strings.Contains(pattern, "/") {
+		matched, _ := path.Match(pattern, path.Base(file))
+		return matched
+	}
+
+	matched, _ := path.Match(pattern, file)
+	return matched
+}
+
+// assetKinds classifies asset files by extension
+var assetKinds = map[string]string{
+	".png": "image", ".jpg": "image", ".jpeg": "image", ".gif": "image",
+	".webp": "image", ".svg": "image", ".ico": "image", ".bmp": "image",
+	".woff": "font", ".woff2": "font", ".ttf": "font", ".otf": "font", ".eot": "font",
+	".mp4": "video", ".webm": "video", ".mov": "video",
+	".mp3": "audio", ".wav": "audio", ".ogg": "audio",
+	".pdf": "document",
+}
+
+// DescribeAssets summarizes changes to asset and other binary files, whose
+// diffs carry no useful text, as short notes such as
+// "Added 3 images under assets/". Files are grouped by change, kind and
+// directory in the order they appear.
+func DescribeAssets(diff string) []string {
+	type group struct {
+		action, kind, dir string
+		files             []string
+	}
+
+	var groups []*group
+	index := make(map[string]*group)
+
+	for _, file := range parseDiff(diff) {
+		name := file.path()
+		kind, ok := assetKinds[strings.ToLower(path.Ext(name))]
+		if !ok {
+			if !file.isBinary() {
+				continue
+			}
+			kind = "binary file"
+		}
+
+		action := file.action()
+		dir := path.Dir(name)
+		key := action + "|" + kind + "|" + dir
+		if index[key] == nil {
+			index[key] = &group{action: action, kind: kind, dir: dir}
+			groups = append(groups, index[key])
+		}
+		index[key].files = append(index[key].files, name)
+	}
+
+	notes := make([]string, 0, len(groups))
+	for _, g := range groups {
+		if len(g.files) == 1 {
+			notes = append(notes, fmt.Sprintf("%s %s %s", g.action, g.kind, g.files[0]))
+			continue
+		}
+
+		location := "under " + g.dir + "/"
+		if g.dir == "." {
+			location = "at the repository root"
+		}
+		notes = append(notes, fmt.Sprintf("%s %d %ss %s", g.action, len(g.files), g.kind, location))
+	}
+
+	return notes
+}
+
+// FileGroup is a set of changed files under one directory
+type FileGroup struct {
+	Dir   string   // Directory, "." for the repository root
+	Files []string // Paths in the order they were given
+	Exts  []string // Distinct file extensions, most common first
+}
+
+// GroupFiles groups paths by their first depth directories, so 300 files
+// read as a handful of components. Groups are ordered by size, largest first.
+func GroupFiles(files []string, depth int) []FileGroup {
+	var groups []*FileGroup
+	index := make(map[string]*FileGroup)
+	extCounts := make(map[string]map[string]int)
+
+	for _, file := range files {
+		dir := path.Dir(file)
+		if parts := strings.Split(dir, "/"); depth > 0 && len(parts) > depth {
+			dir = strings.Join(parts[:depth], "/")
+		}
+
+		if index[dir] == nil {
+			index[dir] = &FileGroup{Dir: dir}
+			extCounts[dir] = make(map[string]int)
+			groups = append(groups, index[dir])
+		}
+		index[dir].Files = append(index[dir].Files, file)
+		if ext := path.Ext(file); ext != "" {
+			extCounts[dir][ext]++
+		}
+	}
+
+	result := make([]FileGroup, 0, len(groups))
+	for _, g := range groups {
+		counts := extCounts[g.Dir]
+		for ext := range counts {
+			g.Exts = append(g.Exts, ext)
+		}
+		sort.Slice(g.Exts, func(i, j int) bool {
+			if counts[g.Exts[i]] != counts[g.Exts[j]] {
+				return counts[g.Exts[i]] > counts[g.Exts[j]]
+			}
+			return g.Exts[i] < g.Exts[j]
+		})
+		result = append(result, *g)
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return len(result[i].Files) > len(result[j].Files)
+	})
+	return result
+}
+
+// action describes how the file changed, e.g. "Added" or "Deleted"
+func (f fileDiff) action() string {
+	for _, line := range f.header {
+		switch {
+		case strings.HasPrefix(line, "new file mode"):
+			return "Added"
+		case strings.HasPrefix(line, "deleted file mode"):
+			return "Deleted"
+		case strings.HasPrefix(line, "rename from"):
+			return "Renamed"
+		}
+	}
+	return "Updated"
+}
+
+// SelectHunks splits diff into the hunks that fit in maxLines and the rest.
+// Hunks with the most added and removed lines are picked first; a file's
+// header counts against the budget once. Both results keep the original
+// file and hunk order.
+func SelectHunks(diff string, maxLines int) (kept, overflow string) {
+	files := parseDiff(diff)
+
+	type item struct {
+		file, hunk int // hunk is -1 for a file without hunks
+		size       int
+		changed    int
+	}
+
+	var items []item
+	for i, file := range files {
+		if len(file.hunks) == 0 {
+			items = append(items, item{file: i, hunk: -1})
+			continue
+		}
+		for j, hunk := range file.hunks {
+			items = append(items, item{file: i, hunk: j, size: len(hunk), changed: changedLines(hunk)})
+		}
+	}
+
+	sort.SliceStable(items, func(a, b int) bool {
+		return items[a].changed > items[b].changed
+	})
+
+	selected := make(map[[2]int]bool)
+	headerUsed := make(map[int]bool)
+	used := 0
+	for _, it := range items {
+		cost := it.size
+		if !headerUsed[it.file] {
+			cost += len(files[it.file].header)
+		}
+		if used+cost > maxLines {
+			continue
+		}
+		used += cost
+		headerUsed[it.file] = true
+		selected[[2]int{it.file, it.hunk}] = true
+	}
+
+	var keptParts, overflowParts []string
+	for i, file := range files {
+		if len(file.hunks) == 0 {
+			section := strings.TrimRight(file.String(), "\n")
+			if selected[[2]int{i, -1}] {
+				keptParts = append(keptParts, section)
+			} else {
+				overflowParts = append(overflowParts, section)
+			}
+			continue
+		}
+
+		keptFile := fileDiff{header: file.header}
+		overflowFile := fileDiff{header: file.header}
+		for j, hunk := range file.hunks {
+			if selected[[2]int{i, j}] {
+				keptFile.hunks = append(keptFile.hunks, hunk)
+			} else {
+				overflowFile.hunks = append(overflowFile.hunks, hunk)
+			}
+		}
+		if len(keptFile.hunks) > 0 {
+			keptParts = append(keptParts, strings.TrimRight(keptFile.String(), "\n"))
+		}
+		if len(overflowFile.hunks) > 0 {
+			overflowParts = append(overflowParts, strings.TrimRight(overflowFile.String(), "\n"))
+		}
+	}
+
+	return joinSections(keptParts), joinSections(overflowParts)
+}
+
+// ChunkDiff splits diff into chunks of whole files, keeping their order, so
+// that each chunk has at most maxLines lines. A file longer than maxLines gets
+// a chunk of its own, truncated to maxLines.
+func ChunkDiff(diff string, maxLines int) []string {
+	var chunks, current []string
+	size := 0
+
+	flush := func() {
+		if len(current) > 0 {
+			chunks = append(chunks, joinSections(current))
+			current, size = nil, 0
+		}
+	}
+
+	for _, file := range parseDiff(diff) {
+		section := strings.TrimRight(file.String(), "\n")
+		lines := strings.Count(section, "\n") + 1
+
+		if maxLines > 0 && lines > maxLines {
+			flush()
+			chunks = append(chunks, TruncateDiff(section, maxLines)+"\n")
+			continue
+		}
+		if maxLines > 0 && size+lines > maxLines {
+			flush()
+		}
+		current = append(current, section)
+		size += lines
+	}
+	flush()
+
+	return chunks
+}
+
+// changedLines counts the added and removed lines in a hunk
+func changedLines(hunk []string) int {
+	count := 0
+	for _, line := range hunk[1:] {
+		if strings.HasPrefix(line, "+") || strings.HasPrefix(line, "-") {
+			count++
+		}
+	}
+	return count
+}
+
+// joinSections joins file sections into a diff
+func joinSections(sections []string) string {
+	if len(sections) == 0 {
+		return ""
+	}
+	return strings.Join(sections, "\n") + "\n"
+}
@@ -0,0 +1,254 @@
+package git
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+const sampleDiff = `diff --git a/auth.go b/auth.go
+index 1111111..2222222 100644
+--- a/auth.go
++++ b/auth.go
+@@ -1,3 +1,3 @@ package auth
+ func login() {
+-	check(user)
++	check(user, password)
+ }
+@@ -10,2 +10,2 @@ func logout() {
+-	log.Print("bye")
++	log.Println("bye")
+diff --git a/README.md b/README.md
+index 3333333..4444444 100644
+--- a/README.md
++++ b/README.md
+@@ -1 +1 @@
+-# Title
++# New title
+`
+
+func TestFilterHunks(t *testing.T) {
+	filtered := FilterHunks(sampleDiff, regexp.MustCompile(`password`))
+
+	if !strings.Contains(filtered, "+\tcheck(user, password)") {
+		t.Errorf("Expected matching hunk to be kept, got:\n%s", filtered)
+	}
+	if !strings.Contains(filtered, "+++ b/auth.go") {
+		t.Errorf("Expected file header to be kept, got:\n%s", filtered)
+	}
+	if strings.Contains(filtered, "log.Println") {
+		t.Errorf("Expected non-matching hunk in the same file to be dropped, got:\n%s", filtered)
+	}
+	if strings.Contains(filtered, "README.md") {
+		t.Errorf("Expected file without matching hunks to be dropped, got:\n%s", filtered)
+	}
+}
+
+func TestFilterHunksIgnoresContextLines(t *testing.T) {
+	// "func login" only appears in an unchanged context line
+	if filtered := FilterHunks(sampleDiff, regexp.MustCompile(`func login`)); filtered != "" {
+		t.Errorf("Expected no hunks when only context lines match, got:\n%s", filtered)
+	}
+}
+
+func TestFilterHunksMultipleFiles(t *testing.T) {
+	filtered := FilterHunks(sampleDiff, regexp.MustCompile(`(?i)title|bye`))
+
+	if !strings.Contains(filtered, "log.Println") || !strings.Contains(filtered, "# New title") {
+		t.Errorf("Expected hunks from both files, got:\n%s", filtered)
+	}
+	if strings.Contains(filtered, "password") {
+		t.Errorf("Expected the password hunk to be dropped, got:\n%s", filtered)
+	}
+}
+
+const mixedDiff = `diff --git a/logo.png b/logo.png
+new file mode 100644
+index 0000000..1234567
+Binary files /dev/null and b/logo.png differ
+diff --git a/api/user.pb.go b/api/user.pb.go
+index 5555555..6666666 100644
+--- a/api/user.pb.go
++++ b/api/user.pb.go
+@@ -1 +1 @@
+-var fileDescriptor = []byte{0x1f}
++var fileDescriptor = []byte{0x2f}
+diff --git a/web/dist/app.js b/web/dist/app.js
+index 7777777..8888888 100644
+--- a/web/dist/app.js
++++ b/web/dist/app.js
+@@ -1 +1 @@
+-!function(){}
++!function(){return 1}
+diff --git a/main.go b/main.go
+index 1111111..2222222 100644
+--- a/main.go
++++ b/main.go
+@@ -1 +1 @@
+-package old
++package main
+`
+
+func TestFilterDiff(t *testing.T) {
+	filtered := FilterDiff(mixedDiff, FilterOptions{GeneratedPatterns: []string{"*.pb.go", "dist/**"}})
+
+	for _, unwanted := range []string{"Binary files", "fileDescriptor", "!function"} {
+		if strings.Contains(filtered, unwanted) {
+			t.Errorf("Expected %q to be stripped, got:\n%s", unwanted, filtered)
+		}
+	}
+
+	for _, wanted := range []string{
+		"diff --git a/logo.png b/logo.png\n(binary file, contents omitted)",
+		"diff --git a/api/user.pb.go b/api/user.pb.go\n(generated file, contents omitted)",
+		"diff --git a/web/dist/app.js b/web/dist/app.js\n(generated file, contents omitted)",
+		"+package main",
+	} {
+		if !strings.Contains(filtered, wanted) {
+			t.Errorf("Expected %q in filtered diff, got:\n%s", wanted, filtered)
+		}
+	}
+}
+
+func TestMatchGlob(t *testing.T) {
+	tests := []struct {
+		pattern string
+		file    string
+		want    bool
+	}{
+		{"*.min.js", "static/js/app.min.js", true},
+		{"*.min.js", "static/js/app.js", false},
+		{"dist/**", "dist/bundle.js", true},
+		{"dist/**", "web/dist/bundle.js", true},
+		{"dist/**", "distribution/notes.md", false},
+		{"api/*.pb.go", "api/user.pb.go", true},
+		{"api/*.pb.go", "internal/api/user.pb.go", false},
+	}
+
+	for _, tt := range tests {
+		if got := matchGlob(tt.pattern, tt.file); got != tt.want {
+			t.Errorf("matchGlob(%q, %q) = %v, expected %v", tt.pattern, tt.file, got, tt.want)
+		}
+	}
+}
+
+func TestDescribeAssets(t *testing.T) {
+	diff := mixedDiff + `diff --git a/assets/a.png b/assets/a.png
+new file mode 100644
+index 0000000..1111111
+Binary files /dev/null and b/assets/a.png differ
+diff --git a/assets/b.png b/assets/b.png
+new file mode 100644
+index 0000000..2222222
+Binary files /dev/null and b/assets/b.png differ
+diff --git a/assets/c.svg b/assets/c.svg
+new file mode 100644
+index 0000000..3333333
+--- /dev/null
++++ b/assets/c.svg
+@@ -0,0 +1 @@
++<svg/>
+diff --git a/fonts/old.woff b/fonts/old.woff
+deleted file mode 100644
+index 4444444..0000000
+Binary files a/fonts/old.woff and /dev/null differ
+`
+
+	notes := DescribeAssets(diff)
+	expected := []string{
+		"Added image logo.png",
+		"Added 3 images under assets/",
+		"Deleted font fonts/old.woff",
+	}
+
+	if strings.Join(notes, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("Expected notes:\n%s\ngot:\n%s", strings.Join(expected, "\n"), strings.Join(notes, "\n"))
+	}
+}
+
+func TestSelectHunks(t *testing.T) {
+	// auth.go's header is 4 lines and its hunks 5 and 3; README.md needs 4 + 3.
+	// Both auth.go hunks change 2 lines, so the first one wins the tie.
+	kept, overflow := SelectHunks(sampleDiff, 9)
+
+	if !strings.Contains(kept, "check(user, password)") {
+		t.Errorf("Expected the first auth.go hunk to be kept, got:\n%s", kept)
+	}
+	if strings.Contains(kept, "README.md") || strings.Contains(kept, "log.Println") {
+		t.Errorf("Expected only one hunk to fit, got:\n%s", kept)
+	}
+	if !strings.Contains(overflow, "log.Println") || !strings.Contains(overflow, "# New title") {
+		t.Errorf("Expected the remaining hunks in the overflow, got:\n%s", overflow)
+	}
+	if strings.Count(overflow, "+++ b/auth.go") != 1 {
+		t.Errorf("Expected overflow to repeat the auth.go header once, got:\n%s", overflow)
+	}
+}
+
+func TestChunkDiff(t *testing.T) {
+	// auth.go is 12 lines and README.md 7
+	chunks := ChunkDiff(sampleDiff, 20)
+	if len(chunks) != 1 || chunks[0] != sampleDiff {
+		t.Errorf("Expected the whole diff in one chunk, got %q", chunks)
+	}
+
+	chunks = ChunkDiff(sampleDiff, 12)
+	if len(chunks) != 2 {
+		t.Fatalf("Expected 2 chunks, got %d: %q", len(chunks), chunks)
+	}
+	if !strings.HasPrefix(chunks[0], "diff --git a/auth.go") || strings.Contains(chunks[0], "README.md") {
+		t.Errorf("Expected auth.go alone in the first chunk, got:\n%s", chunks[0])
+	}
+	if !strings.HasPrefix(chunks[1], "diff --git a/README.md") || !strings.HasSuffix(chunks[1], "+# New title\n") {
+		t.Errorf("Expected README.md in the second chunk, got:\n%s", chunks[1])
+	}
+}
+
+func TestChunkDiffOversizedFile(t *testing.T) {
+	chunks := ChunkDiff(sampleDiff, 8)
+	if len(chunks) != 2 {
+		t.Fatalf("Expected 2 chunks, got %d: %q", len(chunks), chunks)
+	}
+	if !strings.Contains(chunks[0], "(diff truncated after 8 lines)") || strings.Contains(chunks[0], "log.Println") {
+		t.Errorf("Expected auth.go truncated to 8 lines, got:\n%s", chunks[0])
+	}
+	if !strings.Contains(chunks[1], "+# New title") {
+		t.Errorf("Expected README.md in its own chunk, got:\n%s", chunks[1])
+	}
+}
+
+func TestSelectHunksFits(t *testing.T) {
+	kept, overflow := SelectHunks(sampleDiff, 100)
+	if overflow != "" {
+		t.Errorf("Expected no overflow, got:\n%s", overflow)
+	}
+	if kept != sampleDiff {
+		t.Errorf("Expected the diff unchanged, got:\n%s", kept)
+	}
+}
+
+func TestGroupFiles(t *testing.T) {
+	files := []string{
+		"api/gen/v1/user.pb.go",
+		"api/gen/v1/user_grpc.pb.go",
+		"api/gen/v2/order.pb.go",
+		"api/gen/README.md",
+		"cmd/root.go",
+		"go.mod",
+	}
+
+	groups := GroupFiles(files, 2)
+	if len(groups) != 3 {
+		t.Fatalf("Expected 3 groups, got %d: %+v", len(groups), groups)
+	}
+
+	if groups[0].Dir != "api/gen" || len(groups[0].Files) != 4 {
+		t.Errorf("Expected api/gen with 4 files first, got %+v", groups[0])
+	}
+	if len(groups[0].Exts) != 2 || groups[0].Exts[0] != ".go" {
+		t.Errorf("Expected .go to be the most common extension, got %v", groups[0].Exts)
+	}
+	if groups[1].Dir != "cmd" || groups[2].Dir != "." {
+		t.Errorf("Expected equal-sized groups in input order, got %q then %q", groups[1].Dir, groups[2].Dir)
+	}
+}
@@ -2,28 +2,84 @@ package git
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"os/exec"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 )
 
 // Repository represents a Git repository interface
 type Repository interface {
 	GetStagedDiff(ctx context.Context) (string, error)
+	GetStagedDiffWithOpts(ctx context.Context, opts DiffOptions) (string, error)
 	GetUnstagedDiff(ctx context.Context) (string, error)
+	GetStagedDiffContext(ctx context.Context, contextLines int) (string, error)
+	GetStagedDiffStat(ctx context.Context) (string, error)
+	GetUnstagedDiffContext(ctx context.Context, contextLines int) (string, error)
+	GetStagedDiffSince(ctx context.Context, tree string) (string, error)
+	GetIndexTree(ctx context.Context) (string, error)
+	RestoreIndex(ctx context.Context, tree string) error
 	GetCurrentBranch(ctx context.Context) (string, error)
 	GetRepoName(ctx context.Context) (string, error)
 	GetRecentCommits(ctx context.Context, count int) ([]Commit, error)
+	GetCommitsSince(ctx context.Context, since string) ([]Commit, error)
+	GetCommitsInRange(ctx context.Context, from, to string) ([]Commit, error)
+	GetBranchDiff(ctx context.Context, base, target string) (string, error)
+	GetRangeDiff(ctx context.Context, from, to string) (string, error)
 	IsInsideWorkTree(ctx context.Context) (bool, error)
+	HasCommits(ctx context.Context) (bool, error)
+	GetTopLevel(ctx context.Context) (string, error)
+	GetGitDir(ctx context.Context) (string, error)
+	RepoState(ctx context.Context) (State, error)
+	Commit(ctx context.Context, message string, opts CommitOptions) (string, error)
+	Amend(ctx context.Context, message string, opts CommitOptions) (string, error)
+	GetCommit(ctx context.Context, rev string) (Commit, error)
+	GetStagedFiles(ctx context.Context) ([]string, error)
+	GetStagedFileStats(ctx context.Context) ([]FileStat, error)
+	UnstageFile(ctx context.Context, path string) error
+	GetUnstagedFiles(ctx context.Context) ([]string, error)
+	GetUntrackedFiles(ctx context.Context) ([]string, error)
+	StageTrackedChanges(ctx context.Context) ([]string, error)
+	ListFiles(ctx context.Context) ([]string, error)
+	GetDefaultBranch(ctx context.Context) (string, error)
 }
 
+// FileStat is the line count of one staged file. Binary files count no
+// lines.
+type FileStat struct {
+	Path      string
+	Additions int
+	Deletions int
+	Binary    bool
+}
+
+// State describes an operation in progress in the repository
+type State string
+
+const (
+	StateClean         State = ""
+	StateMerging       State = "merge"
+	StateRebasing      State = "rebase"
+	StateCherryPicking State = "cherry-pick"
+	StateReverting     State = "revert"
+)
+
 // Commit represents a Git commit
 type Commit struct {
 	Hash      string
-	Message   string
+	Message   string   // Subject line
+	Body      string   // Rest of the message
+	Parents   []string // Parent hashes; more than one for a merge
 	Author    string
-	Date      string
+	Date      string    // Author date as YYYY-MM-DD in the author's time zone
+	Time      time.Time // Author date, zero if git's output couldn't be parsed
 	Files     []string
+	Renames   map[string]string // Old path of each renamed file in Files, by new path
 	Additions int
 	Deletions int
 }
@@ -31,6 +87,7 @@ type Commit struct {
 // LocalRepo implements Repository for local Git repositories
 type LocalRepo struct {
 	workDir string
+	runner  Runner
 }
 
 // NewLocalRepo creates a new local repository instance
@@ -38,15 +95,42 @@ func NewLocalRepo(workDir string) *LocalRepo {
 	if workDir == "" {
 		workDir = "."
 	}
-	return &LocalRepo{workDir: workDir}
+	return NewLocalRepoWithRunner(workDir, ExecRunner{Dir: workDir})
+}
+
+// NewLocalRepoWithRunner creates a local repository that runs git through runner
+func NewLocalRepoWithRunner(workDir string, runner Runner) *LocalRepo {
+	if workDir == "" {
+		workDir = "."
+	}
+	return &LocalRepo{workDir: workDir, runner: runner}
+}
+
+// git runs a git subcommand in the repository
+func (r *LocalRepo) git(ctx context.Context, args ...string) ([]byte, error) {
+	return r.runner.Run(ctx, "git", args...)
+}
+
+// DiffOptions controls how a diff is produced
+type DiffOptions struct {
+	// IgnoreWhitespace passes --ignore-all-space, so whitespace-only changes
+	// are left out
+	IgnoreWhitespace bool
 }
 
 // GetStagedDiff returns the staged changes
 func (r *LocalRepo) GetStagedDiff(ctx context.Context) (string, error) {
-	cmd := exec.CommandContext(ctx, "git", "--no-pager", "diff", "--cached")
-	cmd.Dir = r.workDir
+	return r.GetStagedDiffWithOpts(ctx, DiffOptions{})
+}
+
+// GetStagedDiffWithOpts returns the staged changes, produced according to opts
+func (r *LocalRepo) GetStagedDiffWithOpts(ctx context.Context, opts DiffOptions) (string, error) {
+	args := []string{"--no-pager", "diff", "--cached"}
+	if opts.IgnoreWhitespace {
+		args = append(args, "--ignore-all-space")
+	}
 
-	output, err := cmd.Output()
+	output, err := r.git(ctx, args...)
 	if err != nil {
 		return "", fmt.Errorf("failed to get staged diff: %w", err)
 	}
@@ -54,12 +138,94 @@ func (r *LocalRepo) GetStagedDiff(ctx context.Context) (string, error) {
 	return string(output), nil
 }
 
+// GetStagedDiffStat returns git's --stat summary of the staged changes
+func (r *LocalRepo) GetStagedDiffStat(ctx context.Context) (string, error) {
+	output, err := r.git(ctx, "--no-pager", "diff", "--cached", "--stat")
+	if err != nil {
+		return "", fmt.Errorf("failed to get staged diff stat: %w", err)
+	}
+
+	return strings.TrimRight(string(output), "\n"), nil
+}
+
+// GetStagedDiffContext returns the staged changes with contextLines lines of
+// unchanged context around each hunk
+func (r *LocalRepo) GetStagedDiffContext(ctx context.Context, contextLines int) (string, error) {
+	output, err := r.git(ctx, "--no-pager", "diff", "--cached", fmt.Sprintf("-U%d", contextLines))
+	if err != nil {
+		return "", fmt.Errorf("failed to get staged diff: %w", err)
+	}
+
+	return string(output), nil
+}
+
+// GetUnstagedDiffContext returns the unstaged changes with contextLines lines
+// of unchanged context around each hunk
+func (r *LocalRepo) GetUnstagedDiffContext(ctx context.Context, contextLines int) (string, error) {
+	output, err := r.git(ctx, "--no-pager", "diff", fmt.Sprintf("-U%d", contextLines))
+	if err != nil {
+		return "", fmt.Errorf("failed to get unstaged diff: %w", err)
+	}
+
+	return string(output), nil
+}
+
+// GetRangeDiff returns the changes between the commits from and to
+func (r *LocalRepo) GetRangeDiff(ctx context.Context, from, to string) (string, error) {
+	return r.GetRangeDiffContext(ctx, from, to, 3)
+}
+
+// GetRangeDiffContext returns the changes between the commits from and to
+// with contextLines lines of unchanged context around each hunk. Both ends
+// must resolve to commits.
+func (r *LocalRepo) GetRangeDiffContext(ctx context.Context, from, to string, contextLines int) (string, error) {
+	for _, rev := range []string{from, to} {
+		if _, err := r.git(ctx, "rev-parse", "--verify", "--quiet", rev+"^{commit}"); err != nil {
+			return "", fmt.Errorf("%q does not name a commit", rev)
+		}
+	}
+
+	output, err := r.git(ctx, "--no-pager", "diff", fmt.Sprintf("-U%d", contextLines), from+".."+to)
+	if err != nil {
+		return "", fmt.Errorf("failed to get diff between %s and %s: %w", from, to, err)
+	}
+
+	return string(output), nil
+}
+
+// GetStagedDiffSince returns the difference between tree and the index,
+// i.e. what has been staged since tree was recorded
+func (r *LocalRepo) GetStagedDiffSince(ctx context.Context, tree string) (string, error) {
+	output, err := r.git(ctx, "--no-pager", "diff", "--cached", tree)
+	if err != nil {
+		return "", fmt.Errorf("failed to get staged diff since %s: %w", tree, err)
+	}
+
+	return string(output), nil
+}
+
+// GetIndexTree writes the index to a tree object and returns its hash
+func (r *LocalRepo) GetIndexTree(ctx context.Context) (string, error) {
+	output, err := r.git(ctx, "write-tree")
+	if err != nil {
+		return "", fmt.Errorf("failed to write index tree: %w", err)
+	}
+
+	return strings.TrimSpace(string(output)), nil
+}
+
+// RestoreIndex replaces the index with tree, as written by GetIndexTree,
+// and leaves the working tree alone
+func (r *LocalRepo) RestoreIndex(ctx context.Context, tree string) error {
+	if _, err := r.git(ctx, "read-tree", tree); err != nil {
+		return fmt.Errorf("failed to restore index: %w", err)
+	}
+	return nil
+}
+
 // GetUnstagedDiff returns the unstaged changes
 func (r *LocalRepo) GetUnstagedDiff(ctx context.Context) (string, error) {
-	cmd := exec.CommandContext(ctx, "git", "--no-pager", "diff")
-	cmd.Dir = r.workDir
-
-	output, err := cmd.Output()
+	output, err := r.git(ctx, "--no-pager", "diff")
 	if err != nil {
 		return "", fmt.Errorf("failed to get unstaged diff: %w", err)
 	}
@@ -67,29 +233,213 @@ func (r *LocalRepo) GetUnstagedDiff(ctx context.Context) (string, error) {
 	return string(output), nil
 }
 
-// GetCurrentBranch returns the current branch name
+// GetStagedFiles returns the paths of files with staged changes
+func (r *LocalRepo) GetStagedFiles(ctx context.Context) ([]string, error) {
+	output, err := r.git(ctx, "--no-pager", "diff", "--cached", "--name-only")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list staged files: %w", err)
+	}
+
+	return splitLines(string(output)), nil
+}
+
+// GetStagedFileStats returns the added and deleted line counts of each
+// staged file. A rename is listed as a deletion and an addition, so each
+// side can be unstaged on its own.
+func (r *LocalRepo) GetStagedFileStats(ctx context.Context) ([]FileStat, error) {
+	output, err := r.git(ctx, "--no-pager", "diff", "--cached", "--numstat", "--no-renames", "-z")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get staged file stats: %w", err)
+	}
+
+	var stats []FileStat
+	for _, entry := range splitNulls(string(output)) {
+		fields := strings.SplitN(entry, "\t", 3)
+		if len(fields) != 3 {
+			continue
+		}
+
+		stat := FileStat{Path: fields[2], Binary: fields[0] == "-"}
+		stat.Additions, _ = strconv.Atoi(fields[0])
+		stat.Deletions, _ = strconv.Atoi(fields[1])
+		stats = append(stats, stat)
+	}
+	return stats, nil
+}
+
+// UnstageFile removes path's staged changes from the index, like
+// git restore --staged, and leaves the working tree alone
+func (r *LocalRepo) UnstageFile(ctx context.Context, path string) error {
+	hasCommits, err := r.HasCommits(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to unstage %s: %w", path, err)
+	}
+
+	args := []string{"restore", "--staged", "--", path}
+	// Before the first commit there is no HEAD to restore from
+	if !hasCommits {
+		args = []string{"rm", "--cached", "--quiet", "--", path}
+	}
+
+	if _, err := r.git(ctx, args...); err != nil {
+		return fmt.Errorf("failed to unstage %s: %w", path, err)
+	}
+	return nil
+}
+
+// GetUnstagedFiles returns the paths of tracked files with unstaged changes
+func (r *LocalRepo) GetUnstagedFiles(ctx context.Context) ([]string, error) {
+	output, err := r.git(ctx, "--no-pager", "diff", "--name-only")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list unstaged files: %w", err)
+	}
+
+	return splitLines(string(output)), nil
+}
+
+// StageTrackedChanges stages every change to tracked files, like git add -u,
+// and returns the paths it staged. Untracked files are left alone.
+func (r *LocalRepo) StageTrackedChanges(ctx context.Context) ([]string, error) {
+	files, err := r.GetUnstagedFiles(ctx)
+	if err != nil || len(files) == 0 {
+		return nil, err
+	}
+
+	if _, err := r.git(ctx, "add", "-u"); err != nil {
+		return nil, fmt.Errorf("failed to stage tracked changes: %w", err)
+	}
+
+	return files, nil
+}
+
+// GetUntrackedFiles returns the files git doesn't track yet, leaving out
+// anything .gitignore excludes
+func (r *LocalRepo) GetUntrackedFiles(ctx context.Context) ([]string, error) {
+	output, err := r.git(ctx, "ls-files", "-z", "--others", "--exclude-standard")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list untracked files: %w", err)
+	}
+
+	return splitNulls(string(output)), nil
+}
+
+// ListFiles returns the tracked and untracked files under the working
+// directory, relative to it, leaving out anything .gitignore excludes
+func (r *LocalRepo) ListFiles(ctx context.Context) ([]string, error) {
+	output, err := r.git(ctx, "ls-files", "-z", "--cached", "--others", "--exclude-standard")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list files: %w", err)
+	}
+
+	return splitNulls(string(output)), nil
+}
+
+// splitNulls splits NUL-separated output such as "git ls-files -z", dropping
+// empty entries
+func splitNulls(output string) []string {
+	var files []string
+	for _, file := range strings.Split(output, "\x00") {
+		if file != "" {
+			files = append(files, file)
+		}
+	}
+	return files
+}
+
+// GetDefaultBranch returns the branch origin/HEAD points to, falling back to
+// a local main or master branch
+func (r *LocalRepo) GetDefaultBranch(ctx context.Context) (string, error) {
+	if output, err := r.git(ctx, "symbolic-ref", "--short", "refs/remotes/origin/HEAD"); err == nil {
+		return strings.TrimPrefix(strings.TrimSpace(string(output)), "origin/"), nil
+	}
+
+	for _, branch := range []string{"main", "master"} {
+		if _, err := r.git(ctx, "rev-parse", "--verify", "--quiet", "refs/heads/"+branch); err == nil {
+			return branch, nil
+		}
+	}
+
+	return "", fmt.Errorf("failed to determine default branch")
+}
+
+// splitLines returns the non-empty lines of output
+func splitLines(output string) []string {
+	var lines []string
+	for _, line := range strings.Split(output, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// DetachedBranchFormat is the name GetCurrentBranch gives a detached HEAD,
+// filled in with the short commit hash
+const DetachedBranchFormat = "HEAD (detached at %s)"
+
+// GetCurrentBranch returns the current branch name, or "HEAD (detached at
+// <short-sha>)" on a detached HEAD
 func (r *LocalRepo) GetCurrentBranch(ctx context.Context) (string, error) {
-	cmd := exec.CommandContext(ctx, "git", "branch", "--show-current")
-	cmd.Dir = r.workDir
+	output, err := r.git(ctx, "branch", "--show-current")
+	if err != nil {
+		return "", fmt.Errorf("failed to get current branch: %w", err)
+	}
 
-	output, err := cmd.Output()
+	if branch := strings.TrimSpace(string(output)); branch != "" {
+		return branch, nil
+	}
+
+	// Detached, e.g. during a bisect or on a checked out tag; name the
+	// commit so prompts and cache keys don't get an empty branch
+	output, err = r.git(ctx, "rev-parse", "--short", "HEAD")
 	if err != nil {
 		return "", fmt.Errorf("failed to get current branch: %w", err)
 	}
+	return fmt.Sprintf(DetachedBranchFormat, strings.TrimSpace(string(output))), nil
+}
+
+// GetTopLevel returns the absolute path of the work tree's root directory
+func (r *LocalRepo) GetTopLevel(ctx context.Context) (string, error) {
+	output, err := r.git(ctx, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return "", fmt.Errorf("failed to locate repository root: %w", err)
+	}
 
 	return strings.TrimSpace(string(output)), nil
 }
 
+// HasCommits reports whether HEAD points at a commit. It is false on the
+// unborn branch of a freshly initialized repository.
+func (r *LocalRepo) HasCommits(ctx context.Context) (bool, error) {
+	_, err := r.git(ctx, "rev-parse", "--verify", "--quiet", "HEAD")
+	if err == nil {
+		return true, nil
+	}
+
+	// With --quiet, rev-parse exits 1 without a message when HEAD doesn't
+	// resolve to a commit; anything else is a real failure
+	if exitCode(err) == 1 {
+		return false, nil
+	}
+	return false, fmt.Errorf("failed to check for commits: %w", err)
+}
+
+// exitCode returns the exit status of a command that ran and failed, or -1
+// when err carries none
+func exitCode(err error) int {
+	var exitErr interface{ ExitCode() int }
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode()
+	}
+	return -1
+}
+
 // GetRepoName returns the repository name
 func (r *LocalRepo) GetRepoName(ctx context.Context) (string, error) {
-	cmd := exec.CommandContext(ctx, "git", "remote", "get-url", "origin")
-	cmd.Dir = r.workDir
-
-	output, err := cmd.Output()
+	output, err := r.git(ctx, "remote", "get-url", "origin")
 	if err != nil {
 		// Fallback to directory name if no remote
-		cmd = exec.CommandContext(ctx, "basename", r.workDir)
-		output, err = cmd.Output()
+		output, err = r.runner.Run(ctx, "basename", r.workDir)
 		if err != nil {
 			return "unknown", nil
 		}
@@ -111,59 +461,191 @@ func (r *LocalRepo) GetRepoName(ctx context.Context) (string, error) {
 // GetRecentCommits returns recent commits with statistics
 func (r *LocalRepo) GetRecentCommits(ctx context.Context, count int) ([]Commit, error) {
 	// Get commit info
-	cmd := exec.CommandContext(ctx, "git", "log",
+	output, err := r.git(ctx, "log",
 		fmt.Sprintf("-%d", count),
-		"--pretty=format:%H|%s|%an|%ad",
-		"--date=short",
+		"--pretty=format:"+commitLogFormat,
+		"--date=iso-strict",
 	)
-	cmd.Dir = r.workDir
-
-	output, err := cmd.Output()
 	if err != nil {
 		return nil, fmt.Errorf("failed to get recent commits: %w", err)
 	}
 
-	lines := strings.Split(string(output), "\n")
-	commits := make([]Commit, 0, len(lines))
+	commits := parseCommitLog(string(output))
+	r.addCommitStats(ctx, commits)
+
+	return commits, nil
+}
+
+// GetCommitsSince returns the commits made since a date git understands,
+// such as "2024-05-01" or "1 week ago", with statistics
+func (r *LocalRepo) GetCommitsSince(ctx context.Context, since string) ([]Commit, error) {
+	output, err := r.git(ctx, "log",
+		"--since="+since,
+		"--pretty=format:"+commitLogFormat,
+		"--date=iso-strict",
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get commits since %s: %w", since, err)
+	}
+
+	commits := parseCommitLog(string(output))
+	r.addCommitStats(ctx, commits)
+
+	return commits, nil
+}
+
+// addCommitStats fills in the files and line counts of each commit. Commits
+// whose stats can't be read are left without them.
+func (r *LocalRepo) addCommitStats(ctx context.Context, commits []Commit) {
+	// Get file stats for each commit in parallel, bounded by the shared pool
+	var wg sync.WaitGroup
+	for i := range commits {
+		wg.Add(1)
+		go func(commit *Commit) {
+			defer wg.Done()
+			sharedPool.Run(ctx, func() error {
+				statsOutput, err := r.git(ctx, "--no-pager", "show", "-M", "--numstat", "--format=", commit.Hash)
+				if err == nil {
+					commit.Files, commit.Renames, commit.Additions, commit.Deletions = parseNumstat(string(statsOutput))
+				}
+				return nil
+			})
+		}(&commits[i])
+	}
+	wg.Wait()
+}
+
+// GetCommitsInRange returns the commits reachable from to but not from from,
+// newest first. An empty from returns the full history of to.
+func (r *LocalRepo) GetCommitsInRange(ctx context.Context, from, to string) ([]Commit, error) {
+	if to == "" {
+		to = "HEAD"
+	}
+
+	revision := to
+	if from != "" {
+		revision = from + ".." + to
+	}
+
+	output, err := r.git(ctx, "log", revision,
+		"--pretty=format:"+commitLogFormat,
+		"--date=iso-strict",
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get commits in %s: %w", revision, err)
+	}
+
+	return parseCommitLog(string(output)), nil
+}
+
+// GetBranchDiff returns the changes on target since it diverged from base
+func (r *LocalRepo) GetBranchDiff(ctx context.Context, base, target string) (string, error) {
+	if target == "" {
+		target = "HEAD"
+	}
 
-	for _, line := range lines {
-		if line == "" {
+	output, err := r.git(ctx, "--no-pager", "diff", base+"..."+target)
+	if err != nil {
+		return "", fmt.Errorf("failed to get diff between %s and %s: %w", base, target, err)
+	}
+
+	return string(output), nil
+}
+
+// GetCommit returns the commit rev resolves to, including its message body
+func (r *LocalRepo) GetCommit(ctx context.Context, rev string) (Commit, error) {
+	output, err := r.git(ctx, "log", "-1", "--pretty=format:%H%x00%an%x00%ad%x00%B", "--date=iso-strict", rev)
+	if err != nil {
+		return Commit{}, fmt.Errorf("failed to read commit %s: %w", rev, err)
+	}
+
+	parts := strings.SplitN(string(output), "\x00", 4)
+	if len(parts) != 4 {
+		return Commit{}, fmt.Errorf("unexpected git log output for %s", rev)
+	}
+
+	subject, body, _ := strings.Cut(strings.TrimSpace(parts[3]), "\n")
+	when, date := parseCommitDate(parts[2])
+	return Commit{
+		Hash:    parts[0],
+		Author:  parts[1],
+		Date:    date,
+		Time:    when,
+		Message: strings.TrimSpace(subject),
+		Body:    strings.TrimSpace(body),
+	}, nil
+}
+
+// IsMerge reports whether the commit has more than one parent
+func (c Commit) IsMerge() bool {
+	return len(c.Parents) > 1
+}
+
+// FullMessage returns the subject and body separated by a blank line
+func (c Commit) FullMessage() string {
+	if c.Body == "" {
+		return c.Message
+	}
+	return c.Message + "\n\n" + c.Body
+}
+
+// commitLogFormat separates fields with the unit separator and ends every
+// commit with the record separator, since subjects and bodies may contain
+// any printable character
+const commitLogFormat = "%H%x1f%s%x1f%an%x1f%ad%x1f%P%x1f%b%x1e"
+
+// parseCommitLog parses git log output written with commitLogFormat
+func parseCommitLog(output string) []Commit {
+	records := strings.Split(output, "\x1e")
+	commits := make([]Commit, 0, len(records))
+
+	for _, record := range records {
+		// git log puts a newline between commits
+		record = strings.TrimLeft(record, "\n")
+		if record == "" {
 			continue
 		}
 
-		parts := strings.Split(line, "|")
-		if len(parts) != 4 {
+		parts := strings.Split(record, "\x1f")
+		if len(parts) != 6 {
 			continue
 		}
 
-		commit := Commit{
+		when, date := parseCommitDate(parts[3])
+		commits = append(commits, Commit{
 			Hash:    parts[0],
 			Message: parts[1],
 			Author:  parts[2],
-			Date:    parts[3],
-		}
-
-		// Get file stats for this commit
-		statsCmd := exec.CommandContext(ctx, "git", "--no-pager", "show", "--stat", "--format=", commit.Hash)
-		statsCmd.Dir = r.workDir
+			Date:    date,
+			Time:    when,
+			Parents: strings.Fields(parts[4]),
+			Body:    strings.TrimSpace(parts[5]),
+		})
+	}
 
-		statsOutput, err := statsCmd.Output()
-		if err == nil {
-			commit.Files, commit.Additions, commit.Deletions = parseGitStats(string(statsOutput))
-		}
+	return commits
+}
 
-		commits = append(commits, commit)
+// parseCommitDate parses a --date=iso-strict date into its time and its
+// YYYY-MM-DD day. A date that doesn't parse is returned as the day as is.
+func parseCommitDate(value string) (time.Time, string) {
+	when, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, value
 	}
-
-	return commits, nil
+	return when, when.Format("2006-01-02")
 }
 
 // IsInsideWorkTree checks if we're inside a Git repository
 func (r *LocalRepo) IsInsideWorkTree(ctx context.Context) (bool, error) {
-	cmd := exec.CommandContext(ctx, "git", "rev-parse", "--is-inside-work-tree")
-	cmd.Dir = r.workDir
+	// Without git every check fails, which would read as "not a repository"
+	if _, ok := r.runner.(ExecRunner); ok {
+		if err := CheckGitAvailable(); err != nil {
+			return false, err
+		}
+	}
 
-	output, err := cmd.Output()
+	output, err := r.git(ctx, "rev-parse", "--is-inside-work-tree")
 	if err != nil {
 		return false, nil // Not a Git repo
 	}
@@ -171,39 +653,103 @@ func (r *LocalRepo) IsInsideWorkTree(ctx context.Context) (bool, error) {
 	return strings.TrimSpace(string(output)) == "true", nil
 }
 
-// parseGitStats parses git --stat output to extract file changes and line counts
-func parseGitStats(stats string) (files []string, additions, deletions int) {
-	lines := strings.Split(stats, "\n")
+// RepoState reports whether a merge, rebase, cherry-pick or revert is in progress
+func (r *LocalRepo) RepoState(ctx context.Context) (State, error) {
+	gitDir, err := r.GetGitDir(ctx)
+	if err != nil {
+		return StateClean, err
+	}
 
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		if line == "" {
-			continue
+	return stateFromGitDir(gitDir), nil
+}
+
+// GetGitDir returns the path of the repository's git directory. In a linked
+// worktree or a submodule, where .git is a file, this is the directory the
+// file points to.
+func (r *LocalRepo) GetGitDir(ctx context.Context) (string, error) {
+	output, err := r.git(ctx, "rev-parse", "--git-dir")
+	if err != nil {
+		return "", fmt.Errorf("failed to locate git directory: %w", err)
+	}
+
+	dir := strings.TrimSpace(string(output))
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(r.workDir, dir)
+	}
+
+	return dir, nil
+}
+
+// stateFromGitDir inspects the marker files Git leaves in its directory
+// while an operation is in progress
+func stateFromGitDir(gitDir string) State {
+	markers := []struct {
+		name  string
+		state State
+	}{
+		{"rebase-merge", StateRebasing},
+		{"rebase-apply", StateRebasing},
+		{"MERGE_HEAD", StateMerging},
+		{"CHERRY_PICK_HEAD", StateCherryPicking},
+		{"REVERT_HEAD", StateReverting},
+	}
+
+	for _, marker := range markers {
+		if _, err := os.Stat(filepath.Join(gitDir, marker.name)); err == nil {
+			return marker.state
 		}
+	}
 
-		// Skip summary line
-		if strings.Contains(line, "file") && (strings.Contains(line, "changed") || strings.Contains(line, "insertion") || strings.Contains(line, "deletion")) {
+	return StateClean
+}
+
+// parseNumstat parses git --numstat output into the changed files and line
+// counts. A rename is listed under its new path and recorded in renames.
+// Binary files, shown as "-", count no lines.
+func parseNumstat(stats string) (files []string, renames map[string]string, additions, deletions int) {
+	for _, line := range strings.Split(stats, "\n") {
+		fields := strings.SplitN(line, "\t", 3)
+		if len(fields) != 3 {
 			continue
 		}
 
-		// Extract filename (before the first |)
-		if strings.Contains(line, "|") {
-			parts := strings.Split(line, "|")
-			if len(parts) >= 2 {
-				filename := strings.TrimSpace(parts[0])
-				if filename != "" {
-					files = append(files, filename)
-				}
+		if n, err := strconv.Atoi(fields[0]); err == nil {
+			additions += n
+		}
+		if n, err := strconv.Atoi(fields[1]); err == nil {
+			deletions += n
+		}
 
-				// Count + and - in the second part
-				changes := parts[1]
-				additions += strings.Count(changes, "+")
-				deletions += strings.Count(changes, "-")
+		oldPath, newPath := splitRename(fields[2])
+		files = append(files, newPath)
+		if oldPath != newPath {
+			if renames == nil {
+				renames = make(map[string]string)
 			}
+			renames[newPath] = oldPath
 		}
 	}
 
-	return files, additions, deletions
+	return files, renames, additions, deletions
+}
+
+// splitRename splits a numstat path such as "old.go => new.go" or
+// "pkg/{old => new}/file.go" into the old and new paths. Other paths are
+// returned as both.
+func splitRename(p string) (oldPath, newPath string) {
+	start, end := strings.Index(p, "{"), strings.LastIndex(p, "}")
+	if start >= 0 && end > start {
+		if from, to, ok := strings.Cut(p[start+1:end], " => "); ok {
+			prefix, suffix := p[:start], p[end+1:]
+			// An empty side, as in "{ => sub}/file.go", leaves a doubled slash
+			return path.Clean(prefix + from + suffix), path.Clean(prefix + to + suffix)
+		}
+	}
+
+	if from, to, ok := strings.Cut(p, " => "); ok {
+		return from, to
+	}
+	return p, p
 }
 
 // TruncateDiff truncates a diff to a maximum number of lines
@@ -0,0 +1,673 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeRunner returns canned output keyed by the full command line
+type fakeRunner struct {
+	mu      sync.Mutex
+	outputs map[string]string
+	errs    map[string]error
+	calls   []string
+}
+
+// exitStatus is a failed run's error, carrying its exit code like
+// *exec.ExitError
+type exitStatus int
+
+func (e exitStatus) Error() string { return fmt.Sprintf("exit status %d", int(e)) }
+
+func (e exitStatus) ExitCode() int { return int(e) }
+
+func (f *fakeRunner) Run(ctx context.Context, name string, args ...string) ([]byte, error) {
+	line := strings.Join(append([]string{name}, args...), " ")
+
+	f.mu.Lock()
+	f.calls = append(f.calls, line)
+	f.mu.Unlock()
+
+	out, ok := f.outputs[line]
+	if !ok {
+		return nil, fmt.Errorf("unexpected command: %s", line)
+	}
+	return []byte(out), f.errs[line]
+}
+
+func TestGetCurrentBranch(t *testing.T) {
+	runner := &fakeRunner{outputs: map[string]string{
+		"git branch --show-current": "feature/x\n",
+	}}
+	repo := NewLocalRepoWithRunner(".", runner)
+
+	branch, err := repo.GetCurrentBranch(context.Background())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if branch != "feature/x" {
+		t.Errorf("Expected 'feature/x', got %q", branch)
+	}
+}
+
+func TestGetCurrentBranchDetached(t *testing.T) {
+	runner := &fakeRunner{outputs: map[string]string{
+		"git branch --show-current":  "",
+		"git rev-parse --short HEAD": "4b825dc\n",
+	}}
+	repo := NewLocalRepoWithRunner(".", runner)
+
+	branch, err := repo.GetCurrentBranch(context.Background())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if branch != "HEAD (detached at 4b825dc)" {
+		t.Errorf("Expected detached name, got %q", branch)
+	}
+}
+
+func TestGetCurrentBranchUnborn(t *testing.T) {
+	runner := &fakeRunner{outputs: map[string]string{
+		"git branch --show-current":           "main\n",
+		"git rev-parse --verify --quiet HEAD": "",
+	}, errs: map[string]error{
+		"git rev-parse --verify --quiet HEAD": exitStatus(1),
+	}}
+	repo := NewLocalRepoWithRunner(".", runner)
+
+	branch, err := repo.GetCurrentBranch(context.Background())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if branch != "main" {
+		t.Errorf("Expected 'main', got %q", branch)
+	}
+
+	if hasCommits, err := repo.HasCommits(context.Background()); hasCommits || err != nil {
+		t.Errorf("Expected no commits on an unborn branch, got %v, %v", hasCommits, err)
+	}
+}
+
+func TestHasCommitsReportsFailures(t *testing.T) {
+	runner := &fakeRunner{outputs: map[string]string{
+		"git rev-parse --verify --quiet HEAD": "",
+	}, errs: map[string]error{
+		"git rev-parse --verify --quiet HEAD": exitStatus(128),
+	}}
+	repo := NewLocalRepoWithRunner(".", runner)
+
+	if _, err := repo.HasCommits(context.Background()); err == nil {
+		t.Error("Expected an error when rev-parse fails for another reason")
+	}
+}
+
+func TestGetTopLevel(t *testing.T) {
+	runner := &fakeRunner{outputs: map[string]string{
+		"git rev-parse --show-toplevel": "/home/ann/project\n",
+	}}
+	repo := NewLocalRepoWithRunner(".", runner)
+
+	root, err := repo.GetTopLevel(context.Background())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if root != "/home/ann/project" {
+		t.Errorf("Expected '/home/ann/project', got %q", root)
+	}
+}
+
+func TestGetRangeDiff(t *testing.T) {
+	runner := &fakeRunner{outputs: map[string]string{
+		"git rev-parse --verify --quiet main^{commit}": "aaa\n",
+		"git rev-parse --verify --quiet HEAD^{commit}": "bbb\n",
+		"git --no-pager diff -U3 main..HEAD":           "diff --git a/x b/x\n",
+		"git rev-parse --verify --quiet nope^{commit}": "",
+	}, errs: map[string]error{
+		"git rev-parse --verify --quiet nope^{commit}": fmt.Errorf("exit status 1"),
+	}}
+	repo := NewLocalRepoWithRunner(".", runner)
+
+	diff, err := repo.GetRangeDiff(context.Background(), "main", "HEAD")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if diff != "diff --git a/x b/x\n" {
+		t.Errorf("Unexpected diff %q", diff)
+	}
+
+	if _, err := repo.GetRangeDiff(context.Background(), "nope", "HEAD"); err == nil || !strings.Contains(err.Error(), `"nope" does not name a commit`) {
+		t.Errorf("Expected an error naming the bad revision, got %v", err)
+	}
+}
+
+func TestParseCommitLogBodyAndParents(t *testing.T) {
+	output := "aaa\x1fMerge branch 'feature'\x1fAnn\x1f2024-01-02T09:30:00+02:00\x1fppp qqq\x1f\x1e\n" +
+		"qqq\x1fFix login\x1fBob\x1f2024-01-01T23:15:00-05:00\x1fppp\x1fThe session expired too early.\n\nCloses #12\n\x1e\n"
+
+	commits := parseCommitLog(output)
+	if len(commits) != 2 {
+		t.Fatalf("Expected 2 commits, got %d", len(commits))
+	}
+
+	if !commits[0].IsMerge() || commits[1].IsMerge() {
+		t.Errorf("Expected only the first commit to be a merge, got parents %v and %v", commits[0].Parents, commits[1].Parents)
+	}
+	if commits[1].Body != "The session expired too early.\n\nCloses #12" {
+		t.Errorf("Unexpected body %q", commits[1].Body)
+	}
+}
+
+func TestStageTrackedChanges(t *testing.T) {
+	runner := &fakeRunner{outputs: map[string]string{
+		"git --no-pager diff --name-only": "main.go\nREADME.md\n",
+		"git add -u":                      "",
+	}}
+	repo := NewLocalRepoWithRunner(".", runner)
+
+	staged, err := repo.StageTrackedChanges(context.Background())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if strings.Join(staged, ",") != "main.go,README.md" {
+		t.Errorf("Expected main.go and README.md, got %v", staged)
+	}
+	if runner.calls[len(runner.calls)-1] != "git add -u" {
+		t.Errorf("Expected git add -u to run last, got %v", runner.calls)
+	}
+}
+
+func TestStageTrackedChangesNothingToStage(t *testing.T) {
+	runner := &fakeRunner{outputs: map[string]string{
+		"git --no-pager diff --name-only": "",
+	}}
+	repo := NewLocalRepoWithRunner(".", runner)
+
+	staged, err := repo.StageTrackedChanges(context.Background())
+	if err != nil || len(staged) != 0 {
+		t.Errorf("Expected nothing staged, got %v (err %v)", staged, err)
+	}
+	if len(runner.calls) != 1 {
+		t.Errorf("Expected git add not to run, got %v", runner.calls)
+	}
+}
+
+func TestRestoreIndex(t *testing.T) {
+	runner := &fakeRunner{outputs: map[string]string{
+		"git read-tree 4b825dc": "",
+	}}
+	repo := NewLocalRepoWithRunner(".", runner)
+
+	if err := repo.RestoreIndex(context.Background(), "4b825dc"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(runner.calls) != 1 || runner.calls[0] != "git read-tree 4b825dc" {
+		t.Errorf("Expected git read-tree, got %v", runner.calls)
+	}
+}
+
+func TestGetRepoName(t *testing.T) {
+	runner := &fakeRunner{outputs: map[string]string{
+		"git remote get-url origin": "git@github.com:owner/project.git\n",
+	}}
+	repo := NewLocalRepoWithRunner(".", runner)
+
+	name, err := repo.GetRepoName(context.Background())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if name != "project" {
+		t.Errorf("Expected 'project', got %q", name)
+	}
+}
+
+func TestGetRecentCommits(t *testing.T) {
+	runner := &fakeRunner{outputs: map[string]string{
+		"git log -2 --pretty=format:" + commitLogFormat + " --date=iso-strict": "aaa\x1ffeat: one\x1fAnn\x1f2024-01-02T09:30:00+02:00\x1fppp\x1f\x1e\nbbb\x1ffix: two\x1fBob\x1f2024-01-01T23:15:00-05:00\x1fqqq\x1f\x1e",
+		"git --no-pager show -M --numstat --format= aaa":                       "2\t1\tmain.go\n",
+		"git --no-pager show -M --numstat --format= bbb":                       "1\t0\tREADME.md\n",
+	}}
+	repo := NewLocalRepoWithRunner(".", runner)
+
+	commits, err := repo.GetRecentCommits(context.Background(), 2)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(commits) != 2 {
+		t.Fatalf("Expected 2 commits, got %d", len(commits))
+	}
+	if commits[0].Message != "feat: one" || commits[0].Author != "Ann" {
+		t.Errorf("Unexpected first commit: %+v", commits[0])
+	}
+	if commits[0].Additions != 2 || commits[0].Deletions != 1 {
+		t.Errorf("Expected 2 additions and 1 deletion, got %d and %d", commits[0].Additions, commits[0].Deletions)
+	}
+	if len(commits[1].Files) != 1 || commits[1].Files[0] != "README.md" {
+		t.Errorf("Expected README.md in second commit, got %v", commits[1].Files)
+	}
+}
+
+func TestGetCommitsSince(t *testing.T) {
+	runner := &fakeRunner{outputs: map[string]string{
+		"git log --since=1 week ago --pretty=format:" + commitLogFormat + " --date=iso-strict": "aaa\x1ffeat: one\x1fAnn\x1f2024-01-02T09:30:00+02:00\x1fppp\x1f\x1e",
+		"git --no-pager show -M --numstat --format= aaa":                                       "4\t0\tmain.go\n",
+	}}
+	repo := NewLocalRepoWithRunner(".", runner)
+
+	commits, err := repo.GetCommitsSince(context.Background(), "1 week ago")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(commits) != 1 || commits[0].Message != "feat: one" {
+		t.Fatalf("Expected the one commit since last week, got %+v", commits)
+	}
+	if len(commits[0].Files) != 1 || commits[0].Additions != 4 {
+		t.Errorf("Expected stats for the commit, got %+v", commits[0])
+	}
+}
+
+func TestParseNumstat(t *testing.T) {
+	stats := "3\t1\tcmd/root.go\n" +
+		"0\t0\tdocs/old.md => docs/new.md\n" +
+		"5\t2\tpkg/{util => helpers}/strings.go\n" +
+		"1\t0\tpkg/{ => internal}/version.go\n" +
+		"-\t-\tassets/logo.png\n"
+
+	files, renames, additions, deletions := parseNumstat(stats)
+
+	wantFiles := []string{"cmd/root.go", "docs/new.md", "pkg/helpers/strings.go", "pkg/internal/version.go", "assets/logo.png"}
+	if strings.Join(files, ",") != strings.Join(wantFiles, ",") {
+		t.Errorf("Expected files %v, got %v", wantFiles, files)
+	}
+
+	wantRenames := map[string]string{
+		"docs/new.md":             "docs/old.md",
+		"pkg/helpers/strings.go":  "pkg/util/strings.go",
+		"pkg/internal/version.go": "pkg/version.go",
+	}
+	if len(renames) != len(wantRenames) {
+		t.Errorf("Expected %d renames, got %v", len(wantRenames), renames)
+	}
+	for newPath, oldPath := range wantRenames {
+		if renames[newPath] != oldPath {
+			t.Errorf("Expected %s to be renamed from %s, got %q", newPath, oldPath, renames[newPath])
+		}
+	}
+
+	if additions != 9 || deletions != 3 {
+		t.Errorf("Expected 9 additions and 3 deletions, got %d and %d", additions, deletions)
+	}
+}
+
+func TestGetRecentCommitsWithPipes(t *testing.T) {
+	runner := &fakeRunner{outputs: map[string]string{
+		"git log -2 --pretty=format:" + commitLogFormat + " --date=iso-strict": "aaa\x1fPipe git log | grep into less\x1fAnn\x1f2024-01-02T09:30:00+02:00\x1fppp\x1f| a | b |\n|---|---|\n\x1e\n" +
+			"bbb\x1fAdd | to the table parser\x1fBob\x1f2024-01-01T23:15:00-05:00\x1fqqq\x1f\x1e",
+		"git --no-pager show -M --numstat --format= aaa": "",
+		"git --no-pager show -M --numstat --format= bbb": "",
+	}}
+	repo := NewLocalRepoWithRunner(".", runner)
+
+	commits, err := repo.GetRecentCommits(context.Background(), 2)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(commits) != 2 {
+		t.Fatalf("Expected both commits to survive the | in their messages, got %d", len(commits))
+	}
+	if commits[0].Message != "Pipe git log | grep into less" || commits[0].Author != "Ann" {
+		t.Errorf("Unexpected first commit: %+v", commits[0])
+	}
+	if commits[0].Body != "| a | b |\n|---|---|" {
+		t.Errorf("Unexpected body %q", commits[0].Body)
+	}
+	if commits[1].Message != "Add | to the table parser" || commits[1].Date != "2024-01-01" {
+		t.Errorf("Unexpected second commit: %+v", commits[1])
+	}
+}
+
+func TestGetStagedDiffContext(t *testing.T) {
+	runner := &fakeRunner{outputs: map[string]string{
+		"git --no-pager diff --cached -U0": "@@ -1 +1 @@\n-a\n+b\n",
+	}}
+	repo := NewLocalRepoWithRunner(".", runner)
+
+	diff, err := repo.GetStagedDiffContext(context.Background(), 0)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if diff != "@@ -1 +1 @@\n-a\n+b\n" {
+		t.Errorf("Unexpected diff %q", diff)
+	}
+}
+
+func TestGetStagedDiffWithOpts(t *testing.T) {
+	runner := &fakeRunner{outputs: map[string]string{
+		"git --no-pager diff --cached --ignore-all-space": "@@ -1 +1 @@\n-a\n+b\n",
+	}}
+	repo := NewLocalRepoWithRunner(".", runner)
+
+	diff, err := repo.GetStagedDiffWithOpts(context.Background(), DiffOptions{IgnoreWhitespace: true})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if diff != "@@ -1 +1 @@\n-a\n+b\n" {
+		t.Errorf("Unexpected diff %q", diff)
+	}
+}
+
+func TestGetStagedDiffStat(t *testing.T) {
+	runner := &fakeRunner{outputs: map[string]string{
+		"git --no-pager diff --cached --stat": " main.go | 3 ++-\n 1 file changed, 2 insertions(+), 1 deletion(-)\n",
+	}}
+	repo := NewLocalRepoWithRunner(".", runner)
+
+	stat, err := repo.GetStagedDiffStat(context.Background())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if stat != " main.go | 3 ++-\n 1 file changed, 2 insertions(+), 1 deletion(-)" {
+		t.Errorf("Unexpected stat %q", stat)
+	}
+}
+
+func TestGetStagedDiffSince(t *testing.T) {
+	runner := &fakeRunner{outputs: map[string]string{
+		"git write-tree":                    "4b825dc642cb6eb9a060e54bf8d69288fbee4904\n",
+		"git --no-pager diff --cached 4b82": "diff --git a/x b/x\n",
+	}}
+	repo := NewLocalRepoWithRunner(".", runner)
+
+	tree, err := repo.GetIndexTree(context.Background())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if tree != "4b825dc642cb6eb9a060e54bf8d69288fbee4904" {
+		t.Errorf("Expected trimmed tree hash, got %q", tree)
+	}
+
+	diff, err := repo.GetStagedDiffSince(context.Background(), "4b82")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if diff != "diff --git a/x b/x\n" {
+		t.Errorf("Unexpected diff %q", diff)
+	}
+}
+
+func TestGetCommitsInRange(t *testing.T) {
+	runner := &fakeRunner{outputs: map[string]string{
+		"git log v1.0.0..HEAD --pretty=format:" + commitLogFormat + " --date=iso-strict": "aaa\x1ffeat: one\x1fAnn\x1f2024-01-02T09:30:00+02:00\x1fppp\x1f\x1e\nbbb\x1ffix: two\x1fBob\x1f2024-01-01T23:15:00-05:00\x1fqqq\x1f\x1e",
+	}}
+	repo := NewLocalRepoWithRunner(".", runner)
+
+	commits, err := repo.GetCommitsInRange(context.Background(), "v1.0.0", "")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(commits) != 2 || commits[1].Message != "fix: two" {
+		t.Errorf("Unexpected commits: %+v", commits)
+	}
+}
+
+func TestGetBranchDiff(t *testing.T) {
+	runner := &fakeRunner{outputs: map[string]string{
+		"git --no-pager diff main...HEAD": "diff --git a/x b/x\n",
+	}}
+	repo := NewLocalRepoWithRunner(".", runner)
+
+	diff, err := repo.GetBranchDiff(context.Background(), "main", "")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if diff != "diff --git a/x b/x\n" {
+		t.Errorf("Unexpected diff %q", diff)
+	}
+}
+
+func TestIsInsideWorkTreeOutsideRepo(t *testing.T) {
+	repo := NewLocalRepoWithRunner(".", &fakeRunner{})
+
+	isGit, err := repo.IsInsideWorkTree(context.Background())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if isGit {
+		t.Error("Expected false when git fails")
+	}
+}
+
+func TestLookGitMissing(t *testing.T) {
+	t.Setenv("PATH", t.TempDir())
+
+	if err := lookGit(); err != ErrGitNotFound {
+		t.Errorf("Expected ErrGitNotFound, got %v", err)
+	}
+}
+
+func TestStateFromGitDir(t *testing.T) {
+	tests := []struct {
+		marker string
+		isDir  bool
+		want   State
+	}{
+		{"", false, StateClean},
+		{"MERGE_HEAD", false, StateMerging},
+		{"rebase-merge", true, StateRebasing},
+		{"rebase-apply", true, StateRebasing},
+		{"CHERRY_PICK_HEAD", false, StateCherryPicking},
+		{"REVERT_HEAD", false, StateReverting},
+	}
+
+	for _, tt := range tests {
+		gitDir := t.TempDir()
+
+		if tt.marker != "" {
+			path := filepath.Join(gitDir, tt.marker)
+			var err error
+			if tt.isDir {
+				err = os.Mkdir(path, 0755)
+			} else {
+				err = os.WriteFile(path, []byte("0123abcd\n"), 0644)
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if got := stateFromGitDir(gitDir); got != tt.want {
+			t.Errorf("stateFromGitDir with %q = %q, want %q", tt.marker, got, tt.want)
+		}
+	}
+}
+
+func TestGetStagedFiles(t *testing.T) {
+	runner := &fakeRunner{outputs: map[string]string{
+		"git --no-pager diff --cached --name-only": "main.go\ncmd/root.go\n",
+	}}
+	repo := NewLocalRepoWithRunner(".", runner)
+
+	files, err := repo.GetStagedFiles(context.Background())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(files) != 2 || files[0] != "main.go" || files[1] != "cmd/root.go" {
+		t.Errorf("Expected [main.go cmd/root.go], got %v", files)
+	}
+}
+
+func TestListFiles(t *testing.T) {
+	runner := &fakeRunner{outputs: map[string]string{
+		"git ls-files -z --cached --others --exclude-standard": "main.go\x00cmd/my file.go\x00",
+	}}
+	repo := NewLocalRepoWithRunner(".", runner)
+
+	files, err := repo.ListFiles(context.Background())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(files) != 2 || files[0] != "main.go" || files[1] != "cmd/my file.go" {
+		t.Errorf("Expected [main.go cmd/my file.go], got %v", files)
+	}
+}
+
+func TestGetUntrackedFiles(t *testing.T) {
+	runner := &fakeRunner{outputs: map[string]string{
+		"git ls-files -z --others --exclude-standard": "notes.md\x00pkg/new/feature.go\x00",
+	}}
+	repo := NewLocalRepoWithRunner(".", runner)
+
+	files, err := repo.GetUntrackedFiles(context.Background())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(files) != 2 || files[0] != "notes.md" || files[1] != "pkg/new/feature.go" {
+		t.Errorf("Expected [notes.md pkg/new/feature.go], got %v", files)
+	}
+}
+
+func TestGetUntrackedFilesNone(t *testing.T) {
+	runner := &fakeRunner{outputs: map[string]string{
+		"git ls-files -z --others --exclude-standard": "",
+	}}
+	repo := NewLocalRepoWithRunner(".", runner)
+
+	files, err := repo.GetUntrackedFiles(context.Background())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(files) != 0 {
+		t.Errorf("Expected no untracked files, got %v", files)
+	}
+}
+
+func TestGetDefaultBranch(t *testing.T) {
+	runner := &fakeRunner{outputs: map[string]string{
+		"git symbolic-ref --short refs/remotes/origin/HEAD": "origin/develop\n",
+	}}
+	repo := NewLocalRepoWithRunner(".", runner)
+
+	branch, err := repo.GetDefaultBranch(context.Background())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if branch != "develop" {
+		t.Errorf("Expected 'develop', got %q", branch)
+	}
+}
+
+func TestGetDefaultBranchFallback(t *testing.T) {
+	// No origin/HEAD and no main branch
+	runner := &fakeRunner{outputs: map[string]string{
+		"git rev-parse --verify --quiet refs/heads/master": "abc123\n",
+	}}
+	repo := NewLocalRepoWithRunner(".", runner)
+
+	branch, err := repo.GetDefaultBranch(context.Background())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if branch != "master" {
+		t.Errorf("Expected 'master', got %q", branch)
+	}
+}
+
+func TestGetCommit(t *testing.T) {
+	runner := &fakeRunner{outputs: map[string]string{
+		"git log -1 --pretty=format:%H%x00%an%x00%ad%x00%B --date=iso-strict HEAD": "abc123\x00Ann\x002024-01-02T09:30:00+02:00\x00Fix race in auth\n\nGuard refresh with a mutex.\n",
+	}}
+	repo := NewLocalRepoWithRunner(".", runner)
+
+	commit, err := repo.GetCommit(context.Background(), "HEAD")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if commit.Hash != "abc123" || commit.Author != "Ann" || commit.Date != "2024-01-02" {
+		t.Errorf("Unexpected commit metadata: %+v", commit)
+	}
+	if want := time.Date(2024, 1, 2, 7, 30, 0, 0, time.UTC); !commit.Time.Equal(want) {
+		t.Errorf("Expected the commit time %s, got %s", want, commit.Time)
+	}
+	if commit.Message != "Fix race in auth" || commit.Body != "Guard refresh with a mutex." {
+		t.Errorf("Unexpected message: %q / %q", commit.Message, commit.Body)
+	}
+	if commit.FullMessage() != "Fix race in auth\n\nGuard refresh with a mutex." {
+		t.Errorf("Unexpected full message: %q", commit.FullMessage())
+	}
+}
+
+func TestGetStagedFileStats(t *testing.T) {
+	runner := &fakeRunner{outputs: map[string]string{
+		"git --no-pager diff --cached --numstat --no-renames -z": "12\t3\tcmd/root.go\x00-\t-\tlogo.png\x000\t4\tnotes with space.md\x00",
+	}}
+	repo := NewLocalRepoWithRunner(".", runner)
+
+	stats, err := repo.GetStagedFileStats(context.Background())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	want := []FileStat{
+		{Path: "cmd/root.go", Additions: 12, Deletions: 3},
+		{Path: "logo.png", Binary: true},
+		{Path: "notes with space.md", Deletions: 4},
+	}
+	if len(stats) != len(want) {
+		t.Fatalf("Expected %d files, got %+v", len(want), stats)
+	}
+	for i := range want {
+		if stats[i] != want[i] {
+			t.Errorf("Expected %+v, got %+v", want[i], stats[i])
+		}
+	}
+}
+
+func TestUnstageFile(t *testing.T) {
+	runner := &fakeRunner{outputs: map[string]string{
+		"git rev-parse --verify --quiet HEAD":      "abc123\n",
+		"git restore --staged -- docs/old name.md": "",
+	}}
+	repo := NewLocalRepoWithRunner(".", runner)
+
+	if err := repo.UnstageFile(context.Background(), "docs/old name.md"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// Before the first commit there is no HEAD to restore from
+	runner = &fakeRunner{
+		outputs: map[string]string{
+			"git rev-parse --verify --quiet HEAD": "",
+			"git rm --cached --quiet -- main.go":  "",
+		},
+		errs: map[string]error{"git rev-parse --verify --quiet HEAD": exitStatus(1)},
+	}
+	repo = NewLocalRepoWithRunner(".", runner)
+
+	if err := repo.UnstageFile(context.Background(), "main.go"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if runner.calls[len(runner.calls)-1] != "git rm --cached --quiet -- main.go" {
+		t.Errorf("Expected git rm --cached to run, got %v", runner.calls)
+	}
+
+	// A failure to read HEAD must not turn into staging a deletion
+	runner = &fakeRunner{
+		outputs: map[string]string{"git rev-parse --verify --quiet HEAD": ""},
+		errs:    map[string]error{"git rev-parse --verify --quiet HEAD": exitStatus(128)},
+	}
+	repo = NewLocalRepoWithRunner(".", runner)
+
+	if err := repo.UnstageFile(context.Background(), "main.go"); err == nil {
+		t.Error("Expected an error when HEAD can't be read")
+	}
+	if len(runner.calls) != 1 {
+		t.Errorf("Expected nothing to run after the failed rev-parse, got %v", runner.calls)
+	}
+}
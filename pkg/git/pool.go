@@ -0,0 +1,52 @@
+package git
+
+import (
+	"context"
+	"runtime"
+)
+
+// Pool bounds the number of git processes running at the same time
+type Pool struct {
+	slots chan struct{}
+}
+
+// NewPool creates a pool that allows up to size concurrent operations.
+// A size below 1 defaults to the number of CPUs.
+func NewPool(size int) *Pool {
+	if size < 1 {
+		size = runtime.NumCPU()
+	}
+	return &Pool{slots: make(chan struct{}, size)}
+}
+
+// Size returns the maximum number of concurrent operations
+func (p *Pool) Size() int {
+	return cap(p.slots)
+}
+
+// Run waits for a free slot, then calls fn. It returns ctx.Err() without
+// calling fn if the context is cancelled while waiting.
+func (p *Pool) Run(ctx context.Context, fn func() error) error {
+	select {
+	case p.slots <- struct{}{}:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	defer func() { <-p.slots }()
+
+	return fn()
+}
+
+// sharedPool is used by every LocalRepo for parallel git calls
+var sharedPool = NewPool(runtime.NumCPU())
+
+// SetConcurrency changes the limit of the shared pool. It must be called
+// before any git operations are started.
+func SetConcurrency(size int) {
+	sharedPool = NewPool(size)
+}
+
+// Concurrency returns the limit of the shared pool
+func Concurrency() int {
+	return sharedPool.Size()
+}
@@ -0,0 +1,130 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestPoolBoundsConcurrency(t *testing.T) {
+	const limit = 3
+	pool := NewPool(limit)
+
+	var running, peak int32
+	runner := func() error {
+		n := atomic.AddInt32(&running, 1)
+		for {
+			p := atomic.LoadInt32(&peak)
+			if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+		atomic.AddInt32(&running, -1)
+		return nil
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := pool.Run(context.Background(), runner); err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if peak > limit {
+		t.Errorf("Expected at most %d concurrent runs, got %d", limit, peak)
+	}
+	if peak == 0 {
+		t.Error("Expected runner to be called")
+	}
+}
+
+// countingRunner records the peak number of concurrent git show calls
+type countingRunner struct {
+	running, peak int32
+}
+
+func (c *countingRunner) Run(ctx context.Context, name string, args ...string) ([]byte, error) {
+	if args[0] == "log" {
+		var lines []string
+		for i := 0; i < 20; i++ {
+			lines = append(lines, fmt.Sprintf("%040d\x1fmsg\x1fauthor\x1f2024-01-01\x1f\x1f\x1e", i))
+		}
+		return []byte(strings.Join(lines, "\n")), nil
+	}
+
+	n := atomic.AddInt32(&c.running, 1)
+	for {
+		p := atomic.LoadInt32(&c.peak)
+		if n <= p || atomic.CompareAndSwapInt32(&c.peak, p, n) {
+			break
+		}
+	}
+	time.Sleep(5 * time.Millisecond)
+	atomic.AddInt32(&c.running, -1)
+	return nil, nil
+}
+
+func TestGetRecentCommitsRespectsConcurrency(t *testing.T) {
+	defer SetConcurrency(Concurrency())
+	SetConcurrency(2)
+
+	runner := &countingRunner{}
+	repo := NewLocalRepoWithRunner(".", runner)
+
+	commits, err := repo.GetRecentCommits(context.Background(), 20)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(commits) != 20 {
+		t.Fatalf("Expected 20 commits, got %d", len(commits))
+	}
+	if runner.peak > 2 {
+		t.Errorf("Expected at most 2 concurrent git calls, got %d", runner.peak)
+	}
+}
+
+func TestPoolRunCancelled(t *testing.T) {
+	pool := NewPool(1)
+	release := make(chan struct{})
+	started := make(chan struct{})
+
+	go pool.Run(context.Background(), func() error {
+		close(started)
+		<-release
+		return nil
+	})
+	<-started
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	called := false
+	err := pool.Run(ctx, func() error {
+		called = true
+		return nil
+	})
+	close(release)
+
+	if err != context.Canceled {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+	if called {
+		t.Error("Expected runner not to be called after cancellation")
+	}
+}
+
+func TestNewPoolDefaultSize(t *testing.T) {
+	if NewPool(0).Size() < 1 {
+		t.Error("Expected default pool size of at least 1")
+	}
+}
@@ -0,0 +1,126 @@
+package git
+
+import (
+	"math"
+	"regexp"
+	"strings"
+)
+
+// RedactedText replaces secrets found by RedactDiff and RedactText
+const RedactedText = "[REDACTED]"
+
+// DefaultSecretPatterns are the regular expressions RedactDiff always
+// applies. When a pattern has a capture group only the group is masked, so
+// "password = hunter2" keeps its key and reads "password = [REDACTED]".
+var DefaultSecretPatterns = []string{
+	// AWS access key IDs
+	`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`,
+	// GitHub and Slack tokens
+	`\bgh[pousr]_[A-Za-z0-9]{36,}\b`,
+	`\bxox[abprs]-[A-Za-z0-9-]{10,}`,
+	// Private key headers
+	`-----BEGIN [A-Z ]*PRIVATE KEY-----`,
+	// Bearer tokens, e.g. in an Authorization header
+	`(?i)\bbearer\s+([A-Za-z0-9._~+/-]{16,}=*)`,
+	// Values assigned to secret-sounding keys, as in .env files and configs
+	`(?i)(?:password|passwd|pwd|secret|token|api[_-]?key|access[_-]?key|private[_-]?key|client[_-]?secret)["']?\s*[:=]\s*["']?([^\s"',;]+)`,
+}
+
+var defaultSecretRegexps = compileSecretPatterns(DefaultSecretPatterns)
+
+// entropyCandidate matches words long enough to be a generated secret
+var entropyCandidate = regexp.MustCompile(`[A-Za-z0-9+/=_-]{32,}`)
+
+// minSecretEntropy is the Shannon entropy in bits per character above which
+// a long word is taken for a secret. Hex strings such as commit hashes top
+// out at 4 and are left alone.
+const minSecretEntropy = 4.5
+
+// RedactDiff masks secrets in the changed and context lines of diff: matches
+// of DefaultSecretPatterns and of patterns, and long high-entropy words such
+// as generated keys. File headers are left as they are. Patterns that aren't
+// valid regular expressions are ignored; validate them with regexp.Compile
+// first.
+func RedactDiff(diff string, patterns []string) string {
+	regexps := append(append([]*regexp.Regexp(nil), defaultSecretRegexps...), compileSecretPatterns(patterns)...)
+
+	lines := strings.Split(diff, "\n")
+	inHunk := false
+	for i, line := range lines {
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			inHunk = false
+		case strings.HasPrefix(line, "@@"):
+			inHunk = true
+		case inHunk:
+			lines[i] = redactLine(line, regexps)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// RedactText masks secrets in every line of text the way RedactDiff does in
+// diff hunks, for text that isn't a diff such as a file's contents
+func RedactText(text string, patterns []string) string {
+	regexps := append(append([]*regexp.Regexp(nil), defaultSecretRegexps...), compileSecretPatterns(patterns)...)
+
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		lines[i] = redactLine(line, regexps)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// redactLine masks the secrets in one line
+func redactLine(line string, regexps []*regexp.Regexp) string {
+	for _, re := range regexps {
+		var b strings.Builder
+		last := 0
+		for _, m := range re.FindAllStringSubmatchIndex(line, -1) {
+			start, end := m[0], m[1]
+			if len(m) > 3 && m[2] >= 0 {
+				start, end = m[2], m[3]
+			}
+			b.WriteString(line[last:start])
+			b.WriteString(RedactedText)
+			last = end
+		}
+		b.WriteString(line[last:])
+		line = b.String()
+	}
+
+	return entropyCandidate.ReplaceAllStringFunc(line, func(word string) string {
+		if shannonEntropy(word) > minSecretEntropy {
+			return RedactedText
+		}
+		return word
+	})
+}
+
+// shannonEntropy returns the entropy of s in bits per character
+func shannonEntropy(s string) float64 {
+	counts := make(map[rune]int)
+	n := 0
+	for _, r := range s {
+		counts[r]++
+		n++
+	}
+
+	entropy := 0.0
+	for _, c := range counts {
+		p := float64(c) / float64(n)
+		entropy -= p * math.Log2(p)
+	}
+	return entropy
+}
+
+// compileSecretPatterns compiles patterns, skipping invalid ones
+func compileSecretPatterns(patterns []string) []*regexp.Regexp {
+	var regexps []*regexp.Regexp
+	for _, pattern := range patterns {
+		if re, err := regexp.Compile(pattern); err == nil {
+			regexps = append(regexps, re)
+		}
+	}
+	return regexps
+}
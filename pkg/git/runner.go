@@ -0,0 +1,65 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"sync"
+)
+
+// ErrGitNotFound is returned by CheckGitAvailable when there is no git
+// executable on PATH
+var ErrGitNotFound = errors.New("git was not found on PATH; install git (https://git-scm.com/downloads) and try again")
+
+var (
+	gitCheckOnce sync.Once
+	gitCheckErr  error
+)
+
+// CheckGitAvailable reports whether git can be run. The lookup is done once
+// per process.
+func CheckGitAvailable() error {
+	gitCheckOnce.Do(func() {
+		gitCheckErr = lookGit()
+	})
+	return gitCheckErr
+}
+
+// lookGit looks for git on PATH
+func lookGit() error {
+	if _, err := exec.LookPath("git"); err != nil {
+		return ErrGitNotFound
+	}
+	return nil
+}
+
+// Runner runs an external command and returns its standard output
+type Runner interface {
+	Run(ctx context.Context, name string, args ...string) ([]byte, error)
+}
+
+// ExecRunner runs commands as child processes in Dir
+type ExecRunner struct {
+	Dir string
+}
+
+// Run executes name with args and returns its standard output
+func (e ExecRunner) Run(ctx context.Context, name string, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Dir = e.Dir
+	return cmd.Output()
+}
+
+// CombinedRunner is implemented by runners that can capture standard output
+// and standard error together, in the order they were written
+type CombinedRunner interface {
+	RunCombined(ctx context.Context, name string, args ...string) ([]byte, error)
+}
+
+// RunCombined executes name with args and returns its interleaved standard
+// output and standard error
+func (e ExecRunner) RunCombined(ctx context.Context, name string, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Dir = e.Dir
+	return cmd.CombinedOutput()
+}
@@ -0,0 +1,106 @@
+package history
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Entry is one generated bash command in the history file
+type Entry struct {
+	Description string    `json:"description"`
+	Command     string    `json:"command"`
+	Timestamp   time.Time `json:"timestamp"`
+	Executed    bool      `json:"executed"`
+}
+
+// DefaultPath returns ~/.config/gh-smart-commit/bash_history.jsonl
+func DefaultPath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to find home directory: %w", err)
+	}
+	return filepath.Join(home, ".config", "gh-smart-commit", "bash_history.jsonl"), nil
+}
+
+// Append writes entry as one JSON line to the file at path, creating the
+// file and its directory if needed
+func Append(path string, entry Entry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to marshal history entry: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create history directory: %w", err)
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open history file: %w", err)
+	}
+	defer file.Close()
+
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write history entry: %w", err)
+	}
+
+	return nil
+}
+
+// Recent returns up to n entries from the file at path, most recent first,
+// so index 1 is always the last command. A missing file is an empty history
+// and lines that don't parse are skipped. n <= 0 returns every entry.
+func Recent(path string, n int) ([]Entry, error) {
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open history file: %w", err)
+	}
+	defer file.Close()
+
+	var entries []Entry
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var entry Entry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil || entry.Command == "" {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read history file: %w", err)
+	}
+
+	// Newest first
+	for i, j := 0, len(entries)-1; i < j; i, j = i+1, j-1 {
+		entries[i], entries[j] = entries[j], entries[i]
+	}
+	if n > 0 && len(entries) > n {
+		entries = entries[:n]
+	}
+	return entries, nil
+}
+
+// Get returns the entry at index, counting from 1 for the most recent command
+func Get(path string, index int) (Entry, error) {
+	if index < 1 {
+		return Entry{}, fmt.Errorf("history index must be 1 or more, got %d", index)
+	}
+
+	entries, err := Recent(path, index)
+	if err != nil {
+		return Entry{}, err
+	}
+	if index > len(entries) {
+		return Entry{}, fmt.Errorf("no history entry %d (history has %d)", index, len(entries))
+	}
+	return entries[index-1], nil
+}
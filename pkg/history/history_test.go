@@ -0,0 +1,89 @@
+package history
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAppendAndRecent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "bash_history.jsonl")
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	commands := []string{"ls -la", "du -sh *", "find . -name '*.go'"}
+	for i, command := range commands {
+		entry := Entry{Description: "task", Command: command, Timestamp: start.Add(time.Duration(i) * time.Minute), Executed: i != 1}
+		if err := Append(path, entry); err != nil {
+			t.Fatalf("Append failed: %v", err)
+		}
+	}
+
+	entries, err := Recent(path, 2)
+	if err != nil {
+		t.Fatalf("Recent failed: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("Expected 2 entries, got %d", len(entries))
+	}
+	if entries[0].Command != "find . -name '*.go'" || entries[1].Command != "du -sh *" {
+		t.Errorf("Expected newest first, got %q then %q", entries[0].Command, entries[1].Command)
+	}
+	if entries[1].Executed {
+		t.Error("Expected the second entry to be recorded as not executed")
+	}
+	if !entries[0].Timestamp.Equal(start.Add(2 * time.Minute)) {
+		t.Errorf("Expected timestamp to round-trip, got %v", entries[0].Timestamp)
+	}
+}
+
+func TestRecentMissingFile(t *testing.T) {
+	entries, err := Recent(filepath.Join(t.TempDir(), "missing.jsonl"), 10)
+	if err != nil {
+		t.Fatalf("Expected no error for a missing file, got %v", err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("Expected empty history, got %d entries", len(entries))
+	}
+}
+
+func TestRecentSkipsBadLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bash_history.jsonl")
+	content := `{"description":"a","command":"ls"}` + "\nnot json\n" + `{"description":"b","command":"pwd"}` + "\n"
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := Recent(path, 0)
+	if err != nil {
+		t.Fatalf("Recent failed: %v", err)
+	}
+	if len(entries) != 2 || entries[0].Command != "pwd" {
+		t.Errorf("Expected [pwd ls], got %+v", entries)
+	}
+}
+
+func TestGet(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bash_history.jsonl")
+	for _, command := range []string{"ls", "pwd"} {
+		if err := Append(path, Entry{Command: command}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	entry, err := Get(path, 2)
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if entry.Command != "ls" {
+		t.Errorf("Expected 'ls', got %q", entry.Command)
+	}
+
+	if _, err := Get(path, 3); err == nil || !strings.Contains(err.Error(), "no history entry 3") {
+		t.Errorf("Expected out of range error, got %v", err)
+	}
+	if _, err := Get(path, 0); err == nil {
+		t.Error("Expected an error for index 0")
+	}
+}
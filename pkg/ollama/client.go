@@ -5,9 +5,12 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"regexp"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -17,14 +20,109 @@ type Client struct {
 	baseURL    string
 	httpClient *http.Client
 	timeout    time.Duration
+	apiKind    string
+	apiKey     string
+	embedModel string
+	keepAlive  KeepAlive
+	// stallTimeout gives up on a stream that goes this long without a
+	// chunk; 0 disables it
+	stallTimeout time.Duration
+}
+
+// Supported API kinds
+const (
+	APIKindOllama = "ollama" // Ollama's native /api/chat
+	APIKindOpenAI = "openai" // OpenAI-compatible /v1/chat/completions
+)
+
+// ErrNotOllama is returned when the server at the configured host doesn't
+// behave like Ollama, e.g. because the host or port is wrong
+var ErrNotOllama = errors.New("not an Ollama server")
+
+// versionPattern matches the versions Ollama reports, e.g. "0.3.12" or
+// "0.5.0-rc1"
+var versionPattern = regexp.MustCompile(`^v?\d+\.\d+`)
+
+// maxVersionResponse caps how much of a /api/version answer is read
+const maxVersionResponse = 64 << 10
+
+// ClientOption configures a Client
+type ClientOption func(*Client)
+
+// WithAPIKind selects the wire protocol used by the client. An empty kind
+// keeps the native Ollama API.
+func WithAPIKind(kind string) ClientOption {
+	return func(c *Client) {
+		if kind != "" {
+			c.apiKind = kind
+		}
+	}
+}
+
+// WithAPIKey sets a bearer token sent with OpenAI-compatible requests
+func WithAPIKey(key string) ClientOption {
+	return func(c *Client) {
+		c.apiKey = key
+	}
+}
+
+// WithKeepAlive sets how long Ollama keeps a model loaded after a request,
+// e.g. "30m" or "-1" for forever, unless the request sets its own. An empty
+// value leaves the server default.
+func WithKeepAlive(keepAlive string) ClientOption {
+	return func(c *Client) {
+		c.keepAlive = KeepAlive(keepAlive)
+	}
+}
+
+// WithStallTimeout sets how long a streaming response may go without a new
+// chunk before it fails with ErrStalled. The wait for the first chunk, which
+// includes loading the model, is only bound by the overall timeout. 0
+// disables stall detection.
+func WithStallTimeout(timeout time.Duration) ClientOption {
+	return func(c *Client) {
+		c.stallTimeout = timeout
+	}
 }
 
 // ChatRequest represents a chat request to Ollama
 type ChatRequest struct {
-	Model    string    `json:"model"`
-	Messages []Message `json:"messages"`
-	Stream   bool      `json:"stream"`
-	Options  Options   `json:"options,omitempty"`
+	Model     string    `json:"model"`
+	Messages  []Message `json:"messages"`
+	Stream    bool      `json:"stream"`
+	Format    string    `json:"format,omitempty"`
+	Options   Options   `json:"options,omitempty"`
+	Think     *bool     `json:"think,omitempty"`      // nil leaves the model's default reasoning behaviour
+	KeepAlive KeepAlive `json:"keep_alive,omitempty"` // Ollama reads this next to options, not inside them
+}
+
+// KeepAlive is how long Ollama keeps a model loaded, either a duration such
+// as "30m" or a number of seconds such as "-1". Ollama parses a string with
+// time.ParseDuration, so a bare number has to go out as a JSON number.
+type KeepAlive string
+
+// MarshalJSON encodes a number of seconds as a JSON number and anything else
+// as a string
+func (k KeepAlive) MarshalJSON() ([]byte, error) {
+	if _, err := strconv.ParseFloat(string(k), 64); err == nil && json.Valid([]byte(k)) {
+		return []byte(k), nil
+	}
+	return json.Marshal(string(k))
+}
+
+// UnmarshalJSON accepts both encodings MarshalJSON produces
+func (k *KeepAlive) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*k = KeepAlive(s)
+		return nil
+	}
+	var n json.Number
+	if err := json.Unmarshal(data, &n); err != nil {
+		return err
+	}
+	*k = KeepAlive(n.String())
+	return nil
 }
 
 // Message represents a chat message
@@ -35,7 +133,9 @@ type Message struct {
 
 // Options represents model options
 type Options struct {
-	Temperature float32 `json:"temperature,omitempty"`
+	Temperature float32 `json:"temperature"` // Sent even when zero, which is a meaningful setting
+	Seed        int     `json:"seed,omitempty"`
+	NumCtx      int     `json:"num_ctx,omitempty"` // Context window in tokens; zero leaves the model's default
 }
 
 // ChatResponse represents a streaming chat response
@@ -52,26 +152,44 @@ type ChatResponse struct {
 	EvalDuration       int64     `json:"eval_duration,omitempty"`
 }
 
+// Model describes a model available on the Ollama server
+type Model struct {
+	Name       string    `json:"name"`
+	Size       int64     `json:"size"`
+	ModifiedAt time.Time `json:"modified_at"`
+}
+
 // NewClient creates a new Ollama client
-func NewClient(baseURL string) *Client {
-	return &Client{
+func NewClient(baseURL string, opts ...ClientOption) *Client {
+	c := &Client{
 		baseURL: strings.TrimSuffix(baseURL, "/"),
 		httpClient: &http.Client{
 			Timeout: 30 * time.Second,
 			Transport: &http.Transport{
-				MaxIdleConns:        10,
-				IdleConnTimeout:     90 * time.Second,
-				DisableCompression:  false,
-				DisableKeepAlives:   false,
+				MaxIdleConns:       10,
+				IdleConnTimeout:    90 * time.Second,
+				DisableCompression: false,
+				DisableKeepAlives:  false,
 			},
 		},
-		timeout: 5 * time.Minute, // Longer timeout for LLM responses
+		timeout:      5 * time.Minute, // Longer timeout for LLM responses
+		apiKind:      APIKindOllama,
+		embedModel:   DefaultEmbedModel,
+		stallTimeout: DefaultStallTimeout,
 	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
 }
 
 // Chat sends a chat request and returns a channel for streaming responses
 func (c *Client) Chat(ctx context.Context, req ChatRequest) (<-chan ChatResponse, <-chan error) {
-	respChan := make(chan ChatResponse, 10)
+	// respChan is unbuffered so every chunk has been received by the caller
+	// before the channels are closed
+	respChan := make(chan ChatResponse)
 	errChan := make(chan error, 1)
 
 	go func() {
@@ -86,14 +204,81 @@ func (c *Client) Chat(ctx context.Context, req ChatRequest) (<-chan ChatResponse
 	return respChan, errChan
 }
 
+// ChatOnce sends a chat request and waits for the complete response. The
+// returned response carries the full message content and the timing fields
+// reported with the final chunk.
+func (c *Client) ChatOnce(ctx context.Context, req ChatRequest) (*ChatResponse, error) {
+	respChan, errChan := c.Chat(ctx, req)
+	return collectResponse(ctx, respChan, errChan)
+}
+
+// collectResponse reads the channels returned by Chat into one response
+// with the full content and the final chunk's timing fields
+func collectResponse(ctx context.Context, respChan <-chan ChatResponse, errChan <-chan error) (*ChatResponse, error) {
+	var final ChatResponse
+	var content strings.Builder
+
+	for {
+		select {
+		case resp, ok := <-respChan:
+			if !ok {
+				// As in CollectStream, the error may still be waiting
+				if err := pendingErr(errChan); err != nil {
+					return nil, err
+				}
+				final.Message.Role = "assistant"
+				final.Message.Content = content.String()
+				return &final, nil
+			}
+			content.WriteString(resp.Message.Content)
+			if resp.Done {
+				final = resp
+			}
+
+		case err, ok := <-errChan:
+			if !ok {
+				errChan = nil // closed; wait for respChan to close
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// ChatComplete sends a chat request and returns the full response text
+func (c *Client) ChatComplete(ctx context.Context, req ChatRequest) (string, error) {
+	resp, err := c.ChatOnce(ctx, req)
+	if err != nil {
+		return "", err
+	}
+	return resp.Message.Content, nil
+}
+
 // streamChat performs the actual streaming request
 func (c *Client) streamChat(ctx context.Context, req ChatRequest, respChan chan<- ChatResponse) error {
+	if c.apiKind == APIKindOpenAI {
+		return c.streamOpenAIChat(ctx, req, respChan)
+	}
+
 	// Create request context with timeout
-	reqCtx, cancel := context.WithTimeout(ctx, c.timeout)
+	timeoutCtx, cancel := context.WithTimeout(ctx, c.timeout)
 	defer cancel()
+	reqCtx, cancelCause := context.WithCancelCause(timeoutCtx)
+	defer cancelCause(nil)
+
+	stall := newStallTimer(c.stallTimeout, cancelCause)
+	defer stall.stop()
 
 	// Ensure streaming is enabled
 	req.Stream = true
+	if req.KeepAlive == "" {
+		req.KeepAlive = c.keepAlive
+	}
 
 	// Marshal request
 	reqBody, err := json.Marshal(req)
@@ -120,7 +305,7 @@ func (c *Client) streamChat(ctx context.Context, req ChatRequest, respChan chan<
 	// Check response status
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("ollama request failed with status %d: %s", resp.StatusCode, string(body))
+		return newAPIError("ollama request", resp.StatusCode, body)
 	}
 
 	// Stream responses
@@ -141,6 +326,7 @@ func (c *Client) streamChat(ctx context.Context, req ChatRequest, respChan chan<
 		case <-ctx.Done():
 			return ctx.Err()
 		}
+		stall.reset()
 
 		// Stop if done
 		if chatResp.Done {
@@ -148,7 +334,16 @@ func (c *Client) streamChat(ctx context.Context, req ChatRequest, respChan chan<
 		}
 	}
 
-	return scanner.Err()
+	return streamErr(reqCtx, scanner.Err())
+}
+
+// streamErr returns why reading a stream failed, reporting a stall rather
+// than the "context canceled" it surfaces as
+func streamErr(reqCtx context.Context, err error) error {
+	if err != nil && errors.Is(context.Cause(reqCtx), ErrStalled) {
+		return context.Cause(reqCtx)
+	}
+	return err
 }
 
 // executeWithRetry executes an HTTP request with exponential backoff retry
@@ -179,22 +374,132 @@ func (c *Client) executeWithRetry(req *http.Request, maxRetries int) (*http.Resp
 	return nil, lastErr
 }
 
-// Ping checks if the Ollama server is accessible
+// Preload loads model into memory by sending it a chat without messages, so
+// the next request doesn't wait for the model to load. OpenAI-compatible
+// servers have no equivalent, so Preload does nothing for them.
+func (c *Client) Preload(ctx context.Context, model string) error {
+	if c.apiKind == APIKindOpenAI {
+		return nil
+	}
+
+	reqBody, err := json.Marshal(ChatRequest{
+		Model:     model,
+		Messages:  []Message{},
+		KeepAlive: c.keepAlive,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal preload request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/api/chat", bytes.NewReader(reqBody))
+	if err != nil {
+		return fmt.Errorf("failed to create preload request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	c.setAuth(req)
+
+	// Loading a large model can take longer than the HTTP client timeout
+	httpClient := *c.httpClient
+	httpClient.Timeout = c.timeout
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to preload model: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return newAPIError("preload request", resp.StatusCode, body)
+	}
+
+	return nil
+}
+
+// Ping checks that the server is reachable and, for the Ollama API, that it
+// is an Ollama server rather than something else listening on the port
 func (c *Client) Ping(ctx context.Context) error {
+	if c.apiKind == APIKindOpenAI {
+		_, err := c.listOpenAIModels(ctx)
+		return err
+	}
+
+	_, err := c.ServerVersion(ctx)
+	return err
+}
+
+// ServerVersion returns the version Ollama reports at /api/version. A server
+// that doesn't answer with a version, such as another service on the port
+// or a proxy that accepts every request, fails with ErrNotOllama.
+// OpenAI-compatible servers have no version endpoint; "" is returned.
+func (c *Client) ServerVersion(ctx context.Context) (string, error) {
+	if c.apiKind == APIKindOpenAI {
+		return "", nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", c.baseURL+"/api/version", nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create version request: %w", err)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to ping ollama: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("%w: %s/api/version answered with status %d", ErrNotOllama, c.baseURL, resp.StatusCode)
+	}
+
+	var body struct {
+		Version string `json:"version"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxVersionResponse)).Decode(&body); err != nil || !versionPattern.MatchString(body.Version) {
+		return "", fmt.Errorf("%w: %s/api/version didn't report a version", ErrNotOllama, c.baseURL)
+	}
+
+	return body.Version, nil
+}
+
+// ListModels returns the models installed on the Ollama server
+func (c *Client) ListModels(ctx context.Context) ([]Model, error) {
+	if c.apiKind == APIKindOpenAI {
+		return c.listOpenAIModels(ctx)
+	}
+
 	req, err := http.NewRequestWithContext(ctx, "GET", c.baseURL+"/api/tags", nil)
 	if err != nil {
-		return fmt.Errorf("failed to create ping request: %w", err)
+		return nil, fmt.Errorf("failed to create list request: %w", err)
 	}
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return fmt.Errorf("failed to ping ollama: %w", err)
+		return nil, fmt.Errorf("failed to list models: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("ollama ping failed with status %d", resp.StatusCode)
+		return nil, fmt.Errorf("listing models failed with status %d", resp.StatusCode)
 	}
 
-	return nil
-} 
\ No newline at end of file
+	var tags struct {
+		Models []Model `json:"models"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&tags); err != nil {
+		return nil, fmt.Errorf("failed to decode model list: %w", err)
+	}
+
+	return tags.Models, nil
+}
+
+// HasModel reports whether name is in models. A name without a tag matches
+// the ":latest" tag, mirroring how Ollama resolves model names.
+func HasModel(models []Model, name string) bool {
+	for _, m := range models {
+		if m.Name == name || (!strings.Contains(name, ":") && m.Name == name+":latest") {
+			return true
+		}
+	}
+	return false
+}
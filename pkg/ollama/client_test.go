@@ -3,6 +3,8 @@ package ollama
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"strings"
@@ -31,11 +33,11 @@ func TestNewClientTrimsSlash(t *testing.T) {
 func TestPing(t *testing.T) {
 	// Create mock server
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if r.URL.Path != "/api/tags" {
-			t.Errorf("Expected path '/api/tags', got '%s'", r.URL.Path)
+		if r.URL.Path != "/api/version" {
+			t.Errorf("Expected path '/api/version', got '%s'", r.URL.Path)
 		}
 		w.WriteHeader(http.StatusOK)
-		w.Write([]byte(`{"models":[]}`))
+		w.Write([]byte(`{"version":"0.3.12"}`))
 	}))
 	defer server.Close()
 
@@ -64,6 +66,41 @@ func TestPingFailure(t *testing.T) {
 	}
 }
 
+func TestServerVersion(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		want    string
+		wantErr bool
+	}{
+		{"ollama", http.StatusOK, `{"version":"0.5.0-rc1"}`, "0.5.0-rc1", false},
+		{"proxy answering everything", http.StatusOK, `<html>ok</html>`, "", true},
+		{"other JSON service", http.StatusOK, `{"status":"up"}`, "", true},
+		{"no version endpoint", http.StatusNotFound, `404 page not found`, "", true},
+	}
+
+	for _, tt := range tests {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(tt.status)
+			w.Write([]byte(tt.body))
+		}))
+
+		version, err := NewClient(server.URL).ServerVersion(context.Background())
+		server.Close()
+
+		if tt.wantErr {
+			if !errors.Is(err, ErrNotOllama) {
+				t.Errorf("%s: expected ErrNotOllama, got %v", tt.name, err)
+			}
+			continue
+		}
+		if err != nil || version != tt.want {
+			t.Errorf("%s: expected version %q, got %q (err %v)", tt.name, tt.want, version, err)
+		}
+	}
+}
+
 func TestChat(t *testing.T) {
 	// Create mock server that returns streaming responses
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -155,3 +192,430 @@ Complete:
 		t.Error("Expected last response to be marked as done")
 	}
 }
+
+func TestChatStalled(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		jsonData, _ := json.Marshal(ChatResponse{Message: Message{Content: "Fix "}})
+		w.Write(jsonData)
+		w.Write([]byte("\n"))
+		w.(http.Flusher).Flush()
+
+		// Hang after the first chunk, like a server out of GPU memory
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, WithStallTimeout(50*time.Millisecond))
+	respChan, errChan := client.Chat(context.Background(), ChatRequest{Model: "test-model"})
+
+	content, err := CollectStream(context.Background(), respChan, errChan, nil)
+	if !errors.Is(err, ErrStalled) {
+		t.Fatalf("Expected ErrStalled, got %v", err)
+	}
+	if content != "Fix " {
+		t.Errorf("Expected the content before the stall, got %q", content)
+	}
+}
+
+func TestChatSlowFirstChunk(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Loading the model may take longer than the stall timeout
+		time.Sleep(150 * time.Millisecond)
+		jsonData, _ := json.Marshal(ChatResponse{Message: Message{Content: "Fix bug"}, Done: true})
+		w.Write(jsonData)
+		w.Write([]byte("\n"))
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, WithStallTimeout(50*time.Millisecond))
+	text, err := client.ChatComplete(context.Background(), ChatRequest{Model: "test-model"})
+	if err != nil {
+		t.Fatalf("Expected the first chunk to be waited for, got %v", err)
+	}
+	if text != "Fix bug" {
+		t.Errorf("Expected 'Fix bug', got %q", text)
+	}
+}
+
+func TestChatOnce(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		responses := []ChatResponse{
+			{Message: Message{Content: "Fix "}},
+			{Message: Message{Content: "bug"}, Done: true, EvalCount: 12, EvalDuration: 600},
+		}
+		for _, resp := range responses {
+			jsonData, _ := json.Marshal(resp)
+			w.Write(jsonData)
+			w.Write([]byte("\n"))
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL)
+	resp, err := client.ChatOnce(context.Background(), ChatRequest{Model: "test-model"})
+	if err != nil {
+		t.Fatalf("ChatOnce failed: %v", err)
+	}
+
+	if resp.Message.Content != "Fix bug" {
+		t.Errorf("Expected content 'Fix bug', got '%s'", resp.Message.Content)
+	}
+
+	if resp.EvalCount != 12 || resp.EvalDuration != 600 {
+		t.Errorf("Expected timing fields from final chunk, got %+v", resp)
+	}
+}
+
+func TestChatOnceErrorAtClose(t *testing.T) {
+	// Both channels are closed with the error still buffered, as Chat
+	// leaves them after a failed request
+	reqErr := errors.New("model not found")
+	for i := 0; i < 100; i++ {
+		respChan := make(chan ChatResponse)
+		errChan := make(chan error, 1)
+		errChan <- reqErr
+		close(errChan)
+		close(respChan)
+
+		if resp, err := collectResponse(context.Background(), respChan, errChan); !errors.Is(err, reqErr) {
+			t.Fatalf("Expected the request error, got %v (response %+v)", err, resp)
+		}
+	}
+}
+
+func TestChatComplete(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for _, content := range []string{"- adds ", "a flag"} {
+			jsonData, _ := json.Marshal(ChatResponse{Message: Message{Content: content}})
+			w.Write(jsonData)
+			w.Write([]byte("\n"))
+		}
+	}))
+	defer server.Close()
+
+	text, err := NewClient(server.URL).ChatComplete(context.Background(), ChatRequest{Model: "test-model"})
+	if err != nil {
+		t.Fatalf("ChatComplete failed: %v", err)
+	}
+	if text != "- adds a flag" {
+		t.Errorf("Expected '- adds a flag', got '%s'", text)
+	}
+}
+
+func TestOptionsMarshalZeroTemperature(t *testing.T) {
+	data, err := json.Marshal(ChatRequest{Model: "m", Options: Options{Temperature: 0, Seed: 42}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	body := string(data)
+	if !strings.Contains(body, `"temperature":0`) {
+		t.Errorf("Expected zero temperature to be sent, got %s", body)
+	}
+	if !strings.Contains(body, `"seed":42`) {
+		t.Errorf("Expected seed to be sent, got %s", body)
+	}
+	if strings.Contains(body, "num_ctx") {
+		t.Errorf("Expected no num_ctx without a context window, got %s", body)
+	}
+
+	data, err = json.Marshal(ChatRequest{Model: "m", Options: Options{NumCtx: 16384}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"num_ctx":16384`) {
+		t.Errorf("Expected num_ctx to be sent, got %s", data)
+	}
+}
+
+func TestChatRequestMarshalThink(t *testing.T) {
+	data, err := json.Marshal(ChatRequest{Model: "m"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), `"think"`) {
+		t.Errorf("Expected think to be omitted when unset, got %s", data)
+	}
+
+	disabled := false
+	data, err = json.Marshal(ChatRequest{Model: "m", Think: &disabled})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"think":false`) {
+		t.Errorf("Expected think:false to be sent, got %s", data)
+	}
+}
+
+func TestListModels(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/tags" {
+			t.Errorf("Expected path '/api/tags', got '%s'", r.URL.Path)
+		}
+		w.Write([]byte(`{"models":[{"name":"llama3.1:8b","size":4661224676},{"name":"mistral:latest"}]}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL)
+	models, err := client.ListModels(context.Background())
+	if err != nil {
+		t.Fatalf("ListModels failed: %v", err)
+	}
+
+	if len(models) != 2 {
+		t.Fatalf("Expected 2 models, got %d", len(models))
+	}
+
+	if !HasModel(models, "llama3.1:8b") {
+		t.Error("Expected llama3.1:8b to be found")
+	}
+	if !HasModel(models, "mistral") {
+		t.Error("Expected untagged name to match ':latest'")
+	}
+	if HasModel(models, "llama3.1:70b") {
+		t.Error("Expected llama3.1:70b not to be found")
+	}
+}
+
+func TestChatOpenAI(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/chat/completions" {
+			t.Errorf("Expected path /v1/chat/completions, got %s", r.URL.Path)
+		}
+		if got := r.Header.Get("Authorization"); got != "Bearer secret" {
+			t.Errorf("Expected bearer token, got %q", got)
+		}
+
+		var body map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Fatalf("Failed to decode request: %v", err)
+		}
+		if body["stream"] != true {
+			t.Errorf("Expected stream true, got %v", body["stream"])
+		}
+		if _, ok := body["options"]; ok {
+			t.Error("Expected no Ollama options in OpenAI request")
+		}
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Write([]byte(`data: {"model":"gpt","choices":[{"delta":{"role":"assistant","content":"feat: "},"finish_reason":null}]}` + "\n\n"))
+		w.Write([]byte(`data: {"model":"gpt","choices":[{"delta":{"content":"add x"},"finish_reason":"stop"}]}` + "\n\n"))
+		w.Write([]byte("data: [DONE]\n\n"))
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, WithAPIKind(APIKindOpenAI), WithAPIKey("secret"))
+
+	resp, err := client.ChatOnce(context.Background(), ChatRequest{
+		Model:    "gpt",
+		Messages: []Message{{Role: "user", Content: "hi"}},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if resp.Message.Content != "feat: add x" {
+		t.Errorf("Expected 'feat: add x', got %q", resp.Message.Content)
+	}
+	if !resp.Done {
+		t.Error("Expected final chunk to be done")
+	}
+}
+
+func TestListModelsOpenAI(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/models" {
+			t.Errorf("Expected path /v1/models, got %s", r.URL.Path)
+		}
+		w.Write([]byte(`{"data":[{"id":"gpt-4o-mini"},{"id":"llama3.1:8b"}]}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, WithAPIKind(APIKindOpenAI))
+
+	if err := client.Ping(context.Background()); err != nil {
+		t.Fatalf("Unexpected ping error: %v", err)
+	}
+
+	models, err := client.ListModels(context.Background())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !HasModel(models, "llama3.1:8b") {
+		t.Errorf("Expected llama3.1:8b in %v", models)
+	}
+}
+
+func TestEmbed(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/embeddings" {
+			t.Errorf("Expected path /api/embeddings, got %s", r.URL.Path)
+		}
+
+		var body map[string]string
+		json.NewDecoder(r.Body).Decode(&body)
+		if body["model"] != "all-minilm" || body["prompt"] != "Fix login" {
+			t.Errorf("Unexpected request body: %v", body)
+		}
+		w.Write([]byte(`{"embedding":[0.5,-1,2]}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, WithEmbedModel("all-minilm"))
+
+	embedding, err := client.Embed(context.Background(), "Fix login")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(embedding) != 3 || embedding[0] != 0.5 || embedding[1] != -1 || embedding[2] != 2 {
+		t.Errorf("Expected [0.5 -1 2], got %v", embedding)
+	}
+}
+
+func TestEmbedOpenAI(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/embeddings" {
+			t.Errorf("Expected path /v1/embeddings, got %s", r.URL.Path)
+		}
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			t.Errorf("Expected bearer token, got %q", r.Header.Get("Authorization"))
+		}
+
+		var body map[string]string
+		json.NewDecoder(r.Body).Decode(&body)
+		if body["model"] != DefaultEmbedModel || body["input"] != "Fix login" {
+			t.Errorf("Unexpected request body: %v", body)
+		}
+		w.Write([]byte(`{"data":[{"embedding":[1,0]}]}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, WithAPIKind(APIKindOpenAI), WithAPIKey("secret"))
+
+	embedding, err := client.Embed(context.Background(), "Fix login")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(embedding) != 2 || embedding[0] != 1 {
+		t.Errorf("Expected [1 0], got %v", embedding)
+	}
+}
+
+func TestChatErrorEnvelope(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"error":"model \"llama9\" not found, try pulling it first"}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL)
+	_, err := client.ChatComplete(context.Background(), ChatRequest{Model: "llama9"})
+	if err == nil {
+		t.Fatal("Expected an error")
+	}
+
+	want := `ollama request failed with status 404: model "llama9" not found, try pulling it first`
+	if err.Error() != want {
+		t.Errorf("Expected %q, got %q", want, err.Error())
+	}
+
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || !strings.HasPrefix(apiErr.Body, `{"error":`) {
+		t.Errorf("Expected an APIError keeping the raw body, got %#v", err)
+	}
+}
+
+func TestNewAPIError(t *testing.T) {
+	tests := []struct {
+		body string
+		want string
+	}{
+		{`{"error":{"message":"invalid api key","type":"auth"}}`, "invalid api key"},
+		{`upstream timed out`, ""},
+		{`{"detail":"nope"}`, ""},
+	}
+
+	for _, tt := range tests {
+		if got := newAPIError("chat completion request", 500, []byte(tt.body)).Message; got != tt.want {
+			t.Errorf("newAPIError(%q).Message = %q, expected %q", tt.body, got, tt.want)
+		}
+	}
+
+	if got := newAPIError("embedding request", 502, []byte("bad gateway\n")).Error(); got != "embedding request failed with status 502: bad gateway" {
+		t.Errorf("Expected the raw body without an envelope, got %q", got)
+	}
+}
+
+func TestPreload(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/chat" {
+			t.Errorf("Expected path /api/chat, got %s", r.URL.Path)
+		}
+
+		var req ChatRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		if req.Model != "llama3.1:8b" || len(req.Messages) != 0 || req.Stream {
+			t.Errorf("Expected a non-streaming chat without messages, got %+v", req)
+		}
+		if req.KeepAlive != "30m" {
+			t.Errorf("Expected keep_alive 30m, got %q", req.KeepAlive)
+		}
+		w.Write([]byte(`{"model":"llama3.1:8b","done":true,"done_reason":"load"}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, WithKeepAlive("30m"))
+	if err := client.Preload(context.Background(), "llama3.1:8b"); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+func TestChatKeepAlive(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req ChatRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		if req.KeepAlive != "-1" {
+			t.Errorf("Expected the client's keep_alive, got %q", req.KeepAlive)
+		}
+		w.Write([]byte(`{"message":{"role":"assistant","content":"ok"},"done":true}` + "\n"))
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, WithKeepAlive("-1"))
+	if _, err := client.ChatComplete(context.Background(), ChatRequest{Model: "llama3.1:8b"}); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+func TestKeepAliveEncoding(t *testing.T) {
+	tests := []struct {
+		keepAlive string
+		expected  string
+	}{
+		{"-1", `"keep_alive":-1`},
+		{"300", `"keep_alive":300`},
+		{"30m", `"keep_alive":"30m"`},
+	}
+
+	for _, tt := range tests {
+		var body string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			raw, _ := io.ReadAll(r.Body)
+			body = string(raw)
+			if r.Header.Get("Authorization") != "Bearer secret" {
+				t.Errorf("Expected bearer token, got %q", r.Header.Get("Authorization"))
+			}
+			w.Write([]byte(`{"done":true}`))
+		}))
+
+		client := NewClient(server.URL, WithKeepAlive(tt.keepAlive), WithAPIKey("secret"))
+		if err := client.Preload(context.Background(), "llama3.1:8b"); err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+		server.Close()
+
+		if !strings.Contains(body, tt.expected) {
+			t.Errorf("Expected body to contain %s, got %s", tt.expected, body)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package ollama
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// DefaultEmbedModel is the embedding model used when none is configured
+const DefaultEmbedModel = "nomic-embed-text"
+
+// WithEmbedModel sets the model used by Embed. An empty name keeps
+// DefaultEmbedModel.
+func WithEmbedModel(model string) ClientOption {
+	return func(c *Client) {
+		if model != "" {
+			c.embedModel = model
+		}
+	}
+}
+
+// EmbedModel returns the model used by Embed
+func (c *Client) EmbedModel() string {
+	return c.embedModel
+}
+
+// Embed returns the embedding vector of text
+func (c *Client) Embed(ctx context.Context, text string) ([]float32, error) {
+	path := "/api/embeddings"
+	var body interface{} = struct {
+		Model  string `json:"model"`
+		Prompt string `json:"prompt"`
+	}{c.embedModel, text}
+	if c.apiKind == APIKindOpenAI {
+		path = "/v1/embeddings"
+		body = struct {
+			Model string `json:"model"`
+			Input string `json:"input"`
+		}{c.embedModel, text}
+	}
+
+	reqBody, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal embedding request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+path, bytes.NewReader(reqBody))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create embedding request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	c.setAuth(req)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to request embedding: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(resp.Body)
+		return nil, newAPIError("embedding request", resp.StatusCode, respBody)
+	}
+
+	// Ollama answers {"embedding": [...]}, OpenAI {"data": [{"embedding": [...]}]}
+	var result struct {
+		Embedding []float32 `json:"embedding"`
+		Data      []struct {
+			Embedding []float32 `json:"embedding"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode embedding: %w", err)
+	}
+
+	embedding := result.Embedding
+	if len(result.Data) > 0 {
+		embedding = result.Data[0].Embedding
+	}
+	if len(embedding) == 0 {
+		return nil, fmt.Errorf("model %s returned an empty embedding", c.embedModel)
+	}
+
+	return embedding, nil
+}
@@ -0,0 +1,52 @@
+package ollama
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// APIError is a non-200 response from the server
+type APIError struct {
+	Op         string // what was requested, e.g. "ollama request"
+	StatusCode int
+	Message    string // the error message from the response body, if it had one
+	Body       string // the raw response body
+}
+
+// Error returns the server's error message, or the raw body when it didn't
+// send one in the usual envelope
+func (e *APIError) Error() string {
+	detail := e.Message
+	if detail == "" {
+		detail = e.Body
+	}
+	return fmt.Sprintf("%s failed with status %d: %s", e.Op, e.StatusCode, detail)
+}
+
+// newAPIError builds an APIError from a response body. Ollama answers
+// {"error": "..."}, OpenAI-compatible servers {"error": {"message": "..."}}.
+func newAPIError(op string, statusCode int, body []byte) *APIError {
+	apiErr := &APIError{Op: op, StatusCode: statusCode, Body: strings.TrimSpace(string(body))}
+
+	var envelope struct {
+		Error json.RawMessage `json:"error"`
+	}
+	if err := json.Unmarshal(body, &envelope); err != nil || len(envelope.Error) == 0 {
+		return apiErr
+	}
+
+	var message string
+	if err := json.Unmarshal(envelope.Error, &message); err == nil {
+		apiErr.Message = message
+		return apiErr
+	}
+
+	var detail struct {
+		Message string `json:"message"`
+	}
+	if err := json.Unmarshal(envelope.Error, &detail); err == nil {
+		apiErr.Message = detail.Message
+	}
+	return apiErr
+}
@@ -0,0 +1,72 @@
+package ollama
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// failoverPingTimeout bounds the ping of every host but the last, so a
+// machine that is asleep doesn't hold up the fallback for the full request
+// timeout
+const failoverPingTimeout = 3 * time.Second
+
+// FailoverClient talks to the first of several hosts that answers Ping. It
+// embeds the selected Client, so every Client method goes to that host.
+// Until Ping has run, the first host is used.
+type FailoverClient struct {
+	*Client
+	clients []*Client
+}
+
+// NewFailoverClient creates a client for hosts, tried in order. Each host
+// gets its own Client with opts applied.
+func NewFailoverClient(hosts []string, opts ...ClientOption) *FailoverClient {
+	f := &FailoverClient{}
+	for _, host := range hosts {
+		f.clients = append(f.clients, NewClient(host, opts...))
+	}
+	if len(f.clients) == 0 {
+		f.clients = []*Client{NewClient("", opts...)}
+	}
+	f.Client = f.clients[0]
+	return f
+}
+
+// Ping pings the hosts in order and selects the first that answers. It
+// fails with the error of every host when none does.
+func (f *FailoverClient) Ping(ctx context.Context) error {
+	var errs []error
+	for i, c := range f.clients {
+		err := pingWithin(ctx, c, i < len(f.clients)-1)
+		if err == nil {
+			f.Client = c
+			return nil
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if len(f.clients) == 1 {
+			return err
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", c.baseURL, err))
+	}
+	return errors.Join(errs...)
+}
+
+// Host returns the base URL of the selected host
+func (f *FailoverClient) Host() string {
+	return f.Client.baseURL
+}
+
+// pingWithin pings c, giving up after failoverPingTimeout when another host
+// is left to try
+func pingWithin(ctx context.Context, c *Client, bounded bool) error {
+	if bounded {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, failoverPingTimeout)
+		defer cancel()
+	}
+	return c.Ping(ctx)
+}
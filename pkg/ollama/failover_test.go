@@ -0,0 +1,70 @@
+package ollama
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestFailoverClientPicksFirstHealthyHost(t *testing.T) {
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer down.Close()
+
+	var chats int
+	up := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/version":
+			w.Write([]byte(`{"version":"0.3.12"}`))
+		case "/api/chat":
+			chats++
+			w.Write([]byte(`{"message":{"role":"assistant","content":"Add failover"},"done":true}` + "\n"))
+		}
+	}))
+	defer up.Close()
+
+	client := NewFailoverClient([]string{down.URL, up.URL})
+	if client.Host() != down.URL {
+		t.Errorf("Expected the first host before Ping, got %s", client.Host())
+	}
+
+	if err := client.Ping(context.Background()); err != nil {
+		t.Fatalf("Ping failed: %v", err)
+	}
+	if client.Host() != up.URL {
+		t.Errorf("Expected %s to be selected, got %s", up.URL, client.Host())
+	}
+
+	content, err := client.ChatComplete(context.Background(), ChatRequest{Model: "test"})
+	if err != nil {
+		t.Fatalf("ChatComplete failed: %v", err)
+	}
+	if content != "Add failover" || chats != 1 {
+		t.Errorf("Expected the chat to go to the healthy host, got %q after %d chats", content, chats)
+	}
+}
+
+func TestFailoverClientAllHostsDown(t *testing.T) {
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer down.Close()
+
+	client := NewFailoverClient([]string{down.URL, down.URL + "/other"})
+	err := client.Ping(context.Background())
+	if err == nil {
+		t.Fatal("Expected Ping to fail when no host answers")
+	}
+	if !strings.Contains(err.Error(), down.URL+":") || !strings.Contains(err.Error(), down.URL+"/other:") {
+		t.Errorf("Expected the error to name every host, got %v", err)
+	}
+
+	// A single host reports its error as before
+	single := NewFailoverClient([]string{down.URL})
+	if err := single.Ping(context.Background()); err == nil || strings.HasPrefix(err.Error(), down.URL) {
+		t.Errorf("Expected the plain ping error for a single host, got %v", err)
+	}
+}
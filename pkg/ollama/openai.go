@@ -0,0 +1,188 @@
+package ollama
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// openAIChatRequest is the request body for /v1/chat/completions
+type openAIChatRequest struct {
+	Model          string                `json:"model"`
+	Messages       []Message             `json:"messages"`
+	Stream         bool                  `json:"stream"`
+	Temperature    float32               `json:"temperature"`
+	Seed           int                   `json:"seed,omitempty"`
+	ResponseFormat *openAIResponseFormat `json:"response_format,omitempty"`
+}
+
+// openAIResponseFormat requests JSON mode from the server
+type openAIResponseFormat struct {
+	Type string `json:"type"`
+}
+
+// openAIChatChunk is a single SSE chunk from a streaming completion
+type openAIChatChunk struct {
+	Model   string `json:"model"`
+	Created int64  `json:"created"`
+	Choices []struct {
+		Delta struct {
+			Role    string `json:"role"`
+			Content string `json:"content"`
+		} `json:"delta"`
+		FinishReason *string `json:"finish_reason"`
+	} `json:"choices"`
+	Usage *struct {
+		PromptTokens     int `json:"prompt_tokens"`
+		CompletionTokens int `json:"completion_tokens"`
+	} `json:"usage"`
+}
+
+// newOpenAIChatRequest converts a ChatRequest to the OpenAI request shape
+func newOpenAIChatRequest(req ChatRequest) openAIChatRequest {
+	body := openAIChatRequest{
+		Model:       req.Model,
+		Messages:    req.Messages,
+		Stream:      true,
+		Temperature: req.Options.Temperature,
+		Seed:        req.Options.Seed,
+	}
+	if req.Format == "json" {
+		body.ResponseFormat = &openAIResponseFormat{Type: "json_object"}
+	}
+	return body
+}
+
+// toChatResponse maps an SSE chunk onto the native ChatResponse
+func (chunk openAIChatChunk) toChatResponse() ChatResponse {
+	resp := ChatResponse{
+		Model:   chunk.Model,
+		Message: Message{Role: "assistant"},
+	}
+	if chunk.Created > 0 {
+		resp.CreatedAt = time.Unix(chunk.Created, 0)
+	}
+	if len(chunk.Choices) > 0 {
+		resp.Message.Content = chunk.Choices[0].Delta.Content
+		resp.Done = chunk.Choices[0].FinishReason != nil
+	}
+	if chunk.Usage != nil {
+		resp.PromptEvalCount = chunk.Usage.PromptTokens
+		resp.EvalCount = chunk.Usage.CompletionTokens
+	}
+	return resp
+}
+
+// setAuth adds the bearer token, if any, to an OpenAI-compatible request
+func (c *Client) setAuth(req *http.Request) {
+	if c.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+}
+
+// streamOpenAIChat performs a streaming request against /v1/chat/completions
+func (c *Client) streamOpenAIChat(ctx context.Context, req ChatRequest, respChan chan<- ChatResponse) error {
+	// Create request context with timeout
+	timeoutCtx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	reqCtx, cancelCause := context.WithCancelCause(timeoutCtx)
+	defer cancelCause(nil)
+
+	stall := newStallTimer(c.stallTimeout, cancelCause)
+	defer stall.stop()
+
+	reqBody, err := json.Marshal(newOpenAIChatRequest(req))
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(reqCtx, "POST", c.baseURL+"/v1/chat/completions", bytes.NewReader(reqBody))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Accept", "text/event-stream")
+	c.setAuth(httpReq)
+
+	resp, err := c.executeWithRetry(httpReq, 3)
+	if err != nil {
+		return fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return newAPIError("chat completion request", resp.StatusCode, body)
+	}
+
+	// Each event is a "data: {...}" line; the stream ends with "data: [DONE]"
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if !strings.HasPrefix(line, "data:") {
+			continue
+		}
+
+		data := strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+		if data == "[DONE]" {
+			break
+		}
+
+		var chunk openAIChatChunk
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return fmt.Errorf("failed to unmarshal response: %w", err)
+		}
+
+		select {
+		case respChan <- chunk.toChatResponse():
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		stall.reset()
+	}
+
+	return streamErr(reqCtx, scanner.Err())
+}
+
+// listOpenAIModels returns the models served by an OpenAI-compatible endpoint
+func (c *Client) listOpenAIModels(ctx context.Context) ([]Model, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", c.baseURL+"/v1/models", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create list request: %w", err)
+	}
+	c.setAuth(req)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list models: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("listing models failed with status %d", resp.StatusCode)
+	}
+
+	var list struct {
+		Data []struct {
+			ID      string `json:"id"`
+			Created int64  `json:"created"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&list); err != nil {
+		return nil, fmt.Errorf("failed to decode model list: %w", err)
+	}
+
+	models := make([]Model, 0, len(list.Data))
+	for _, m := range list.Data {
+		models = append(models, Model{Name: m.ID, ModifiedAt: time.Unix(m.Created, 0)})
+	}
+
+	return models, nil
+}
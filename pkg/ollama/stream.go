@@ -0,0 +1,106 @@
+package ollama
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultStallTimeout is how long a stream may go without a new chunk
+// before it is given up on
+const DefaultStallTimeout = 30 * time.Second
+
+// ErrStalled is returned when a model stops sending chunks part way through
+// a response, e.g. after running out of GPU memory
+var ErrStalled = errors.New("model stopped responding")
+
+// CollectStream reads the channels returned by Chat until the stream ends
+// and returns the concatenated content. onChunk, when not nil, is called
+// with each chunk as it arrives, e.g. to animate a spinner. On an error or
+// cancellation the content received so far is returned with the error.
+func CollectStream(ctx context.Context, respChan <-chan ChatResponse, errChan <-chan error, onChunk func(string)) (string, error) {
+	var content strings.Builder
+
+	for {
+		select {
+		case resp, ok := <-respChan:
+			if !ok {
+				// An error is sent before the channels close, but select
+				// may see the closed respChan first
+				return content.String(), pendingErr(errChan)
+			}
+			content.WriteString(resp.Message.Content)
+			if onChunk != nil {
+				onChunk(resp.Message.Content)
+			}
+
+		case err, ok := <-errChan:
+			if !ok {
+				errChan = nil // closed; wait for respChan to close
+				continue
+			}
+			if err != nil {
+				return content.String(), err
+			}
+
+		case <-ctx.Done():
+			return content.String(), ctx.Err()
+		}
+	}
+}
+
+// pendingErr returns the error waiting in errChan, if any, without blocking
+func pendingErr(errChan <-chan error) error {
+	select {
+	case err := <-errChan:
+		return err
+	default:
+		return nil
+	}
+}
+
+// stallTimer cancels a streaming request when no chunk arrives within
+// timeout of the previous one. The first chunk arms it, so a model that
+// takes minutes to load is still waited for under the overall timeout.
+type stallTimer struct {
+	timeout time.Duration
+	cancel  context.CancelCauseFunc
+
+	mu    sync.Mutex
+	timer *time.Timer
+}
+
+// newStallTimer returns a stallTimer calling cancel on a stall. A timeout
+// of 0 or less disables it.
+func newStallTimer(timeout time.Duration, cancel context.CancelCauseFunc) *stallTimer {
+	return &stallTimer{timeout: timeout, cancel: cancel}
+}
+
+// reset restarts the countdown; call it for every chunk received
+func (s *stallTimer) reset() {
+	if s.timeout <= 0 {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.timer == nil {
+		s.timer = time.AfterFunc(s.timeout, func() {
+			s.cancel(fmt.Errorf("%w: no output for %s", ErrStalled, s.timeout))
+		})
+		return
+	}
+	s.timer.Reset(s.timeout)
+}
+
+// stop disarms the timer once the stream has ended
+func (s *stallTimer) stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.timer != nil {
+		s.timer.Stop()
+	}
+}
@@ -0,0 +1,86 @@
+package ollama
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// fakeStream returns channels that deliver chunks and then err, closed the
+// way Chat closes them
+func fakeStream(chunks []string, err error) (<-chan ChatResponse, <-chan error) {
+	respChan := make(chan ChatResponse)
+	errChan := make(chan error, 1)
+
+	go func() {
+		defer close(respChan)
+		defer close(errChan)
+		for _, chunk := range chunks {
+			respChan <- ChatResponse{Message: Message{Content: chunk}}
+		}
+		if err != nil {
+			errChan <- err
+		}
+	}()
+
+	return respChan, errChan
+}
+
+func TestCollectStream(t *testing.T) {
+	respChan, errChan := fakeStream([]string{"Fix ", "parser ", "crash"}, nil)
+
+	var chunks int
+	content, err := CollectStream(context.Background(), respChan, errChan, func(string) { chunks++ })
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if content != "Fix parser crash" {
+		t.Errorf("Expected concatenated content, got %q", content)
+	}
+	if chunks != 3 {
+		t.Errorf("Expected onChunk for each of 3 chunks, got %d", chunks)
+	}
+}
+
+func TestCollectStreamError(t *testing.T) {
+	streamErr := errors.New("connection reset")
+	respChan, errChan := fakeStream([]string{"Fix "}, streamErr)
+
+	content, err := CollectStream(context.Background(), respChan, errChan, nil)
+	if !errors.Is(err, streamErr) {
+		t.Fatalf("Expected the stream error, got %v", err)
+	}
+	if content != "Fix " {
+		t.Errorf("Expected the partial content, got %q", content)
+	}
+}
+
+func TestCollectStreamErrorAtClose(t *testing.T) {
+	// Both channels are closed with the error still buffered, so select
+	// can see the closed respChan before the error
+	streamErr := errors.New("connection reset")
+	for i := 0; i < 100; i++ {
+		respChan := make(chan ChatResponse)
+		errChan := make(chan error, 1)
+		errChan <- streamErr
+		close(errChan)
+		close(respChan)
+
+		if _, err := CollectStream(context.Background(), respChan, errChan, nil); !errors.Is(err, streamErr) {
+			t.Fatalf("Expected the stream error, got %v", err)
+		}
+	}
+}
+
+func TestCollectStreamCancelled(t *testing.T) {
+	// A stream that never ends, like a hung server
+	respChan := make(chan ChatResponse)
+	errChan := make(chan error)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := CollectStream(ctx, respChan, errChan, nil); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+}
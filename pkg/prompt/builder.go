@@ -2,10 +2,16 @@ package prompt
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
+	"path"
+	"regexp"
+	"sort"
 	"strings"
 	"text/template"
 
+	"github.com/mattn/go-runewidth"
+
 	"gh-smart-commit/pkg/git"
 )
 
@@ -24,8 +30,28 @@ type Context struct {
 	Rules       []string
 	MaxLength   int
 	Style       string
-	Description string      // For bash command descriptions
-	SystemInfo  interface{} // For system context information
+	Description string            // For bash command descriptions
+	SystemInfo  interface{}       // For system context information
+	Groups      []ChangelogGroup  // For changelog sections
+	Release     string            // For the changelog heading, e.g. "v1.2.0" or "Unreleased"
+	BaseBranch  string            // For pull request descriptions
+	Body        bool              // For smart-commit: request a body after the subject
+	Assets      []string          // For smart-commit: notes on image and binary file changes
+	Types       []string          // For smart-commit: allowed conventional commit types; empty means natural language
+	Examples    []string          // For smart-commit: similar past commit messages to match in style
+	Gitmojis    []Gitmoji         // For smart-commit: start the subject with a gitmoji; ignored when Types is set
+	Checklists  []Checklist       // For lint-suggestions: review points for the languages in the diff
+	Vars        map[string]string // Ad-hoc values from --var, e.g. {{.Vars.ticket}}
+	// For smart-commit: staged files by directory, e.g. "cmd/ (3 files), pkg/git/ (1 file)"
+	ChangedAreas string
+	ExtraFiles   []ContextFile // For smart-commit: related files from --context-file
+}
+
+// ContextFile is a file shown to the model for context, outside the diff
+type ContextFile struct {
+	Path      string
+	Content   string
+	Truncated bool // only the start of the file is in Content
 }
 
 // SmartCommitTemplate is the prompt template for generating commit messages
@@ -40,7 +66,30 @@ CRITICAL INSTRUCTIONS:
 - Just the raw commit message and nothing else
 
 Requirements for the commit message:
-1. Start with an action verb in imperative mood (Add, Remove, Fix, Update, Refactor, etc.)
+{{if .Types}}1. Use conventional commit format: "type: description" or "type(scope): description"
+2. The type MUST be one of: {{join .Types ", "}}
+3. Keep the first line under 72 characters
+4. Write the description in imperative mood and lower case
+5. Use the changed component or directory as the scope when it helps
+6. Focus on what was changed and where it was changed
+
+EXAMPLE OUTPUT FORMAT:
+feat(auth): add OAuth2 integration to AuthService
+fix: handle null pointer in user validation service
+docs: update installation instructions in README
+{{else if .Gitmojis}}1. Start with the gitmoji for the kind of change, then a space:
+{{range .Gitmojis}}   {{.Emoji}} for {{.Meaning}}
+{{end}}2. After the gitmoji, start with an action verb in imperative mood
+3. Keep the first line under 72 characters
+4. Include specific file names or components where changes were made
+5. Do not add a conventional commit type like "feat:" or "fix:"
+6. Focus on what was changed and where it was changed
+
+EXAMPLE OUTPUT FORMAT:
+✨ Add OAuth2 integration to AuthService
+🐛 Fix null pointer error in user validation service
+📝 Update installation instructions in README
+{{else}}1. Start with an action verb in imperative mood (Add, Remove, Fix, Update, Refactor, etc.)
 2. Include specific file names or components where changes were made
 3. Keep the first line under 72 characters
 4. Be descriptive but concise
@@ -53,22 +102,52 @@ Remove unused points status property in ThirdView
 Fix null pointer error in user validation service
 Update installation instructions in README
 Refactor database queries in ProductRepository
+{{end}}
+{{if .Body}}
+BODY:
+After the first line, add one blank line and then a short body explaining WHY
+the change was made and anything a reviewer should know. Wrap body lines at
+72 characters. Omit the body if the first line already says everything.
 
+EXAMPLE WITH BODY:
+Fix token refresh race in AuthService
+
+Concurrent requests could each start a refresh and overwrite each other's
+tokens. Guard the refresh with a mutex so only one runs at a time.
+{{end}}
 REMEMBER: 
-- Start with action verb (Add, Remove, Fix, Update, etc.)
+{{if .Types}}- Start with one of the allowed types: {{join .Types ", "}}
+- Never invent a type that is not in the list
+{{else if .Gitmojis}}- Start with exactly one gitmoji from the list
+- NO conventional commit format like "feat:" or "fix:"
+{{else}}- Start with action verb (Add, Remove, Fix, Update, etc.)
 - Include file/component names
 - NO conventional commit format like "feat:" or "fix:"
-- Output ONLY the commit message. No other text whatsoever.`,
+{{end}}- Output ONLY the commit message. No other text whatsoever.`,
 
 	User: `Repository: {{.Repo}}
 Branch: {{.Branch}}
-
+{{if .ChangedAreas}}Changed areas: {{.ChangedAreas}}
+{{end}}
 {{if .Rules}}Rules:
 {{range .Rules}}- {{.}}
 {{end}}
 {{end}}
 
-Diff:
+{{if .Assets}}Asset changes (contents not shown in the diff):
+{{range .Assets}}- {{.}}
+{{end}}
+{{end}}{{if .Examples}}Similar past commit messages in this repository (match their style, not their content):
+{{range .Examples}}- {{.}}
+{{end}}
+{{end}}{{if .Vars}}Additional context:
+{{range $key, $value := .Vars}}- {{$key}}: {{$value}}
+{{end}}
+{{end}}{{if .ExtraFiles}}Related files, for context only (they are not part of the change):
+{{range .ExtraFiles}}--- {{.Path}}{{if .Truncated}} (first lines only){{end}}
+{{.Content}}
+{{end}}
+{{end}}Diff:
 {{.Diff}}
 
 Output the commit message only:`,
@@ -86,10 +165,21 @@ var LintSuggestionsTemplate = Template{
 
 Format your response as a numbered list where each suggestion includes:
 - Severity level: [HIGH/MEDIUM/LOW]
+- The location as file:line, taken from the diff, when the suggestion applies to a specific line
 - Brief description
 - Specific recommendation
 
-Keep suggestions actionable and specific. Focus on the most impactful improvements first.`,
+Example: 1. [HIGH] cmd/root.go:42 Handle the error returned by Close
+
+Only comment on lines that were added or changed in the diff. Unchanged context
+lines and code outside the diff are there for orientation; do not make suggestions
+about them or about code you cannot see.
+
+Keep suggestions actionable and specific. Focus on the most impactful improvements first.
+{{range .Checklists}}
+For {{.Language}} changes, also check for:
+{{range .Items}}- {{.}}
+{{end}}{{end}}`,
 
 	User: `Repository: {{.Repo}}
 Branch: {{.Branch}}
@@ -97,7 +187,10 @@ Branch: {{.Branch}}
 Changes to review:
 {{.Diff}}
 
-Provide ordered suggestions for improvement:`,
+{{if .Vars}}Additional context:
+{{range $key, $value := .Vars}}- {{$key}}: {{$value}}
+{{end}}
+{{end}}Provide ordered suggestions for improvement:`,
 }
 
 // BranchDescribeTemplate is the prompt template for describing branch changes
@@ -116,13 +209,94 @@ Branch: {{.Branch}}
 
 Recent commits:
 {{range .Commits}}- {{.Message}} ({{.Date}})
-{{end}}
+{{if .Body}}{{indent .Body "  "}}
+{{end}}{{end}}
 
 {{if .Diff}}Recent changes:
 {{.Diff}}
 {{end}}
 
-Generate a concise description of what this branch accomplishes:`,
+{{if .Vars}}Additional context:
+{{range $key, $value := .Vars}}- {{$key}}: {{$value}}
+{{end}}
+{{end}}Generate a concise description of what this branch accomplishes:`,
+}
+
+// PRDescribeTemplate is the prompt template for pull request titles and bodies
+var PRDescribeTemplate = Template{
+	System: `You are an expert software engineer who writes clear pull request descriptions.
+
+Respond in exactly this format:
+- First line: a concise pull request title (max 72 chars, imperative mood, no markdown)
+- Then an empty line
+- Then a markdown body with these sections, in this order:
+
+## Summary
+One or two sentences on what the pull request does and why.
+
+## Changes
+A bulleted list of the notable changes.
+
+## Testing
+How the changes were or should be tested. If the diff adds tests, mention them.
+
+Do not wrap the response in a code block.`,
+
+	User: `Repository: {{.Repo}}
+Branch: {{.Branch}} (into {{.BaseBranch}})
+
+Commits on this branch:
+{{range .Commits}}- {{.Message}}
+{{end}}
+{{if .Diff}}Diff against {{.BaseBranch}}:
+{{.Diff}}
+{{end}}
+{{if .Vars}}Additional context:
+{{range $key, $value := .Vars}}- {{$key}}: {{$value}}
+{{end}}
+{{end}}Write the pull request title and body:`,
+}
+
+// DiffSummaryTemplate is the prompt template for summarizing diff hunks that
+// do not fit in the main prompt
+var DiffSummaryTemplate = Template{
+	System: `You summarize code changes for another model that will write a commit message.
+
+Describe what the diff changes as short bullet points, one per logical change,
+naming the files or components involved. Mention behaviour changes, new or removed
+functionality and renamed symbols. Do not speculate and do not include code.`,
+
+	User: `Diff:
+{{.Diff}}
+
+Summarize these changes:`,
+}
+
+// ChangelogTemplate is the prompt template for drafting release notes
+var ChangelogTemplate = Template{
+	System: `You are an expert software engineer who writes release notes in the Keep a Changelog format (https://keepachangelog.com).
+
+Write a single markdown section for the release:
+- Start with the heading "## [{{.Release}}]"
+- Use only these subsections, in this order, and omit empty ones: ### Added, ### Changed, ### Deprecated, ### Removed, ### Fixed, ### Security
+- One bullet per user-visible change, written for users rather than developers
+- Merge commits that describe the same change into one bullet
+- Leave out purely internal changes
+
+Output the markdown section only.`,
+
+	User: `Repository: {{.Repo}}
+Release: {{.Release}}
+
+Commits grouped by suggested section:
+{{range .Groups}}
+{{.Name}}:
+{{range .Commits}}- {{.Message}}
+{{end}}{{end}}
+{{if .Vars}}Additional context:
+{{range $key, $value := .Vars}}- {{$key}}: {{$value}}
+{{end}}
+{{end}}Write the changelog section:`,
 }
 
 // BashTemplate is the prompt template for generating bash commands
@@ -172,7 +346,36 @@ System Context:
 Current Directory Structure:
 {{.SystemInfo.FileTree}}
 
-Generate the bash command:`,
+{{if .Vars}}Additional context:
+{{range $key, $value := .Vars}}- {{$key}}: {{$value}}
+{{end}}
+{{end}}Generate the bash command:`,
+}
+
+// BashExplainTemplate is the bash template for --explain: the same request,
+// answered with the command and a one-line rationale as JSON
+var BashExplainTemplate = Template{
+	System: `You are an expert system administrator and command-line specialist. Generate safe, efficient bash commands based on user descriptions and system context, and explain them briefly.
+
+CRITICAL INSTRUCTIONS:
+- Respond ONLY with a JSON object of the form:
+  {"command": "the bash command", "explanation": "one sentence on what it does and why"}
+- The command must be a single raw bash command, with NO markdown code blocks or formatting
+- The explanation must be one short sentence, mentioning any flag that is not obvious
+- Ensure the command is safe and appropriate for the given context
+- Use standard Unix/Linux tools when possible
+- Be mindful of the operating system and available tools
+
+SAFETY GUIDELINES:
+- Avoid destructive operations without explicit user intent
+- Use appropriate flags for safety (e.g., -i for interactive confirmations)
+- Prefer relative paths when working within a project
+- Use standard tools available on most systems
+
+EXAMPLE OUTPUT:
+{"command": "find . -name \"*.go\" -type f", "explanation": "Lists every Go file below the current directory; -type f leaves out directories."}`,
+
+	User: BashTemplate.User,
 }
 
 // TagSuggestTemplate is the prompt template for suggesting tags
@@ -192,7 +395,7 @@ Suggest 3-5 most relevant tags. Format as a simple comma-separated list.`,
 Branch: {{.Branch}}
 
 Files changed:
-{{range .Commits}}{{range .Files}}- {{.}}
+{{range .Commits}}{{$renames := .Renames}}{{range .Files}}- {{.}}{{with index $renames .}} (renamed from {{.}}){{end}}
 {{end}}{{end}}
 
 Changes:
@@ -203,7 +406,9 @@ Suggest relevant tags (comma-separated):`,
 
 // Builder builds prompts from templates and context
 type Builder struct {
-	templates map[string]Template
+	templates    map[string]Template
+	systemPrefix string
+	systemSuffix string
 }
 
 // NewBuilder creates a new prompt builder
@@ -214,11 +419,32 @@ func NewBuilder() *Builder {
 			"lint-suggestions": LintSuggestionsTemplate,
 			"branch-describe":  BranchDescribeTemplate,
 			"bash":             BashTemplate,
+			"bash-explain":     BashExplainTemplate,
 			"tag-suggest":      TagSuggestTemplate,
+			"changelog":        ChangelogTemplate,
+			"pr-describe":      PRDescribeTemplate,
+			"diff-summary":     DiffSummaryTemplate,
 		},
 	}
 }
 
+// templateFuncs are the helpers available to every prompt template
+var templateFuncs = template.FuncMap{
+	"join":   strings.Join,
+	"indent": indent,
+}
+
+// indent prefixes every non-empty line of text with prefix
+func indent(text, prefix string) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		if line != "" {
+			lines[i] = prefix + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
 // Build builds a prompt for the given template name and context
 func (b *Builder) Build(templateName string, ctx Context) (system, user string, err error) {
 	tmpl, exists := b.templates[templateName]
@@ -226,10 +452,9 @@ func (b *Builder) Build(templateName string, ctx Context) (system, user string,
 		return "", "", fmt.Errorf("template not found: %s", templateName)
 	}
 
-	// Build system prompt
-	systemTmpl, err := template.New("system").Parse(tmpl.System)
+	systemTmpl, userTmpl, err := parseTemplate(tmpl)
 	if err != nil {
-		return "", "", fmt.Errorf("failed to parse system template: %w", err)
+		return "", "", err
 	}
 
 	var systemBuf bytes.Buffer
@@ -237,25 +462,72 @@ func (b *Builder) Build(templateName string, ctx Context) (system, user string,
 		return "", "", fmt.Errorf("failed to execute system template: %w", err)
 	}
 
-	// Build user prompt
-	userTmpl, err := template.New("user").Parse(tmpl.User)
-	if err != nil {
-		return "", "", fmt.Errorf("failed to parse user template: %w", err)
-	}
-
 	var userBuf bytes.Buffer
 	if err := userTmpl.Execute(&userBuf, ctx); err != nil {
 		return "", "", fmt.Errorf("failed to execute user template: %w", err)
 	}
 
-	return systemBuf.String(), userBuf.String(), nil
+	system = systemBuf.String()
+	if b.systemPrefix != "" {
+		system = b.systemPrefix + "\n\n" + system
+	}
+	if b.systemSuffix != "" {
+		system = strings.TrimRight(system, "\n") + "\n\n" + b.systemSuffix
+	}
+
+	return system, userBuf.String(), nil
 }
 
-// AddTemplate adds a custom template
-func (b *Builder) AddTemplate(name string, tmpl Template) {
+// SetSystemText sets instructions added before and after the system prompt
+// of every template, e.g. "Use American English spelling." Empty strings add
+// nothing.
+func (b *Builder) SetSystemText(prefix, suffix string) {
+	b.systemPrefix = strings.TrimSpace(prefix)
+	b.systemSuffix = strings.TrimSpace(suffix)
+}
+
+// AddTemplate adds a custom template. Both prompts are parsed up front, so a
+// malformed template is reported here rather than on the first Build.
+func (b *Builder) AddTemplate(name string, tmpl Template) error {
+	if _, _, err := parseTemplate(tmpl); err != nil {
+		return fmt.Errorf("template %s: %w", name, err)
+	}
 	b.templates[name] = tmpl
+	return nil
 }
 
+// ListTemplates returns the names of the registered templates, sorted
+func (b *Builder) ListTemplates() []string {
+	names := make([]string, 0, len(b.templates))
+	for name := range b.templates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// parseTemplate parses the system and user prompts of tmpl. A missing map
+// key, such as an unset {{.Vars.ticket}}, fails execution instead of
+// rendering "<no value>".
+func parseTemplate(tmpl Template) (system, user *template.Template, err error) {
+	system, err = template.New("system").Funcs(templateFuncs).Option("missingkey=error").Parse(tmpl.System)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse system template: %w", err)
+	}
+
+	user, err = template.New("user").Funcs(templateFuncs).Option("missingkey=error").Parse(tmpl.User)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse user template: %w", err)
+	}
+
+	return system, user, nil
+}
+
+// ErrMissingType is returned by ValidateCommitMessage for a subject without
+// a "type: description" separator, which is expected of natural-language
+// messages
+var ErrMissingType = errors.New("commit message should follow 'type: description' format")
+
 // ValidateCommitMessage validates a generated commit message
 func ValidateCommitMessage(message string) error {
 	if message == "" {
@@ -267,19 +539,61 @@ func ValidateCommitMessage(message string) error {
 		return fmt.Errorf("commit message is empty")
 	}
 
+	// Only the subject is length-checked; the body follows a blank line.
+	// Width is counted in columns so a leading emoji counts as it displays.
 	firstLine := strings.TrimSpace(lines[0])
-	if len(firstLine) > 72 {
-		return fmt.Errorf("first line is too long (%d chars, max 72)", len(firstLine))
+	if width := runewidth.StringWidth(firstLine); width > 72 {
+		return fmt.Errorf("first line is too long (%d chars, max 72)", width)
+	}
+
+	if len(lines) > 1 && strings.TrimSpace(lines[1]) != "" {
+		return fmt.Errorf("second line should be blank to separate the subject from the body")
 	}
 
 	// Basic conventional commit format check
 	if !strings.Contains(firstLine, ":") {
-		return fmt.Errorf("commit message should follow 'type: description' format")
+		return ErrMissingType
 	}
 
 	return nil
 }
 
+// MessageMatchesFiles reports whether message mentions any of the changed
+// files by name, by name without extension or by directory, e.g. the scope
+// "auth" or the component "TokenStore" for pkg/auth/token_store.go
+func MessageMatchesFiles(message string, files []string) bool {
+	normalize := func(s string) string {
+		return strings.NewReplacer("_", "", "-", "", ".", "").Replace(strings.ToLower(s))
+	}
+	text := normalize(message)
+
+	for _, file := range files {
+		base := path.Base(file)
+		candidates := []string{base, strings.TrimSuffix(base, path.Ext(base))}
+		for _, dir := range strings.Split(path.Dir(file), "/") {
+			candidates = append(candidates, dir)
+		}
+
+		for _, candidate := range candidates {
+			// Very short names such as "a" or "db" match too much by accident
+			if n := normalize(candidate); len(n) >= 3 && strings.Contains(text, n) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// reasoningPattern matches <think> blocks emitted by reasoning models. An
+// unterminated block runs to the end of the response.
+var reasoningPattern = regexp.MustCompile(`(?is)<think>.*?(?:</think>|\z)`)
+
+// StripReasoning removes reasoning blocks from a model response
+func StripReasoning(response string) string {
+	return strings.TrimSpace(reasoningPattern.ReplaceAllString(response, ""))
+}
+
 // SanitizeCommitMessage cleans up a generated commit message
 func SanitizeCommitMessage(message string) string {
 	// Remove common AI prefixes and cleanup
@@ -291,7 +605,7 @@ func SanitizeCommitMessage(message string) string {
 		"```",
 	}
 
-	cleaned := strings.TrimSpace(message)
+	cleaned := StripReasoning(message)
 	for _, prefix := range prefixes {
 		if strings.HasPrefix(cleaned, prefix) {
 			cleaned = strings.TrimSpace(strings.TrimPrefix(cleaned, prefix))
@@ -301,7 +615,113 @@ func SanitizeCommitMessage(message string) string {
 	// Remove trailing punctuation like quotes or backticks
 	cleaned = strings.Trim(cleaned, "`\"'")
 
-	return strings.TrimSpace(cleaned)
+	subject, body := SplitCommitMessage(cleaned)
+	if body == "" {
+		return subject
+	}
+
+	return subject + "\n\n" + wrapBody(body, bodyWidth)
+}
+
+// CompleteLines drops the last line of a response that was cut off
+// mid-stream, since it is likely unfinished. A response without a line
+// break has nothing complete and yields "".
+func CompleteLines(partial string) string {
+	i := strings.LastIndex(partial, "\n")
+	if i < 0 {
+		return ""
+	}
+	return partial[:i]
+}
+
+// bodyWidth is the column commit bodies are wrapped at
+const bodyWidth = 72
+
+// SplitCommitMessage splits a commit message into its subject line and body.
+// The body is everything after the first line, with or without the blank
+// separator line.
+func SplitCommitMessage(message string) (subject, body string) {
+	lines := strings.SplitN(strings.TrimSpace(message), "\n", 2)
+	subject = strings.TrimSpace(lines[0])
+	if len(lines) > 1 {
+		body = strings.TrimSpace(lines[1])
+	}
+	return subject, body
+}
+
+// wrapBody re-flows each paragraph of a commit body to width columns.
+// List items ("- " or "* ") start a new line and wrap with a hanging indent.
+func wrapBody(body string, width int) string {
+	paragraphs := strings.Split(body, "\n\n")
+	for i, paragraph := range paragraphs {
+		var items []string
+		for _, line := range strings.Split(paragraph, "\n") {
+			line = strings.TrimSpace(line)
+			if line == "" {
+				continue
+			}
+			if len(items) == 0 || strings.HasPrefix(line, "- ") || strings.HasPrefix(line, "* ") {
+				items = append(items, line)
+			} else {
+				items[len(items)-1] += " " + line
+			}
+		}
+
+		for j, item := range items {
+			indent := ""
+			if strings.HasPrefix(item, "- ") || strings.HasPrefix(item, "* ") {
+				indent = "  "
+			}
+			items[j] = wrapLine(item, width, indent)
+		}
+		paragraphs[i] = strings.Join(items, "\n")
+	}
+
+	return strings.Join(paragraphs, "\n\n")
+}
+
+// wrapLine breaks text at spaces so no line exceeds width, prefixing
+// continuation lines with indent. Words longer than width are kept whole.
+func wrapLine(text string, width int, indent string) string {
+	var b strings.Builder
+	lineLen := 0
+	for i, word := range strings.Fields(text) {
+		switch {
+		case i == 0:
+		case lineLen+1+len(word) > width:
+			b.WriteString("\n" + indent)
+			lineLen = len(indent)
+		default:
+			b.WriteString(" ")
+			lineLen++
+		}
+		b.WriteString(word)
+		lineLen += len(word)
+	}
+	return b.String()
+}
+
+// SplitPRDescription splits a generated pull request description into its
+// title line and markdown body
+func SplitPRDescription(response string) (title, body string) {
+	cleaned := StripReasoning(response)
+	cleaned = strings.TrimPrefix(cleaned, "```markdown")
+	cleaned = strings.TrimPrefix(cleaned, "```")
+	cleaned = strings.TrimSpace(strings.TrimSuffix(cleaned, "```"))
+
+	lines := strings.SplitN(cleaned, "\n", 2)
+	title = strings.TrimSpace(lines[0])
+	title = strings.TrimSpace(strings.TrimLeft(title, "#"))
+	for _, prefix := range []string{"Title:", "PR title:", "**Title:**"} {
+		title = strings.TrimSpace(strings.TrimPrefix(title, prefix))
+	}
+	title = strings.Trim(title, "`\"'*")
+
+	if len(lines) > 1 {
+		body = strings.TrimSpace(lines[1])
+	}
+
+	return title, body
 }
 
 // SanitizeBashCommand cleans up a generated bash command
@@ -322,7 +742,7 @@ func SanitizeBashCommand(command string) string {
 		"# ",
 	}
 
-	cleaned := strings.TrimSpace(command)
+	cleaned := StripReasoning(command)
 
 	// Remove prefixes
 	for _, prefix := range prefixes {
@@ -3,6 +3,8 @@ package prompt
 import (
 	"strings"
 	"testing"
+
+	"gh-smart-commit/pkg/git"
 )
 
 func TestNewBuilder(t *testing.T) {
@@ -11,8 +13,8 @@ func TestNewBuilder(t *testing.T) {
 		t.Fatal("NewBuilder returned nil")
 	}
 
-	if len(builder.templates) != 4 {
-		t.Errorf("Expected 4 templates, got %d", len(builder.templates))
+	if len(builder.templates) != 9 {
+		t.Errorf("Expected 9 templates, got %d", len(builder.templates))
 	}
 }
 
@@ -56,6 +58,150 @@ func TestBuildSmartCommit(t *testing.T) {
 	}
 }
 
+func TestBuildSmartCommitBody(t *testing.T) {
+	builder := NewBuilder()
+
+	system, _, err := builder.Build("smart-commit", Context{Repo: "test-repo"})
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+	if strings.Contains(system, "BODY:") {
+		t.Error("Subject-only prompt should not ask for a body")
+	}
+
+	system, _, err = builder.Build("smart-commit", Context{Repo: "test-repo", Body: true})
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+	if !strings.Contains(system, "BODY:") {
+		t.Error("Expected the prompt to ask for a body")
+	}
+}
+
+func TestBuildSmartCommitAssets(t *testing.T) {
+	diff := "diff --git a/assets/logo.png b/assets/logo.png\nnew file mode 100644\nindex 0000000..1234567\nBinary files /dev/null and b/assets/logo.png differ\n"
+
+	builder := NewBuilder()
+	_, user, err := builder.Build("smart-commit", Context{
+		Repo:   "test-repo",
+		Diff:   diff,
+		Assets: git.DescribeAssets(diff),
+	})
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+
+	if !strings.Contains(user, "- Added image assets/logo.png") {
+		t.Errorf("Expected asset note in prompt, got:\n%s", user)
+	}
+}
+
+func TestBuildSmartCommitChangedAreas(t *testing.T) {
+	builder := NewBuilder()
+	_, user, err := builder.Build("smart-commit", Context{
+		Repo:         "test-repo",
+		Diff:         "+change",
+		ChangedAreas: "cmd/ (3 files), pkg/git/ (1 file)",
+	})
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+
+	if !strings.Contains(user, "Changed areas: cmd/ (3 files), pkg/git/ (1 file)\n") {
+		t.Errorf("Expected changed areas in prompt, got:\n%s", user)
+	}
+
+	_, user, err = builder.Build("smart-commit", Context{Repo: "test-repo", Diff: "+change"})
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+	if strings.Contains(user, "Changed areas") {
+		t.Errorf("Expected no changed areas line without areas, got:\n%s", user)
+	}
+}
+
+func TestBuildSmartCommitExtraFiles(t *testing.T) {
+	builder := NewBuilder()
+	_, user, err := builder.Build("smart-commit", Context{
+		Repo: "test-repo",
+		Diff: "+\treturn parseConfig(path)",
+		ExtraFiles: []ContextFile{
+			{Path: "config/parse.go", Content: "func parseConfig(path string) error {", Truncated: true},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+
+	if !strings.Contains(user, "--- config/parse.go (first lines only)\nfunc parseConfig(path string) error {\n") {
+		t.Errorf("Expected the context file in prompt, got:\n%s", user)
+	}
+	if strings.Index(user, "config/parse.go") > strings.Index(user, "Diff:") {
+		t.Errorf("Expected context files before the diff, got:\n%s", user)
+	}
+}
+
+func TestBuildSmartCommitExamples(t *testing.T) {
+	builder := NewBuilder()
+	_, user, err := builder.Build("smart-commit", Context{
+		Repo:     "test-repo",
+		Diff:     "test diff",
+		Examples: []string{"Fix login redirect in AuthController", "Fix logout redirect"},
+	})
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+
+	if !strings.Contains(user, "Similar past commit messages") || !strings.Contains(user, "- Fix logout redirect\n") {
+		t.Errorf("Expected examples in prompt, got:\n%s", user)
+	}
+
+	_, user, _ = builder.Build("smart-commit", Context{Repo: "test-repo", Diff: "test diff"})
+	if strings.Contains(user, "Similar past commit messages") {
+		t.Errorf("Expected no examples section without examples, got:\n%s", user)
+	}
+}
+
+func TestBuildBranchDescribeCommitBodies(t *testing.T) {
+	builder := NewBuilder()
+	_, user, err := builder.Build("branch-describe", Context{
+		Repo:   "test-repo",
+		Branch: "feature/login",
+		Commits: []git.Commit{
+			{Message: "Fix login", Date: "2024-01-01", Body: "The session expired too early.\n\nCloses #12"},
+			{Message: "Add signup", Date: "2024-01-02"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+
+	want := "- Fix login (2024-01-01)\n  The session expired too early.\n\n  Closes #12\n- Add signup (2024-01-02)\n"
+	if !strings.Contains(user, want) {
+		t.Errorf("Expected commit bodies indented under their subjects, got:\n%s", user)
+	}
+}
+
+func TestBuildTagSuggestRenames(t *testing.T) {
+	builder := NewBuilder()
+	_, user, err := builder.Build("tag-suggest", Context{
+		Repo: "test-repo",
+		Commits: []git.Commit{
+			{Files: []string{"README.md", "docs/new.md"}, Renames: map[string]string{"docs/new.md": "docs/old.md"}},
+			{Files: []string{"main.go"}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+
+	for _, want := range []string{"- README.md\n", "- docs/new.md (renamed from docs/old.md)\n", "- main.go\n"} {
+		if !strings.Contains(user, want) {
+			t.Errorf("Expected %q in prompt, got:\n%s", want, user)
+		}
+	}
+}
+
 func TestBuildNonExistentTemplate(t *testing.T) {
 	builder := NewBuilder()
 	ctx := Context{
@@ -81,7 +227,9 @@ func TestAddTemplate(t *testing.T) {
 		User:   "Custom user prompt with {{.Repo}}",
 	}
 
-	builder.AddTemplate("custom", customTemplate)
+	if err := builder.AddTemplate("custom", customTemplate); err != nil {
+		t.Fatalf("AddTemplate failed: %v", err)
+	}
 
 	ctx := Context{
 		Repo: "test-repo",
@@ -101,6 +249,113 @@ func TestAddTemplate(t *testing.T) {
 	}
 }
 
+func TestAddTemplateInvalid(t *testing.T) {
+	builder := NewBuilder()
+
+	err := builder.AddTemplate("broken", Template{System: "ok", User: "{{.Repo"})
+	if err == nil {
+		t.Fatal("Expected error for malformed template")
+	}
+	if !strings.Contains(err.Error(), "user template") {
+		t.Errorf("Expected error to name the user template, got: %v", err)
+	}
+
+	for _, name := range builder.ListTemplates() {
+		if name == "broken" {
+			t.Error("Malformed template should not be registered")
+		}
+	}
+}
+
+func TestListTemplates(t *testing.T) {
+	builder := NewBuilder()
+	if err := builder.AddTemplate("aaa-custom", Template{System: "s", User: "u"}); err != nil {
+		t.Fatalf("AddTemplate failed: %v", err)
+	}
+
+	names := builder.ListTemplates()
+	if len(names) != 10 {
+		t.Fatalf("Expected 10 templates, got %d: %v", len(names), names)
+	}
+	if names[0] != "aaa-custom" {
+		t.Errorf("Expected sorted names starting with aaa-custom, got %v", names)
+	}
+}
+
+func TestBuildVars(t *testing.T) {
+	builder := NewBuilder()
+	if err := builder.AddTemplate("ticket", Template{System: "s", User: "Ticket: {{.Vars.ticket}}"}); err != nil {
+		t.Fatalf("AddTemplate failed: %v", err)
+	}
+
+	_, user, err := builder.Build("ticket", Context{Vars: map[string]string{"ticket": "PROJ-7"}})
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+	if user != "Ticket: PROJ-7" {
+		t.Errorf("Expected ticket in prompt, got %q", user)
+	}
+
+	_, user, err = builder.Build("smart-commit", Context{Vars: map[string]string{"ticket": "PROJ-7"}})
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+	if !strings.Contains(user, "- ticket: PROJ-7") {
+		t.Error("Expected smart-commit prompt to list the vars")
+	}
+
+	// Every built-in template a command passes --var to lists them
+	systemInfo := map[string]interface{}{
+		"OS": "linux", "Arch": "amd64", "WorkingDir": "/src", "Shell": "bash", "User": "ann",
+		"IsGitRepo": false, "Repo": "", "Branch": "", "FileTree": ".",
+	}
+	for _, name := range []string{"lint-suggestions", "branch-describe", "pr-describe", "changelog", "bash", "bash-explain"} {
+		_, user, err := builder.Build(name, Context{SystemInfo: systemInfo, Vars: map[string]string{"ticket": "PROJ-7"}})
+		if err != nil {
+			t.Fatalf("Build %s failed: %v", name, err)
+		}
+		if !strings.Contains(user, "Additional context:\n- ticket: PROJ-7") {
+			t.Errorf("Expected %s prompt to list the vars, got:\n%s", name, user)
+		}
+
+		_, user, _ = builder.Build(name, Context{SystemInfo: systemInfo})
+		if strings.Contains(user, "Additional context") {
+			t.Errorf("Expected no additional context in %s without vars", name)
+		}
+	}
+
+	_, _, err = builder.Build("ticket", Context{})
+	if err == nil || !strings.Contains(err.Error(), `no entry for key "ticket"`) {
+		t.Errorf("Expected missing key error, got %v", err)
+	}
+}
+
+func TestBuildSystemText(t *testing.T) {
+	builder := NewBuilder()
+	builder.SetSystemText("Our project uses American English spelling.", "  Never mention ticket numbers.\n")
+
+	for _, name := range []string{"smart-commit", "lint-suggestions", "branch-describe", "changelog"} {
+		system, _, err := builder.Build(name, Context{Repo: "test-repo", Diff: "+change"})
+		if err != nil {
+			t.Fatalf("Build(%s) failed: %v", name, err)
+		}
+
+		if !strings.HasPrefix(system, "Our project uses American English spelling.\n\n") {
+			t.Errorf("Expected %s system prompt to start with the prefix, got:\n%s", name, system)
+		}
+		if !strings.HasSuffix(system, "\n\nNever mention ticket numbers.") {
+			t.Errorf("Expected %s system prompt to end with the suffix, got:\n%s", name, system)
+		}
+	}
+
+	plain, _, _ := NewBuilder().Build("smart-commit", Context{Repo: "test-repo"})
+	builder.SetSystemText("", "")
+	system, _, _ := builder.Build("smart-commit", Context{Repo: "test-repo"})
+	if system != plain {
+		t.Errorf("Expected empty prefix and suffix to leave the system prompt unchanged")
+	}
+}
+
 func TestValidateCommitMessage(t *testing.T) {
 	tests := []struct {
 		message string
@@ -112,6 +367,10 @@ func TestValidateCommitMessage(t *testing.T) {
 		{"", true}, // empty message
 		{"this is a very long commit message that exceeds the 72 character limit for the first line", true}, // too long
 		{"missing colon in conventional format", true},                                                      // no colon
+		{"fix: handle nil\n\nThis body line may be longer than the subject limit without failing validation.", false},
+		{"fix: handle nil\nbody without separator", true},
+		{"✨ feat: " + strings.Repeat("a", 63), false}, // emoji counts as two columns
+		{"✨ feat: " + strings.Repeat("a", 64), true},
 	}
 
 	for _, tt := range tests {
@@ -122,6 +381,26 @@ func TestValidateCommitMessage(t *testing.T) {
 	}
 }
 
+func TestMessageMatchesFiles(t *testing.T) {
+	files := []string{"pkg/auth/token_store.go", "README.md"}
+
+	tests := []struct {
+		message string
+		want    bool
+	}{
+		{"fix(auth): guard refresh", true},
+		{"Fix race in TokenStore", true},
+		{"Update readme badges", true},
+		{"feat: add dark mode", false},
+	}
+
+	for _, tt := range tests {
+		if got := MessageMatchesFiles(tt.message, files); got != tt.want {
+			t.Errorf("MessageMatchesFiles(%q) = %v, expected %v", tt.message, got, tt.want)
+		}
+	}
+}
+
 func TestSanitizeCommitMessage(t *testing.T) {
 	tests := []struct {
 		input    string
@@ -133,6 +412,7 @@ func TestSanitizeCommitMessage(t *testing.T) {
 		{`"feat: add feature"`, "feat: add feature"},
 		{"`feat: add feature`", "feat: add feature"},
 		{"The commit message is: feat: add feature", "feat: add feature"},
+		{"<think>The diff adds a flag.</think>\nfeat: add feature", "feat: add feature"},
 	}
 
 	for _, tt := range tests {
@@ -142,3 +422,122 @@ func TestSanitizeCommitMessage(t *testing.T) {
 		}
 	}
 }
+
+func TestSanitizeCommitMessageBody(t *testing.T) {
+	input := "```\nFix token refresh race in AuthService\nConcurrent requests could each start a refresh and overwrite each other's tokens, so guard it.\n\n- Add a mutex around refresh so that only a single refresh runs at any time\n```"
+	expected := "Fix token refresh race in AuthService\n\n" +
+		"Concurrent requests could each start a refresh and overwrite each\n" +
+		"other's tokens, so guard it.\n\n" +
+		"- Add a mutex around refresh so that only a single refresh runs at any\n" +
+		"  time"
+
+	if result := SanitizeCommitMessage(input); result != expected {
+		t.Errorf("SanitizeCommitMessage() = %q, expected %q", result, expected)
+	}
+}
+
+func TestStripReasoning(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"feat: add feature", "feat: add feature"},
+		{"<think>\nreasoning\n</think>\n\nfix: bug", "fix: bug"},
+		{"<THINK>a</THINK>b<think>c</think>", "b"},
+		{"answer\n<think>cut off mid-thought", "answer"},
+	}
+
+	for _, tt := range tests {
+		if result := StripReasoning(tt.input); result != tt.expected {
+			t.Errorf("StripReasoning(%q) = %q, expected %q", tt.input, result, tt.expected)
+		}
+	}
+}
+
+func TestSplitPRDescription(t *testing.T) {
+	tests := []struct {
+		input string
+		title string
+		body  string
+	}{
+		{"Add changelog command\n\n## Summary\nDrafts notes.", "Add changelog command", "## Summary\nDrafts notes."},
+		{"Title: Add changelog command\n## Summary\nx", "Add changelog command", "## Summary\nx"},
+		{"```markdown\n# Add changelog command\n\n## Summary\nx\n```", "Add changelog command", "## Summary\nx"},
+		{"Add changelog command", "Add changelog command", ""},
+	}
+
+	for _, tt := range tests {
+		title, body := SplitPRDescription(tt.input)
+		if title != tt.title || body != tt.body {
+			t.Errorf("SplitPRDescription(%q) = (%q, %q), expected (%q, %q)", tt.input, title, body, tt.title, tt.body)
+		}
+	}
+}
+
+func TestBuildSmartCommitTypes(t *testing.T) {
+	builder := NewBuilder()
+
+	system, _, err := builder.Build("smart-commit", Context{Repo: "test-repo", Types: []string{"feat", "fix", "deps"}})
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+	if !strings.Contains(system, "one of: feat, fix, deps") {
+		t.Errorf("Expected the allowed types in the prompt, got:\n%s", system)
+	}
+	if strings.Contains(system, "NO conventional commit format") {
+		t.Error("Conventional prompt should not forbid conventional format")
+	}
+}
+
+func TestBuildSmartCommitGitmoji(t *testing.T) {
+	builder := NewBuilder()
+
+	system, _, err := builder.Build("smart-commit", Context{Repo: "test-repo", Gitmojis: Gitmojis})
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+	if !strings.Contains(system, "🐛 for a bug fix") {
+		t.Errorf("Expected the gitmoji list in the prompt, got:\n%s", system)
+	}
+	if strings.Contains(system, "Start with action verb (Add") {
+		t.Error("Gitmoji prompt should not ask for a leading verb first")
+	}
+}
+
+func TestBuildLintSuggestionsChecklists(t *testing.T) {
+	builder := NewBuilder()
+
+	system, _, err := builder.Build("lint-suggestions", Context{Checklists: ChecklistsFor([]string{"Go", "Markdown", "SQL"})})
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+	if !strings.Contains(system, "For Go changes, also check for:\n- Errors that are ignored") {
+		t.Errorf("Expected the Go checklist, got:\n%s", system)
+	}
+	if !strings.Contains(system, "For SQL changes") || strings.Contains(system, "Markdown") {
+		t.Errorf("Expected only languages with a checklist, got:\n%s", system)
+	}
+
+	plain, _, _ := builder.Build("lint-suggestions", Context{})
+	if strings.Contains(plain, "also check for") {
+		t.Error("Expected no checklist without languages")
+	}
+}
+
+func TestCompleteLines(t *testing.T) {
+	tests := []struct {
+		partial string
+		want    string
+	}{
+		{"Fix token refresh race\n\nConcurrent requests could", "Fix token refresh race\n"},
+		{"Fix token refresh race\n", "Fix token refresh race"},
+		{"Fix token ref", ""},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		if got := CompleteLines(tt.partial); got != tt.want {
+			t.Errorf("CompleteLines(%q) = %q, expected %q", tt.partial, got, tt.want)
+		}
+	}
+}
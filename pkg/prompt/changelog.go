@@ -0,0 +1,106 @@
+package prompt
+
+import (
+	"regexp"
+	"strings"
+
+	"gh-smart-commit/pkg/git"
+)
+
+// ChangelogGroup is a Keep a Changelog section and the commits that belong in it
+type ChangelogGroup struct {
+	Name    string
+	Commits []git.Commit
+}
+
+// changelogSections lists Keep a Changelog sections in display order
+var changelogSections = []string{"Added", "Changed", "Deprecated", "Removed", "Fixed", "Security"}
+
+// conventionalPattern matches a Conventional Commits subject such as "feat(ui)!: add x"
+var conventionalPattern = regexp.MustCompile(`^(\w+)(?:\([^)]*\))?!?:\s*`)
+
+// conventionalSections maps Conventional Commits types to changelog sections
+var conventionalSections = map[string]string{
+	"feat":      "Added",
+	"fix":       "Fixed",
+	"perf":      "Changed",
+	"refactor":  "Changed",
+	"revert":    "Changed",
+	"security":  "Security",
+	"deprecate": "Deprecated",
+}
+
+// skippedTypes are conventional types that rarely matter to release notes
+var skippedTypes = map[string]bool{
+	"chore": true,
+	"ci":    true,
+	"test":  true,
+	"style": true,
+	"build": true,
+	"docs":  true,
+}
+
+// GroupCommits sorts commits into changelog sections, using the Conventional
+// Commits type when present and keywords in the subject otherwise. Merge
+// commits and housekeeping types such as chore, ci and test are dropped.
+// Empty sections are omitted.
+func GroupCommits(commits []git.Commit) []ChangelogGroup {
+	bySection := make(map[string][]git.Commit)
+
+	for _, commit := range commits {
+		if strings.HasPrefix(commit.Message, "Merge ") {
+			continue
+		}
+
+		section := ""
+		if m := conventionalPattern.FindStringSubmatch(commit.Message); m != nil {
+			commitType := strings.ToLower(m[1])
+			if skippedTypes[commitType] {
+				continue
+			}
+			section = conventionalSections[commitType]
+		}
+		if section == "" {
+			section = sectionFromKeywords(commit.Message)
+		}
+
+		bySection[section] = append(bySection[section], commit)
+	}
+
+	var groups []ChangelogGroup
+	for _, name := range changelogSections {
+		if len(bySection[name]) > 0 {
+			groups = append(groups, ChangelogGroup{Name: name, Commits: bySection[name]})
+		}
+	}
+
+	return groups
+}
+
+// sectionFromKeywords guesses a changelog section from a commit subject
+func sectionFromKeywords(message string) string {
+	subject := strings.ToLower(conventionalPattern.ReplaceAllString(message, ""))
+
+	switch {
+	case strings.Contains(subject, "security") || strings.Contains(subject, "vulnerab") || strings.Contains(subject, "cve-"):
+		return "Security"
+	case strings.Contains(subject, "deprecat"):
+		return "Deprecated"
+	}
+
+	words := strings.Fields(subject)
+	if len(words) == 0 {
+		return "Changed"
+	}
+
+	switch words[0] {
+	case "add", "adds", "added", "implement", "implements", "introduce", "introduces", "support", "create":
+		return "Added"
+	case "fix", "fixes", "fixed", "resolve", "resolves", "correct", "prevent", "handle":
+		return "Fixed"
+	case "remove", "removes", "removed", "delete", "deletes", "drop", "drops":
+		return "Removed"
+	default:
+		return "Changed"
+	}
+}
@@ -0,0 +1,74 @@
+package prompt
+
+import (
+	"strings"
+	"testing"
+
+	"gh-smart-commit/pkg/git"
+)
+
+func TestGroupCommits(t *testing.T) {
+	commits := []git.Commit{
+		{Message: "feat(ui): add dark mode"},
+		{Message: "fix: handle empty diff"},
+		{Message: "chore: bump deps"},
+		{Message: "Merge branch 'main' into feature"},
+		{Message: "Remove legacy config loader"},
+		{Message: "Update README"},
+		{Message: "refactor!: split client package"},
+		{Message: "Patch CVE-2024-1234 in parser"},
+	}
+
+	groups := GroupCommits(commits)
+
+	got := make(map[string][]string)
+	var order []string
+	for _, g := range groups {
+		order = append(order, g.Name)
+		for _, c := range g.Commits {
+			got[g.Name] = append(got[g.Name], c.Message)
+		}
+	}
+
+	if strings.Join(order, ",") != "Added,Changed,Removed,Fixed,Security" {
+		t.Errorf("Unexpected section order: %v", order)
+	}
+	if len(got["Added"]) != 1 || got["Added"][0] != "feat(ui): add dark mode" {
+		t.Errorf("Unexpected Added section: %v", got["Added"])
+	}
+	if len(got["Changed"]) != 2 {
+		t.Errorf("Expected 2 Changed commits, got %v", got["Changed"])
+	}
+	if len(got["Removed"]) != 1 {
+		t.Errorf("Expected heuristic Removed commit, got %v", got["Removed"])
+	}
+	for _, messages := range got {
+		for _, m := range messages {
+			if strings.HasPrefix(m, "chore") || strings.HasPrefix(m, "Merge") {
+				t.Errorf("Expected %q to be skipped", m)
+			}
+		}
+	}
+}
+
+func TestBuildChangelog(t *testing.T) {
+	builder := NewBuilder()
+	ctx := Context{
+		Repo:    "repo",
+		Release: "v1.2.0",
+		Groups: []ChangelogGroup{
+			{Name: "Fixed", Commits: []git.Commit{{Message: "fix: handle empty diff"}}},
+		},
+	}
+
+	system, user, err := builder.Build("changelog", ctx)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !strings.Contains(system, "## [v1.2.0]") {
+		t.Errorf("Expected release heading in system prompt, got %s", system)
+	}
+	if !strings.Contains(user, "Fixed:\n- fix: handle empty diff") {
+		t.Errorf("Expected grouped commits in user prompt, got %s", user)
+	}
+}
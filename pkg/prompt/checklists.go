@@ -0,0 +1,76 @@
+package prompt
+
+// Checklist is a list of review points specific to one language
+type Checklist struct {
+	Language string
+	Items    []string
+}
+
+// ReviewChecklists holds the language-specific review points for
+// lint-suggestions, keyed by the language names git.DetectLanguages returns
+var ReviewChecklists = map[string][]string{
+	"Go": {
+		"Errors that are ignored, or returned without context",
+		"Goroutines that can leak because nothing stops or waits for them",
+		"Data races on state shared between goroutines",
+		"Deferred calls inside loops and unclosed response bodies or files",
+		"Contexts that are not passed down to blocking calls",
+	},
+	"Python": {
+		"Bare or overly broad except clauses",
+		"Mutable default arguments",
+		"Files and connections opened without a context manager",
+		"Missing or incorrect type hints on public functions",
+	},
+	"JavaScript": {
+		"Promises that are not awaited or have no error handling",
+		"Loose equality (==) where strict equality is meant",
+		"User input inserted into HTML without escaping",
+		"Event listeners and timers that are never removed",
+	},
+	"TypeScript": {
+		"Uses of any or non-null assertions that hide real type errors",
+		"Promises that are not awaited or have no error handling",
+		"Type assertions on data from outside the program without validation",
+		"Event listeners and timers that are never removed",
+	},
+	"Rust": {
+		"unwrap or expect on values that can fail at runtime",
+		"unsafe blocks without a comment stating the invariant",
+		"Needless clones and allocations in hot paths",
+	},
+	"Java": {
+		"Resources not closed with try-with-resources",
+		"Swallowed exceptions and overly broad catch blocks",
+		"Shared mutable state without synchronization",
+	},
+	"Shell": {
+		"Unquoted variables that break on spaces or globs",
+		"Missing set -euo pipefail or unchecked exit codes",
+		"Commands built from user input (injection)",
+	},
+	"SQL": {
+		"Queries built by string concatenation (SQL injection)",
+		"Filters and joins on columns without an index",
+		"Migrations that lock large tables or cannot be rolled back",
+		"SELECT * and unbounded queries without LIMIT",
+	},
+	"Dockerfile": {
+		"Images not pinned to a version or digest",
+		"Running as root without a USER instruction",
+		"Layers that leave package caches or secrets in the image",
+		"COPY ordering that defeats the build cache",
+	},
+}
+
+// ChecklistsFor returns the checklists for languages, in order, skipping
+// languages without one
+func ChecklistsFor(languages []string) []Checklist {
+	var checklists []Checklist
+	for _, language := range languages {
+		if items, ok := ReviewChecklists[language]; ok {
+			checklists = append(checklists, Checklist{Language: language, Items: items})
+		}
+	}
+	return checklists
+}
@@ -0,0 +1,133 @@
+package prompt
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Gitmoji is the emoji that marks one type of change
+type Gitmoji struct {
+	Type    string
+	Emoji   string
+	Meaning string
+}
+
+// Gitmojis maps conventional commit types to their canonical gitmoji
+var Gitmojis = []Gitmoji{
+	{"feat", "✨", "a new feature"},
+	{"fix", "🐛", "a bug fix"},
+	{"docs", "📝", "documentation"},
+	{"style", "🎨", "code structure or formatting"},
+	{"refactor", "♻️", "refactoring"},
+	{"perf", "⚡️", "a performance improvement"},
+	{"test", "✅", "adding or updating tests"},
+	{"build", "📦️", "build system or dependencies"},
+	{"ci", "👷", "CI configuration"},
+	{"chore", "🔧", "configuration and maintenance"},
+	{"revert", "⏪️", "reverting a change"},
+}
+
+// gitmojiVerbs are leading verbs that only fit one type. Broad verbs like
+// "Add" or "Update" are left out so a deliberate emoji isn't overridden.
+var gitmojiVerbs = map[string]string{
+	"fix":      "fix",
+	"document": "docs",
+	"refactor": "refactor",
+	"revert":   "revert",
+}
+
+// GitmojiFor returns the canonical gitmoji for a commit type, or "" when the
+// type has none
+func GitmojiFor(commitType string) string {
+	for _, g := range Gitmojis {
+		if strings.EqualFold(g.Type, commitType) {
+			return g.Emoji
+		}
+	}
+	return ""
+}
+
+// NormalizeGitmoji makes the subject start with the canonical gitmoji for
+// its type. A conventional prefix such as "fix(auth):" is replaced by its
+// emoji, and an emoji that contradicts an unambiguous verb (✨ on "Fix ...")
+// is corrected. Other messages are returned unchanged.
+func NormalizeGitmoji(message string) string {
+	subject, body := SplitCommitMessage(message)
+	emoji, rest := splitLeadingEmoji(subject)
+
+	commitType := ""
+	if match := conventionalPattern.FindStringSubmatch(rest); match != nil && GitmojiFor(match[1]) != "" {
+		commitType = match[1]
+		rest = capitalize(rest[len(match[0]):])
+	} else {
+		commitType = gitmojiVerbs[strings.ToLower(FirstVerb(rest))]
+	}
+
+	if canonical := GitmojiFor(commitType); canonical != "" {
+		emoji = canonical
+	}
+	if emoji == "" {
+		return message
+	}
+
+	subject = emoji + " " + rest
+	if body == "" {
+		return subject
+	}
+	return subject + "\n\n" + body
+}
+
+// ValidateGitmoji checks that message's subject starts with a known gitmoji
+func ValidateGitmoji(message string) error {
+	subject, _ := SplitCommitMessage(message)
+	emoji, _ := splitLeadingEmoji(subject)
+	for _, g := range Gitmojis {
+		if emoji != "" && stripVariation(emoji) == stripVariation(g.Emoji) {
+			return nil
+		}
+	}
+	return fmt.Errorf("commit message should start with a gitmoji such as ✨ or 🐛")
+}
+
+// splitLeadingEmoji splits a subject into its leading emoji, if any, and
+// the rest of the text
+func splitLeadingEmoji(subject string) (emoji, rest string) {
+	fields := strings.SplitN(subject, " ", 2)
+	if !isEmoji(fields[0]) {
+		return "", subject
+	}
+	if len(fields) > 1 {
+		rest = strings.TrimSpace(fields[1])
+	}
+	return fields[0], rest
+}
+
+// isEmoji reports whether token is made of symbols only, such as "♻️"
+func isEmoji(token string) bool {
+	if token == "" {
+		return false
+	}
+	for _, r := range token {
+		if r < unicode.MaxASCII || unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// stripVariation drops the emoji presentation selector, which models
+// include or leave out at random
+func stripVariation(emoji string) string {
+	return strings.ReplaceAll(emoji, "\uFE0F", "")
+}
+
+// capitalize upper-cases the first letter of s
+func capitalize(s string) string {
+	r, size := utf8.DecodeRuneInString(s)
+	if r == utf8.RuneError {
+		return s
+	}
+	return string(unicode.ToUpper(r)) + s[size:]
+}
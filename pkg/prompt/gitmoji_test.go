@@ -0,0 +1,44 @@
+package prompt
+
+import "testing"
+
+func TestNormalizeGitmoji(t *testing.T) {
+	tests := []struct {
+		message string
+		want    string
+	}{
+		{"✨ Add OAuth2 login", "✨ Add OAuth2 login"},
+		{"feat(auth): add OAuth2 login", "✨ Add OAuth2 login"},
+		{"🐛 docs: update README", "📝 Update README"},
+		{"✨ Fix nil pointer in parser", "🐛 Fix nil pointer in parser"},
+		{"🚀 Refactor cache layer\n\nSplit the store.", "♻️ Refactor cache layer\n\nSplit the store."},
+		{"Fix nil pointer in parser", "🐛 Fix nil pointer in parser"},
+		{"Add OAuth2 login", "Add OAuth2 login"},
+		{"🐛 Add tests for parser", "🐛 Add tests for parser"},
+	}
+
+	for _, tt := range tests {
+		if got := NormalizeGitmoji(tt.message); got != tt.want {
+			t.Errorf("NormalizeGitmoji(%q) = %q, expected %q", tt.message, got, tt.want)
+		}
+	}
+}
+
+func TestValidateGitmoji(t *testing.T) {
+	tests := []struct {
+		message string
+		wantErr bool
+	}{
+		{"✨ Add OAuth2 login", false},
+		{"♻ Refactor cache layer", false}, // without the variation selector
+		{"🚀 Deploy", true},
+		{"Add OAuth2 login", true},
+	}
+
+	for _, tt := range tests {
+		err := ValidateGitmoji(tt.message)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ValidateGitmoji(%q) error = %v, wantErr %v", tt.message, err, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package prompt
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// StructuredValidator is implemented by structured response schemas
+type StructuredValidator interface {
+	Validate() error
+}
+
+// SchemaError describes a structured response that does not match its schema
+type SchemaError struct {
+	Field  string
+	Reason string
+}
+
+// Error implements the error interface
+func (e *SchemaError) Error() string {
+	if e.Field == "" {
+		return fmt.Sprintf("schema mismatch: %s", e.Reason)
+	}
+	return fmt.Sprintf("schema mismatch at %s: %s", e.Field, e.Reason)
+}
+
+// StructuredSuggestion is a single suggestion in a structured lint response
+type StructuredSuggestion struct {
+	Severity    string `json:"severity"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	File        string `json:"file,omitempty"`
+	Line        int    `json:"line,omitempty"`
+}
+
+// SuggestionsSchema is the expected structured lint-suggestions response
+type SuggestionsSchema struct {
+	Suggestions []StructuredSuggestion `json:"suggestions"`
+}
+
+// Validate checks that every suggestion has a known severity and a title
+func (s *SuggestionsSchema) Validate() error {
+	if s.Suggestions == nil {
+		return &SchemaError{Field: "suggestions", Reason: "field is required"}
+	}
+
+	for i, suggestion := range s.Suggestions {
+		field := fmt.Sprintf("suggestions[%d]", i)
+		switch strings.ToUpper(strings.TrimSpace(suggestion.Severity)) {
+		case "HIGH", "MEDIUM", "LOW":
+		default:
+			return &SchemaError{Field: field + ".severity", Reason: fmt.Sprintf("unknown severity %q", suggestion.Severity)}
+		}
+		if strings.TrimSpace(suggestion.Title) == "" {
+			return &SchemaError{Field: field + ".title", Reason: "field is required"}
+		}
+	}
+
+	return nil
+}
+
+// TagsSchema is the expected structured tag-suggest response
+type TagsSchema struct {
+	Tags []string `json:"tags"`
+}
+
+// Validate checks that at least one non-empty tag was returned
+func (s *TagsSchema) Validate() error {
+	if len(s.Tags) == 0 {
+		return &SchemaError{Field: "tags", Reason: "at least one tag is required"}
+	}
+
+	for i, tag := range s.Tags {
+		if strings.TrimSpace(tag) == "" {
+			return &SchemaError{Field: fmt.Sprintf("tags[%d]", i), Reason: "tag is empty"}
+		}
+	}
+
+	return nil
+}
+
+// CommandSchema is the expected structured bash --explain response
+type CommandSchema struct {
+	Command     string `json:"command"`
+	Explanation string `json:"explanation"`
+}
+
+// Validate checks that a command was returned
+func (s *CommandSchema) Validate() error {
+	if strings.TrimSpace(s.Command) == "" {
+		return &SchemaError{Field: "command", Reason: "field is required"}
+	}
+	return nil
+}
+
+// SuggestionsJSONInstruction is appended to the lint system prompt in structured mode
+const SuggestionsJSONInstruction = `Respond ONLY with a JSON object of the form:
+{"suggestions": [{"severity": "HIGH|MEDIUM|LOW", "title": "short title", "description": "specific recommendation", "file": "path/to/file.go", "line": 42}]}
+Omit "file" and "line" when a suggestion does not apply to a specific location.`
+
+// StrictJSONInstruction builds the corrective instruction used when retrying
+// after a structured response failed validation
+func StrictJSONInstruction(schemaInstruction string, cause error) string {
+	return fmt.Sprintf(`Your previous response was rejected: %s.
+Return ONLY valid JSON, with no markdown, comments or extra keys.
+%s`, cause.Error(), schemaInstruction)
+}
+
+// DecodeStructured decodes a structured model response into target and validates it
+func DecodeStructured(raw string, target StructuredValidator) error {
+	cleaned := strings.TrimSpace(raw)
+	cleaned = strings.TrimPrefix(cleaned, "```json")
+	cleaned = strings.TrimPrefix(cleaned, "```")
+	cleaned = strings.TrimSuffix(cleaned, "```")
+	cleaned = strings.TrimSpace(cleaned)
+
+	if cleaned == "" {
+		return &SchemaError{Reason: "response is empty"}
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader([]byte(cleaned)))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(target); err != nil {
+		return &SchemaError{Reason: err.Error()}
+	}
+
+	return target.Validate()
+}
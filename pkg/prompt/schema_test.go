@@ -0,0 +1,81 @@
+package prompt
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestDecodeStructuredSuggestions(t *testing.T) {
+	raw := "```json\n{\"suggestions\":[{\"severity\":\"high\",\"title\":\"Check error\",\"description\":\"Handle the returned error\"}]}\n```"
+
+	var schema SuggestionsSchema
+	if err := DecodeStructured(raw, &schema); err != nil {
+		t.Fatalf("DecodeStructured failed: %v", err)
+	}
+
+	if len(schema.Suggestions) != 1 {
+		t.Fatalf("Expected 1 suggestion, got %d", len(schema.Suggestions))
+	}
+
+	if schema.Suggestions[0].Title != "Check error" {
+		t.Errorf("Expected title 'Check error', got '%s'", schema.Suggestions[0].Title)
+	}
+}
+
+func TestDecodeStructuredMismatch(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  string
+	}{
+		{"malformed", `{"suggestions": [`},
+		{"empty", ""},
+		{"unknown field", `{"suggestions": [], "extra": true}`},
+		{"missing field", `{"items": []}`},
+		{"bad severity", `{"suggestions": [{"severity": "URGENT", "title": "x"}]}`},
+		{"missing title", `{"suggestions": [{"severity": "LOW", "title": ""}]}`},
+	}
+
+	for _, tt := range tests {
+		var schema SuggestionsSchema
+		err := DecodeStructured(tt.raw, &schema)
+		if err == nil {
+			t.Errorf("%s: expected schema error, got nil", tt.name)
+			continue
+		}
+
+		var schemaErr *SchemaError
+		if !errors.As(err, &schemaErr) {
+			t.Errorf("%s: expected *SchemaError, got %T", tt.name, err)
+		}
+	}
+}
+
+func TestDecodeStructuredTags(t *testing.T) {
+	var schema TagsSchema
+	if err := DecodeStructured(`{"tags": ["api", "auth"]}`, &schema); err != nil {
+		t.Fatalf("DecodeStructured failed: %v", err)
+	}
+
+	if len(schema.Tags) != 2 {
+		t.Errorf("Expected 2 tags, got %d", len(schema.Tags))
+	}
+
+	if err := DecodeStructured(`{"tags": []}`, &TagsSchema{}); err == nil {
+		t.Error("Expected error for empty tag list")
+	}
+}
+
+func TestDecodeStructuredCommand(t *testing.T) {
+	var schema CommandSchema
+	raw := `{"command": "du -sh * | sort -h", "explanation": "Sizes each entry and sorts them smallest first."}`
+	if err := DecodeStructured(raw, &schema); err != nil {
+		t.Fatalf("DecodeStructured failed: %v", err)
+	}
+	if schema.Command != "du -sh * | sort -h" || schema.Explanation == "" {
+		t.Errorf("Unexpected command schema %+v", schema)
+	}
+
+	if err := DecodeStructured(`{"command": " ", "explanation": "x"}`, &CommandSchema{}); err == nil {
+		t.Error("Expected error for an empty command")
+	}
+}
@@ -0,0 +1,90 @@
+package prompt
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// coAuthorPattern matches "Name <email>"
+var coAuthorPattern = regexp.MustCompile(`^[^<>]+\s<[^<>\s]+@[^<>\s]+>$`)
+
+// trailerPattern matches a "Key: value" git trailer line
+var trailerPattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9-]*: .+$`)
+
+// ParseCoAuthor validates and normalizes a "Name <email>" co-author
+func ParseCoAuthor(coauthor string) (string, error) {
+	coauthor = strings.Join(strings.Fields(coauthor), " ")
+	if !coAuthorPattern.MatchString(coauthor) {
+		return "", fmt.Errorf("invalid co-author %q, expected \"Name <email>\"", coauthor)
+	}
+	return coauthor, nil
+}
+
+// AppendCoAuthors appends a Co-authored-by trailer for each co-author that
+// the message doesn't already credit. Trailers are separated from the
+// subject and body by a blank line, or join an existing trailer block.
+func AppendCoAuthors(message string, coauthors []string) (string, error) {
+	message = strings.TrimRight(message, "\n ")
+
+	var trailers []string
+	seen := make(map[string]bool)
+	for _, coauthor := range coauthors {
+		normalized, err := ParseCoAuthor(coauthor)
+		if err != nil {
+			return message, err
+		}
+
+		trailer := "Co-authored-by: " + normalized
+		key := strings.ToLower(trailer)
+		if seen[key] || strings.Contains(strings.ToLower(message), key) {
+			continue
+		}
+		seen[key] = true
+		trailers = append(trailers, trailer)
+	}
+
+	if len(trailers) == 0 {
+		return message, nil
+	}
+
+	separator := "\n\n"
+	if endsWithTrailers(message) {
+		separator = "\n"
+	}
+
+	return message + separator + strings.Join(trailers, "\n"), nil
+}
+
+// AppendDiffStat appends a git --stat summary as the last paragraph of the
+// body, ahead of any trailers the message already ends with. The stat lines
+// are kept whole: wrapping them would break their columns.
+func AppendDiffStat(message, stat string) string {
+	message = strings.TrimRight(message, "\n ")
+	stat = strings.TrimRight(stat, "\n ")
+	if stat == "" {
+		return message
+	}
+
+	if endsWithTrailers(message) {
+		i := strings.LastIndex(message, "\n\n")
+		return message[:i] + "\n\n" + stat + message[i:]
+	}
+	return message + "\n\n" + stat
+}
+
+// endsWithTrailers reports whether the last paragraph of a multi-paragraph
+// message consists only of trailer lines
+func endsWithTrailers(message string) bool {
+	paragraphs := strings.Split(message, "\n\n")
+	if len(paragraphs) < 2 {
+		return false
+	}
+
+	for _, line := range strings.Split(paragraphs[len(paragraphs)-1], "\n") {
+		if !trailerPattern.MatchString(strings.TrimSpace(line)) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,90 @@
+package prompt
+
+import "testing"
+
+func TestAppendCoAuthors(t *testing.T) {
+	tests := []struct {
+		message   string
+		coauthors []string
+		expected  string
+	}{
+		{"feat: add x", nil, "feat: add x"},
+		{
+			"feat: add x",
+			[]string{"Ann Lee <ann@example.com>"},
+			"feat: add x\n\nCo-authored-by: Ann Lee <ann@example.com>",
+		},
+		{
+			"fix: y\n\nExplain why.\n",
+			[]string{"Ann <ann@example.com>", "Bob  <bob@example.com>", "ann <ANN@example.com>"},
+			"fix: y\n\nExplain why.\n\nCo-authored-by: Ann <ann@example.com>\nCo-authored-by: Bob <bob@example.com>",
+		},
+		{
+			"fix: y\n\nCo-authored-by: Ann <ann@example.com>",
+			[]string{"Ann <ann@example.com>", "Bob <bob@example.com>"},
+			"fix: y\n\nCo-authored-by: Ann <ann@example.com>\nCo-authored-by: Bob <bob@example.com>",
+		},
+	}
+
+	for _, tt := range tests {
+		result, err := AppendCoAuthors(tt.message, tt.coauthors)
+		if err != nil {
+			t.Errorf("AppendCoAuthors(%q) unexpected error: %v", tt.message, err)
+			continue
+		}
+		if result != tt.expected {
+			t.Errorf("AppendCoAuthors(%q, %v) = %q, expected %q", tt.message, tt.coauthors, result, tt.expected)
+		}
+	}
+}
+
+func TestAppendCoAuthorsInvalid(t *testing.T) {
+	for _, coauthor := range []string{"Ann", "ann@example.com", "<ann@example.com>", "Ann <ann>"} {
+		if _, err := AppendCoAuthors("feat: x", []string{coauthor}); err == nil {
+			t.Errorf("Expected error for co-author %q", coauthor)
+		}
+	}
+}
+
+func TestAppendDiffStat(t *testing.T) {
+	stat := " cmd/root.go | 4 ++--\n 1 file changed, 2 insertions(+), 2 deletions(-)\n"
+
+	tests := []struct {
+		message  string
+		stat     string
+		expected string
+	}{
+		{"fix: y", "", "fix: y"},
+		{
+			"fix: y\n",
+			stat,
+			"fix: y\n\n cmd/root.go | 4 ++--\n 1 file changed, 2 insertions(+), 2 deletions(-)",
+		},
+		{
+			"fix: y\n\nExplain why.",
+			stat,
+			"fix: y\n\nExplain why.\n\n cmd/root.go | 4 ++--\n 1 file changed, 2 insertions(+), 2 deletions(-)",
+		},
+		{
+			"fix: y\n\nExplain why.\n\nSigned-off-by: Ann <ann@example.com>",
+			stat,
+			"fix: y\n\nExplain why.\n\n cmd/root.go | 4 ++--\n 1 file changed, 2 insertions(+), 2 deletions(-)\n\nSigned-off-by: Ann <ann@example.com>",
+		},
+	}
+
+	for _, tt := range tests {
+		if result := AppendDiffStat(tt.message, tt.stat); result != tt.expected {
+			t.Errorf("AppendDiffStat(%q) = %q, expected %q", tt.message, result, tt.expected)
+		}
+	}
+}
+
+func TestValidateCommitMessageIgnoresTrailers(t *testing.T) {
+	message, err := AppendCoAuthors("feat: add x", []string{"A Very Long Name Indeed For Testing Purposes <someone.with.a.long.address@example.com>"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := ValidateCommitMessage(message); err != nil {
+		t.Errorf("Trailers should not affect validation: %v", err)
+	}
+}
@@ -0,0 +1,58 @@
+package prompt
+
+import (
+	"regexp"
+	"strings"
+)
+
+// DefaultPrefixFormat is prepended to the subject when TransformConfig has no
+// PrefixFormat
+const DefaultPrefixFormat = "{ticket} "
+
+// TransformConfig controls how a generated message is rewritten before it is
+// shown
+type TransformConfig struct {
+	// TicketPattern finds a ticket such as PROJ-123 in the branch name. The
+	// first capture group is the ticket when the pattern has one, otherwise
+	// the whole match is.
+	TicketPattern *regexp.Regexp
+	// PrefixFormat is prepended to the subject with {ticket} replaced
+	PrefixFormat string
+}
+
+// TransformMessage prepends the ticket found in branch to the subject line.
+// The message is returned unchanged when there is no pattern, the branch
+// doesn't match, or the subject already mentions the ticket.
+func TransformMessage(msg, branch string, cfg TransformConfig) string {
+	if cfg.TicketPattern == nil || branch == "" {
+		return msg
+	}
+
+	match := cfg.TicketPattern.FindStringSubmatch(branch)
+	if match == nil {
+		return msg
+	}
+	ticket := match[0]
+	if len(match) > 1 && match[1] != "" {
+		ticket = match[1]
+	}
+	if ticket == "" {
+		return msg
+	}
+
+	subject, body := SplitCommitMessage(msg)
+	if strings.Contains(subject, ticket) {
+		return msg
+	}
+
+	format := cfg.PrefixFormat
+	if format == "" {
+		format = DefaultPrefixFormat
+	}
+	subject = strings.ReplaceAll(format, "{ticket}", ticket) + subject
+
+	if body == "" {
+		return subject
+	}
+	return subject + "\n\n" + body
+}
@@ -0,0 +1,68 @@
+package prompt
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestTransformMessage(t *testing.T) {
+	ticket := regexp.MustCompile(`[A-Z][A-Z0-9]+-\d+`)
+	grouped := regexp.MustCompile(`^feature/(\d+)-`)
+
+	tests := []struct {
+		name   string
+		msg    string
+		branch string
+		cfg    TransformConfig
+		want   string
+	}{
+		{
+			name:   "default format",
+			msg:    "Fix login redirect",
+			branch: "feature/PROJ-123-login",
+			cfg:    TransformConfig{TicketPattern: ticket},
+			want:   "PROJ-123 Fix login redirect",
+		},
+		{
+			name:   "custom format keeps body",
+			msg:    "Fix login redirect\n\nThe session cookie was dropped.",
+			branch: "PROJ-7",
+			cfg:    TransformConfig{TicketPattern: ticket, PrefixFormat: "[{ticket}] "},
+			want:   "[PROJ-7] Fix login redirect\n\nThe session cookie was dropped.",
+		},
+		{
+			name:   "capture group",
+			msg:    "Add signup form",
+			branch: "feature/42-signup",
+			cfg:    TransformConfig{TicketPattern: grouped, PrefixFormat: "#{ticket}: "},
+			want:   "#42: Add signup form",
+		},
+		{
+			name:   "no match",
+			msg:    "Fix login redirect",
+			branch: "main",
+			cfg:    TransformConfig{TicketPattern: ticket},
+			want:   "Fix login redirect",
+		},
+		{
+			name:   "already mentioned",
+			msg:    "Fix PROJ-123 login redirect",
+			branch: "PROJ-123-login",
+			cfg:    TransformConfig{TicketPattern: ticket},
+			want:   "Fix PROJ-123 login redirect",
+		},
+		{
+			name:   "no pattern",
+			msg:    "Fix login redirect",
+			branch: "PROJ-123",
+			cfg:    TransformConfig{},
+			want:   "Fix login redirect",
+		},
+	}
+
+	for _, tt := range tests {
+		if got := TransformMessage(tt.msg, tt.branch, tt.cfg); got != tt.want {
+			t.Errorf("%s: expected %q, got %q", tt.name, tt.want, got)
+		}
+	}
+}
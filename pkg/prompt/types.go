@@ -0,0 +1,59 @@
+package prompt
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DefaultCommitTypes are the conventional commit types allowed when
+// commit.types is not configured
+var DefaultCommitTypes = []string{"feat", "fix", "docs", "style", "refactor", "perf", "test", "build", "ci", "chore", "revert"}
+
+// CommitType returns the conventional commit type of message's subject,
+// e.g. "feat" for "feat(auth)!: drop v1 tokens", or "" when there is none
+func CommitType(message string) string {
+	subject := strings.TrimSpace(strings.SplitN(message, "\n", 2)[0])
+	match := conventionalPattern.FindStringSubmatch(subject)
+	if match == nil {
+		return ""
+	}
+	return match[1]
+}
+
+// ValidateCommitType checks that message starts with one of the allowed
+// conventional commit types. Types are compared case-insensitively
+func ValidateCommitType(message string, types []string) error {
+	commitType := CommitType(message)
+	if commitType == "" {
+		return fmt.Errorf("commit message should start with a type, one of: %s", strings.Join(types, ", "))
+	}
+
+	for _, allowed := range types {
+		if strings.EqualFold(commitType, allowed) {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown commit type %q, expected one of: %s", commitType, strings.Join(types, ", "))
+}
+
+// FirstVerb returns the first word of message's subject without trailing
+// punctuation, e.g. "Fix" for "Fix: handle nil tokens"
+func FirstVerb(message string) string {
+	fields := strings.Fields(strings.SplitN(message, "\n", 2)[0])
+	if len(fields) == 0 {
+		return ""
+	}
+	return strings.TrimRight(fields[0], ":,.!")
+}
+
+// ValidateVerb checks that message starts with one of the allowed verbs.
+// Verbs are compared case-insensitively
+func ValidateVerb(message string, verbs []string) error {
+	verb := FirstVerb(message)
+	for _, allowed := range verbs {
+		if strings.EqualFold(verb, allowed) {
+			return nil
+		}
+	}
+	return fmt.Errorf("commit message starts with %q, expected one of: %s", verb, strings.Join(verbs, ", "))
+}
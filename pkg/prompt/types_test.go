@@ -0,0 +1,82 @@
+package prompt
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCommitType(t *testing.T) {
+	tests := []struct {
+		message  string
+		expected string
+	}{
+		{"feat: add flag", "feat"},
+		{"fix(api)!: drop v1 tokens", "fix"},
+		{"deps(go): bump cobra\n\nbody: with colon", "deps"},
+		{"Add OAuth2 integration to AuthService", ""},
+	}
+
+	for _, tt := range tests {
+		if got := CommitType(tt.message); got != tt.expected {
+			t.Errorf("CommitType(%q) = %q, expected %q", tt.message, got, tt.expected)
+		}
+	}
+}
+
+func TestValidateCommitTypeCustom(t *testing.T) {
+	types := append(append([]string{}, DefaultCommitTypes...), "deps", "i18n")
+
+	for _, message := range []string{"deps: bump cobra to v1.8", "i18n(de): translate settings", "Perf: cache tree"} {
+		if err := ValidateCommitType(message, types); err != nil {
+			t.Errorf("ValidateCommitType(%q) returned %v, expected nil", message, err)
+		}
+	}
+}
+
+func TestValidateCommitTypeRejects(t *testing.T) {
+	tests := []struct {
+		message string
+		want    string
+	}{
+		{"wip: half done", `unknown commit type "wip"`},
+		{"deps: bump cobra", `unknown commit type "deps"`},
+		{"Update README", "should start with a type"},
+	}
+
+	for _, tt := range tests {
+		err := ValidateCommitType(tt.message, DefaultCommitTypes)
+		if err == nil {
+			t.Errorf("ValidateCommitType(%q) returned nil, expected an error", tt.message)
+			continue
+		}
+		if !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("Expected error containing %q, got %v", tt.want, err)
+		}
+	}
+}
+
+func TestValidateVerb(t *testing.T) {
+	verbs := []string{"Add", "Fix", "Update", "Refactor", "Remove", "Docs"}
+
+	tests := []struct {
+		message string
+		wantErr bool
+	}{
+		{"Add OAuth2 integration to AuthService", false},
+		{"fix null pointer in validator", false},
+		{"Docs: describe --chunk", false},
+		{"Implement retry in client.go", true},
+		{"", true},
+	}
+
+	for _, tt := range tests {
+		err := ValidateVerb(tt.message, verbs)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ValidateVerb(%q) error = %v, wantErr %v", tt.message, err, tt.wantErr)
+		}
+	}
+
+	if err := ValidateVerb("Implement retry", verbs); err == nil || !strings.Contains(err.Error(), `starts with "Implement"`) {
+		t.Errorf("Expected the offending verb in the error, got %v", err)
+	}
+}
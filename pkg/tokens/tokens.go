@@ -0,0 +1,23 @@
+package tokens
+
+import "unicode/utf8"
+
+const (
+	// charsPerToken is a rough average for English text and source code
+	charsPerToken = 4
+	// ResponseReserve is the number of tokens kept free for the model's answer
+	ResponseReserve = 512
+)
+
+// Estimate returns a rough token count for text. It errs on neither side
+// consistently, so budgets built on it should leave some headroom.
+func Estimate(text string) int {
+	return (utf8.RuneCountInString(text) + charsPerToken - 1) / charsPerToken
+}
+
+// Fits reports whether a prompt of text still leaves ResponseReserve tokens
+// free in a context window of window tokens. A window of zero or less means
+// the size is not checked.
+func Fits(text string, window int) bool {
+	return window <= 0 || Estimate(text)+ResponseReserve <= window
+}
@@ -0,0 +1,39 @@
+package tokens
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEstimate(t *testing.T) {
+	tests := []struct {
+		text string
+		want int
+	}{
+		{"", 0},
+		{"abc", 1},
+		{"abcd", 1},
+		{"abcde", 2},
+		{"äöüß", 1}, // runes, not bytes
+	}
+
+	for _, tt := range tests {
+		if got := Estimate(tt.text); got != tt.want {
+			t.Errorf("Estimate(%q) = %d, expected %d", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestFits(t *testing.T) {
+	text := strings.Repeat("a", 4*100)
+
+	if !Fits(text, 100+ResponseReserve) {
+		t.Error("Expected 100 tokens to fit with the reserve")
+	}
+	if Fits(text, 99+ResponseReserve) {
+		t.Error("Expected 100 tokens not to fit in a window one token too small")
+	}
+	if !Fits(text, 0) {
+		t.Error("Expected an unset window to accept any prompt")
+	}
+}
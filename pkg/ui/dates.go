@@ -0,0 +1,81 @@
+package ui
+
+import (
+	"fmt"
+	"time"
+)
+
+// Date formats accepted by SetDateFormat
+const (
+	DateShort    = "short"    // 2024-05-01
+	DateRelative = "relative" // 3 days ago
+	DateISO      = "iso"      // 2024-05-01T14:03:00+02:00
+)
+
+// dateFormat is the resolved date.format setting
+var dateFormat = DateShort
+
+// SetDateFormat sets how commit dates are shown: short, relative or iso
+func SetDateFormat(format string) error {
+	switch format {
+	case DateShort, DateRelative, DateISO:
+	default:
+		return fmt.Errorf("invalid date format %q, expected %s, %s or %s", format, DateShort, DateRelative, DateISO)
+	}
+	dateFormat = format
+	return nil
+}
+
+// FormatDate formats t in the configured date format. A zero time gives "".
+func FormatDate(t time.Time) string {
+	return formatDate(t, dateFormat, time.Now())
+}
+
+// formatDate formats t as format asks, measuring relative dates from now
+func formatDate(t time.Time, format string, now time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+
+	switch format {
+	case DateRelative:
+		return relativeDate(t, now)
+	case DateISO:
+		return t.Format(time.RFC3339)
+	}
+	return t.Format("2006-01-02")
+}
+
+// relativeDate describes how long before now t was, in the largest unit
+// that fits, like git's --date=relative
+func relativeDate(t, now time.Time) string {
+	age := now.Sub(t)
+	if age < 0 {
+		return "in the future"
+	}
+
+	const day = 24 * time.Hour
+	switch {
+	case age < time.Minute:
+		return "just now"
+	case age < time.Hour:
+		return ago(int(age/time.Minute), "minute")
+	case age < day:
+		return ago(int(age/time.Hour), "hour")
+	case age < 14*day:
+		return ago(int(age/day), "day")
+	case age < 10*7*day:
+		return ago(int(age/(7*day)), "week")
+	case age < 365*day:
+		return ago(int(age/(30*day)), "month")
+	}
+	return ago(int(age/(365*day)), "year")
+}
+
+// ago formats a count of units as "1 day ago" or "3 days ago"
+func ago(n int, unit string) string {
+	if n == 1 {
+		return fmt.Sprintf("1 %s ago", unit)
+	}
+	return fmt.Sprintf("%d %ss ago", n, unit)
+}
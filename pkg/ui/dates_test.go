@@ -0,0 +1,39 @@
+package ui
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFormatDate(t *testing.T) {
+	now := time.Date(2024, 5, 15, 12, 0, 0, 0, time.UTC)
+	commit := time.Date(2024, 5, 12, 9, 30, 0, 0, time.FixedZone("CEST", 2*60*60))
+
+	tests := []struct {
+		format string
+		when   time.Time
+		want   string
+	}{
+		{DateShort, commit, "2024-05-12"},
+		{DateISO, commit, "2024-05-12T09:30:00+02:00"},
+		{DateRelative, commit, "3 days ago"},
+		{DateRelative, now.Add(-30 * time.Second), "just now"},
+		{DateRelative, now.Add(-time.Minute), "1 minute ago"},
+		{DateRelative, now.Add(-5 * time.Hour), "5 hours ago"},
+		{DateRelative, now.AddDate(0, 0, -21), "3 weeks ago"},
+		{DateRelative, now.AddDate(0, -4, 0), "4 months ago"},
+		{DateRelative, now.AddDate(-2, 0, 0), "2 years ago"},
+		{DateRelative, now.Add(time.Hour), "in the future"},
+		{DateRelative, time.Time{}, ""},
+	}
+
+	for _, tt := range tests {
+		if got := formatDate(tt.when, tt.format, now); got != tt.want {
+			t.Errorf("%s of %s: expected %q, got %q", tt.format, tt.when, tt.want, got)
+		}
+	}
+
+	if err := SetDateFormat("locale"); err == nil {
+		t.Error("Expected an unknown date format to be rejected")
+	}
+}
@@ -0,0 +1,64 @@
+package ui
+
+// emojiSet is an emoji and what replaces it when emoji are turned off.
+// Emoji that carry meaning, such as severities and warnings, get an ASCII
+// marker; the ones that only decorate a heading are dropped.
+type emojiSet struct {
+	emoji string
+	ascii string
+}
+
+// emojis are the emoji the UI uses, by name
+var emojis = map[string]emojiSet{
+	"generated":   {"✨", ""},
+	"current":     {"📌", ""},
+	"output":      {"📎", ""},
+	"history":     {"🕘", ""},
+	"suggestions": {"💡", ""},
+	"branch":      {"📄", ""},
+	"cache":       {"💾", ""},
+	"stats":       {"📊", ""},
+	"changelog":   {"📋", ""},
+	"pr":          {"🔀", ""},
+	"bench":       {"⏱", ""},
+	"commit":      {"🤖", ""},
+	"summary":     {"📝", ""},
+	"lint":        {"🔍", ""},
+	"warning":     {"⚠", "!"},
+	"high":        {"🔴", "[!]"},
+	"medium":      {"🟡", "[*]"},
+	"low":         {"🟢", "[-]"},
+	"severity":    {"⚪", "[?]"},
+}
+
+// emojiEnabled is the resolved output.emoji setting
+var emojiEnabled = true
+
+// SetEmoji turns emoji in output on or off. Colors are not affected.
+func SetEmoji(enabled bool) {
+	emojiEnabled = enabled
+}
+
+// Emoji returns the named emoji, or its ASCII stand-in when emoji are
+// turned off, which is "" for decoration
+func Emoji(name string) string {
+	set := emojis[name]
+	if !emojiEnabled {
+		return set.ascii
+	}
+	return set.emoji
+}
+
+// WithEmoji prefixes text with the named emoji and a space, leaving text
+// alone when the emoji has no stand-in
+func WithEmoji(name, text string) string {
+	return withIcon(Emoji(name), text)
+}
+
+// withIcon prefixes text with icon and a space unless icon is empty
+func withIcon(icon, text string) string {
+	if icon == "" {
+		return text
+	}
+	return icon + " " + text
+}
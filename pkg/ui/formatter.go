@@ -3,7 +3,9 @@ package ui
 import (
 	"fmt"
 	"gh-smart-commit/pkg/git"
+	"gh-smart-commit/pkg/history"
 	"strings"
+	"time"
 )
 
 // CommitMessageFormatter handles formatting commit messages beautifully
@@ -24,9 +26,14 @@ Generated commit message:
 ─────────────────────────`, message)
 	}
 
-	header := HeaderStyle.Render("✨ Generated Commit Message")
-	separator := CreateSeparator(60)
-	messageStyled := CommitMessageStyle.Render(message)
+	return f.formatMessage(WithEmoji("generated", "Generated Commit Message"), message, SeparatorWidth())
+}
+
+// formatMessage renders message in a box wrapped to fit width columns
+func (f *CommitMessageFormatter) formatMessage(title, message string, width int) string {
+	header := HeaderStyle.Render(title)
+	separator := CreateSeparator(width)
+	messageStyled := renderWrapped(CommitMessageStyle, message, width)
 
 	return fmt.Sprintf("\n%s\n%s\n%s\n%s\n",
 		header,
@@ -38,15 +45,72 @@ Generated commit message:
 // FormatConfirmation formats the confirmation prompt
 func (f *CommitMessageFormatter) FormatConfirmation() string {
 	if IsNoColor() {
-		return "\nDo you want to commit with this message? [y/N]: "
+		return "\nDo you want to commit with this message? [y/N/r to regenerate]: "
 	}
 
 	prompt := InfoStyle.Render("Do you want to commit with this message?")
-	options := MutedStyle.Render("[y/N]")
+	options := MutedStyle.Render("[y/N/r to regenerate]")
+
+	return fmt.Sprintf("\n%s %s: ", prompt, options)
+}
+
+// FormatGuidancePrompt formats the prompt asking how to change the message
+func (f *CommitMessageFormatter) FormatGuidancePrompt() string {
+	if IsNoColor() {
+		return "What should change? (e.g. \"mention the performance impact\"): "
+	}
+
+	prompt := InfoStyle.Render("What should change?")
+	example := MutedStyle.Render("(e.g. \"mention the performance impact\")")
+
+	return fmt.Sprintf("%s %s: ", prompt, example)
+}
+
+// FormatCurrent formats the message of the commit being amended
+func (f *CommitMessageFormatter) FormatCurrent(message string) string {
+	if IsNoColor() {
+		return fmt.Sprintf(`
+Current commit message:
+─────────────────────────
+%s
+─────────────────────────`, message)
+	}
+
+	return f.formatMessage(WithEmoji("current", "Current Commit Message"), message, SeparatorWidth())
+}
+
+// FormatKeepConfirmation formats the keep/regenerate prompt shown when amending
+func (f *CommitMessageFormatter) FormatKeepConfirmation() string {
+	if IsNoColor() {
+		return "\nThe current message still fits. Keep it or regenerate? [K/r]: "
+	}
+
+	prompt := InfoStyle.Render("The current message still fits. Keep it or regenerate?")
+	options := MutedStyle.Render("[K/r]")
 
 	return fmt.Sprintf("\n%s %s: ", prompt, options)
 }
 
+// FormatGitOutput formats the output git and its hooks printed while
+// committing, in a red box when the commit failed
+func (f *CommitMessageFormatter) FormatGitOutput(output string, failed bool) string {
+	title := "Git output"
+	if failed {
+		title = "Git output (commit failed)"
+	}
+
+	if IsNoColor() {
+		return fmt.Sprintf("\n%s:\n%s\n%s\n", title, strings.Repeat("─", noColorSeparatorWidth), output)
+	}
+
+	if failed {
+		style := ContainerStyle.Copy().BorderForeground(errorColor)
+		return "\n" + style.Render(fmt.Sprintf("%s\n\n%s", ErrorStyle.Render("✗ "+title), BodyStyle.Render(output))) + "\n"
+	}
+
+	return "\n" + RenderBox(WithEmoji("output", title), output) + "\n"
+}
+
 // BashCommandFormatter handles formatting bash commands beautifully
 type BashCommandFormatter struct{}
 
@@ -65,7 +129,7 @@ func (f *BashCommandFormatter) FormatGenerated(command string) string {
 	}
 
 	header := HeaderStyle.Render("Generated Bash Command")
-	separator := CreateSeparator(60)
+	separator := CreateSeparator(SeparatorWidth())
 	commandStyled := CodeStyle.Render(command)
 
 	return fmt.Sprintf("\n%s\n%s\n%s\n%s\n",
@@ -75,6 +139,16 @@ func (f *BashCommandFormatter) FormatGenerated(command string) string {
 		separator)
 }
 
+// FormatExplanation formats the model's rationale for a generated command,
+// shown below it
+func (f *BashCommandFormatter) FormatExplanation(explanation string) string {
+	if IsNoColor() {
+		return "\n" + explanation + "\n"
+	}
+
+	return "\n" + MutedStyle.Render(explanation) + "\n"
+}
+
 // FormatConfirmation formats the confirmation prompt for command execution
 func (f *BashCommandFormatter) FormatConfirmation() string {
 	if IsNoColor() {
@@ -87,8 +161,71 @@ func (f *BashCommandFormatter) FormatConfirmation() string {
 	return fmt.Sprintf("\n%s %s: ", prompt, options)
 }
 
+// FormatDanger formats the reasons a command was flagged as dangerous in a red box
+func (f *BashCommandFormatter) FormatDanger(reasons []string) string {
+	var lines strings.Builder
+	lines.WriteString("This command looks dangerous:")
+	for _, reason := range reasons {
+		lines.WriteString("\n  • " + reason)
+	}
+
+	if IsNoColor() {
+		return fmt.Sprintf("\nWARNING: %s\n", lines.String())
+	}
+
+	return "\n" + RenderErrorBox(lines.String()) + "\n"
+}
+
+// FormatDangerConfirmation formats the confirmation prompt for a dangerous command
+func (f *BashCommandFormatter) FormatDangerConfirmation() string {
+	if IsNoColor() {
+		return "\nType 'yes' to execute this dangerous command: "
+	}
+
+	prompt := ErrorStyle.Render("Type 'yes' to execute this dangerous command")
+
+	return fmt.Sprintf("\n%s: ", prompt)
+}
+
+// FormatHistory formats recent bash commands, most recent first, numbered
+// for --rerun
+func (f *BashCommandFormatter) FormatHistory(entries []history.Entry) string {
+	var b strings.Builder
+
+	if IsNoColor() {
+		b.WriteString("\nBash history\n")
+		b.WriteString(strings.Repeat("─", noColorSeparatorWidth) + "\n")
+	} else {
+		b.WriteString("\n" + HeaderStyle.Render(WithEmoji("history", "Bash History")) + "\n")
+		b.WriteString(CreateSeparator(SeparatorWidth()) + "\n")
+	}
+
+	for i, entry := range entries {
+		status := "ran"
+		if !entry.Executed {
+			status = "not run"
+		}
+		meta := fmt.Sprintf("%s · %s · %s", entry.Timestamp.Local().Format("2006-01-02 15:04"), status, entry.Description)
+
+		if IsNoColor() {
+			b.WriteString(fmt.Sprintf("%3d  %s\n     %s\n", i+1, entry.Command, meta))
+			continue
+		}
+		b.WriteString(fmt.Sprintf("%s  %s\n     %s\n",
+			InfoStyle.Render(fmt.Sprintf("%3d", i+1)),
+			BodyStyle.Render(entry.Command),
+			MutedStyle.Render(meta)))
+	}
+
+	return b.String()
+}
+
 // SuggestionFormatter handles formatting lint suggestions
-type SuggestionFormatter struct{}
+type SuggestionFormatter struct {
+	// GroupBySeverity adds a heading whenever the severity changes; the
+	// suggestions are expected to already be sorted
+	GroupBySeverity bool
+}
 
 // NewSuggestionFormatter creates a new suggestion formatter
 func NewSuggestionFormatter() *SuggestionFormatter {
@@ -104,30 +241,116 @@ func (f *SuggestionFormatter) FormatSuggestionsList(suggestions []Suggestion, di
 	var result strings.Builder
 
 	// Header
-	header := fmt.Sprintf("💡 Code Improvement Suggestions (%s changes)", diffType)
+	header := fmt.Sprintf("Code Improvement Suggestions (%s changes)", diffType)
 	if IsNoColor() {
 		result.WriteString(fmt.Sprintf("\n%s\n", header))
 		result.WriteString(strings.Repeat("─", 60) + "\n\n")
 	} else {
-		result.WriteString("\n" + HeaderStyle.Render(header) + "\n")
-		result.WriteString(CreateSeparator(60) + "\n\n")
+		result.WriteString("\n" + HeaderStyle.Render(WithEmoji("suggestions", header)) + "\n")
+		result.WriteString(CreateSeparator(SeparatorWidth()) + "\n\n")
 	}
 
 	// Suggestions
 	for i, suggestion := range suggestions {
+		if f.GroupBySeverity && (i == 0 || suggestions[i-1].Severity != suggestion.Severity) {
+			result.WriteString(f.FormatSeverityHeading(suggestion.Severity))
+		}
 		result.WriteString(f.FormatSuggestion(i+1, suggestion))
 		result.WriteString("\n")
 	}
 
 	// Footer
-	result.WriteString(f.FormatSuggestionsSummary(len(suggestions), total))
+	result.WriteString(f.FormatSuggestionsSummary(suggestions, total))
+
+	return result.String()
+}
+
+// FormatSeverityHeading formats the heading of a severity group
+func (f *SuggestionFormatter) FormatSeverityHeading(severity string) string {
+	if IsNoColor() {
+		return fmt.Sprintf("%s\n\n", severity)
+	}
+
+	return fmt.Sprintf("%s\n\n", GetSeverityStyle(severity).Render(withIcon(GetSeverityIcon(severity), severity)))
+}
 
+// FormatPlain formats suggestions as undecorated text for scripting
+func (f *SuggestionFormatter) FormatPlain(suggestions []Suggestion) string {
+	var result strings.Builder
+	for i, suggestion := range suggestions {
+		result.WriteString(fmt.Sprintf("%d. [%s] %s\n", i+1, suggestion.Severity, suggestion.Title))
+		if location := suggestion.Location(); location != "" {
+			result.WriteString("   " + location + "\n")
+		}
+		if suggestion.Description != "" {
+			result.WriteString("   " + suggestion.Description + "\n")
+		}
+	}
 	return result.String()
 }
 
+// FormatGitHub formats suggestions as GitHub Actions workflow commands so
+// they show up as annotations on the pull request
+func (f *SuggestionFormatter) FormatGitHub(suggestions []Suggestion) string {
+	var result strings.Builder
+	for _, suggestion := range suggestions {
+		var props []string
+		if suggestion.File != "" {
+			props = append(props, "file="+escapeGitHubProperty(suggestion.File))
+			if suggestion.Line > 0 {
+				props = append(props, fmt.Sprintf("line=%d", suggestion.Line))
+			}
+		}
+		props = append(props, "title="+escapeGitHubProperty(suggestion.Title))
+
+		message := suggestion.Description
+		if message == "" {
+			message = suggestion.Title
+		}
+
+		result.WriteString(fmt.Sprintf("::%s %s::%s\n",
+			githubAnnotationLevel(suggestion.Severity),
+			strings.Join(props, ","),
+			escapeGitHubData(message)))
+	}
+	return result.String()
+}
+
+// githubAnnotationLevel maps a severity to a workflow command
+func githubAnnotationLevel(severity string) string {
+	switch strings.ToUpper(severity) {
+	case "HIGH":
+		return "error"
+	case "MEDIUM":
+		return "warning"
+	default:
+		return "notice"
+	}
+}
+
+// escapeGitHubData escapes a workflow command message
+func escapeGitHubData(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(s)
+}
+
+// escapeGitHubProperty escapes a workflow command property value
+func escapeGitHubProperty(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C").Replace(s)
+}
+
 // FormatSuggestion formats a single suggestion
 func (f *SuggestionFormatter) FormatSuggestion(number int, suggestion Suggestion) string {
+	location := suggestion.Location()
+
 	if IsNoColor() {
+		if location != "" {
+			return fmt.Sprintf("%d. [%s] %s\n   %s\n   %s",
+				number,
+				suggestion.Severity,
+				suggestion.Title,
+				location,
+				suggestion.Description)
+		}
 		return fmt.Sprintf("%d. [%s] %s\n   %s",
 			number,
 			suggestion.Severity,
@@ -143,7 +366,11 @@ func (f *SuggestionFormatter) FormatSuggestion(number int, suggestion Suggestion
 		BodyStyle.Render(suggestion.Title))
 
 	var result strings.Builder
-	result.WriteString(fmt.Sprintf("%s %d. %s\n", icon, number, title))
+	result.WriteString(withIcon(icon, fmt.Sprintf("%d. %s\n", number, title)))
+
+	if location != "" {
+		result.WriteString("   " + LocationStyle.Render(location) + "\n")
+	}
 
 	if suggestion.Description != "" {
 		description := MutedStyle.Render("   " + suggestion.Description)
@@ -153,10 +380,18 @@ func (f *SuggestionFormatter) FormatSuggestion(number int, suggestion Suggestion
 	return result.String()
 }
 
-// FormatSuggestionsSummary formats the summary at the end
-func (f *SuggestionFormatter) FormatSuggestionsSummary(shown, total int) string {
+// severityOrder is the order severities are tallied in, most severe first
+var severityOrder = []string{"HIGH", "MEDIUM", "LOW"}
+
+// FormatSuggestionsSummary formats the summary at the end: how many
+// suggestions are shown, e.g. "3 HIGH, 5 MEDIUM, 2 LOW", out of total
+func (f *SuggestionFormatter) FormatSuggestionsSummary(suggestions []Suggestion, total int) string {
+	shown := len(suggestions)
 	if IsNoColor() {
 		summary := fmt.Sprintf("Found %d suggestions", shown)
+		if counts := f.formatSeverityCounts(suggestions, false); counts != "" {
+			summary += ": " + counts
+		}
 		if total > shown {
 			summary += fmt.Sprintf(" (filtered from %d total)", total)
 		}
@@ -165,12 +400,49 @@ func (f *SuggestionFormatter) FormatSuggestionsSummary(shown, total int) string
 
 	summary := fmt.Sprintf("Found %s suggestions",
 		SuccessStyle.Render(fmt.Sprintf("%d", shown)))
+	if counts := f.formatSeverityCounts(suggestions, true); counts != "" {
+		summary += ": " + counts
+	}
 
 	if total > shown {
 		summary += MutedStyle.Render(fmt.Sprintf(" (filtered from %d total)", total))
 	}
 
-	return "\n" + InfoStyle.Render("💡 ") + summary + "\n"
+	if icon := Emoji("suggestions"); icon != "" {
+		summary = InfoStyle.Render(icon+" ") + summary
+	}
+	return "\n" + summary + "\n"
+}
+
+// formatSeverityCounts tallies suggestions by severity, e.g. "3 HIGH,
+// 5 MEDIUM". Severities without suggestions are left out, and unknown ones
+// come last in the order they first appear.
+func (f *SuggestionFormatter) formatSeverityCounts(suggestions []Suggestion, colored bool) string {
+	counts := make(map[string]int)
+	for _, severity := range severityOrder {
+		counts[severity] = 0
+	}
+
+	order := append([]string(nil), severityOrder...)
+	for _, s := range suggestions {
+		if _, known := counts[s.Severity]; !known {
+			order = append(order, s.Severity)
+		}
+		counts[s.Severity]++
+	}
+
+	var parts []string
+	for _, severity := range order {
+		if counts[severity] == 0 {
+			continue
+		}
+		part := fmt.Sprintf("%d %s", counts[severity], severity)
+		if colored {
+			part = GetSeverityStyle(severity).Render(part)
+		}
+		parts = append(parts, part)
+	}
+	return strings.Join(parts, ", ")
 }
 
 // BranchFormatter handles formatting branch descriptions
@@ -183,6 +455,11 @@ func NewBranchFormatter() *BranchFormatter {
 
 // FormatDescription formats a branch description beautifully
 func (f *BranchFormatter) FormatDescription(description string, cached bool) string {
+	return f.formatDescription(description, cached, SeparatorWidth())
+}
+
+// formatDescription renders description wrapped to width columns
+func (f *BranchFormatter) formatDescription(description string, cached bool, width int) string {
 	if IsNoColor() {
 		header := "Branch Description"
 		if cached {
@@ -197,14 +474,14 @@ func (f *BranchFormatter) FormatDescription(description string, cached bool) str
 
 	var header string
 	if cached {
-		header = HeaderStyle.Render("📄 Branch Description") +
+		header = HeaderStyle.Render(WithEmoji("branch", "Branch Description")) +
 			MutedStyle.Render(" (cached)")
 	} else {
-		header = HeaderStyle.Render("📄 Branch Description")
+		header = HeaderStyle.Render(WithEmoji("branch", "Branch Description"))
 	}
 
-	separator := CreateSeparator(60)
-	content := BodyStyle.Render(description)
+	separator := CreateSeparator(width)
+	content := renderWrapped(BodyStyle, description, width)
 
 	result := fmt.Sprintf("\n%s\n%s\n%s\n",
 		header,
@@ -212,7 +489,7 @@ func (f *BranchFormatter) FormatDescription(description string, cached bool) str
 		content)
 
 	if cached {
-		cacheNote := MutedStyle.Render("💾 From cache • Use --no-cache to regenerate")
+		cacheNote := MutedStyle.Render(WithEmoji("cache", "From cache • Use --no-cache to regenerate"))
 		result += "\n" + cacheNote + "\n"
 	}
 
@@ -225,16 +502,68 @@ func (f *BranchFormatter) FormatStats(stats string) string {
 		return "\nStatistics: " + stats + "\n"
 	}
 
-	return "\n" + InfoStyle.Render("📊 Statistics: ") +
+	return "\n" + InfoStyle.Render(WithEmoji("stats", "Statistics: ")) +
 		MutedStyle.Render(stats) + "\n"
 }
 
+// ChangelogFormatter handles formatting generated changelog sections
+type ChangelogFormatter struct{}
+
+// NewChangelogFormatter creates a new changelog formatter
+func NewChangelogFormatter() *ChangelogFormatter {
+	return &ChangelogFormatter{}
+}
+
+// FormatChangelog formats a generated changelog section
+func (f *ChangelogFormatter) FormatChangelog(changelog string, from, to string, commits int) string {
+	title := fmt.Sprintf("Changelog %s..%s (%d commits)", from, to, commits)
+	if IsNoColor() {
+		return fmt.Sprintf("\n%s:\n%s\n%s\n", title, strings.Repeat("─", noColorSeparatorWidth), changelog)
+	}
+
+	return fmt.Sprintf("\n%s\n%s\n%s\n",
+		HeaderStyle.Render(WithEmoji("changelog", title)),
+		CreateSeparator(SeparatorWidth()),
+		BodyStyle.Render(changelog))
+}
+
+// PRFormatter handles formatting pull request descriptions
+type PRFormatter struct{}
+
+// NewPRFormatter creates a new pull request formatter
+func NewPRFormatter() *PRFormatter {
+	return &PRFormatter{}
+}
+
+// FormatHeader formats the heading shown above a generated description
+func (f *PRFormatter) FormatHeader(branch, base string) string {
+	title := fmt.Sprintf("Pull Request: %s → %s", branch, base)
+	if IsNoColor() {
+		return fmt.Sprintf("\n%s\n%s\n", title, strings.Repeat("─", noColorSeparatorWidth))
+	}
+
+	return fmt.Sprintf("\n%s\n%s\n", HeaderStyle.Render(WithEmoji("pr", title)), CreateSeparator(SeparatorWidth()))
+}
+
 // Suggestion represents a code improvement suggestion
 type Suggestion struct {
 	Severity    string
 	Title       string
 	Description string
 	Number      int
+	File        string
+	Line        int
+}
+
+// Location returns the suggestion's "file:line" anchor, or "" if it has none
+func (s Suggestion) Location() string {
+	if s.File == "" {
+		return ""
+	}
+	if s.Line > 0 {
+		return fmt.Sprintf("%s:%d", s.File, s.Line)
+	}
+	return s.File
 }
 
 // ContextFormatter handles formatting context information
@@ -261,6 +590,19 @@ func (f *ContextFormatter) FormatRepoInfo(repoName, branch string, verbose bool)
 	return fmt.Sprintf("%s\n%s\n", repo, branchInfo)
 }
 
+// FormatDiffStat formats a `git diff --stat` summary under a heading
+func (f *ContextFormatter) FormatDiffStat(stat string) string {
+	if strings.TrimSpace(stat) == "" {
+		return ""
+	}
+
+	if IsNoColor() {
+		return "Staged changes:\n" + stat + "\n"
+	}
+
+	return MutedStyle.Render("Staged changes:") + "\n" + BodyStyle.Render(stat) + "\n"
+}
+
 // FormatCommitList formats a list of commits
 func (f *ContextFormatter) FormatCommitList(commits []git.Commit) string {
 	if len(commits) == 0 {
@@ -275,7 +617,7 @@ func (f *ContextFormatter) FormatCommitList(commits []git.Commit) string {
 			if i >= 5 { // Limit display
 				break
 			}
-			result.WriteString(fmt.Sprintf("  • %s\n", commit.Message))
+			result.WriteString(fmt.Sprintf("  • %s%s\n", commit.Message, commitDateSuffix(commit)))
 		}
 	} else {
 		result.WriteString(MutedStyle.Render("Recent commits:") + "\n")
@@ -284,7 +626,216 @@ func (f *ContextFormatter) FormatCommitList(commits []git.Commit) string {
 				break
 			}
 			result.WriteString(MutedStyle.Render("  • ") +
-				BodyStyle.Render(commit.Message) + "\n")
+				BodyStyle.Render(commit.Message) + MutedStyle.Render(commitDateSuffix(commit)) + "\n")
+		}
+	}
+
+	return result.String()
+}
+
+// commitDateSuffix returns the commit's date in parentheses, or "" when
+// it is unknown
+func commitDateSuffix(commit git.Commit) string {
+	if date := FormatDate(commit.Time); date != "" {
+		return " (" + date + ")"
+	}
+	return ""
+}
+
+// BenchRow is a single model's row in the benchmark table
+type BenchRow struct {
+	Model        string
+	Runs         int
+	Min          time.Duration
+	Median       time.Duration
+	P95          time.Duration
+	TokensPerSec float64
+	// Message is the subject line the model produced on its last run
+	Message string
+	Err     string
+}
+
+// BenchFormatter handles formatting model benchmark results
+type BenchFormatter struct{}
+
+// NewBenchFormatter creates a new benchmark formatter
+func NewBenchFormatter() *BenchFormatter {
+	return &BenchFormatter{}
+}
+
+// FormatTable formats benchmark rows as an aligned comparison table
+func (f *BenchFormatter) FormatTable(rows []BenchRow) string {
+	headers := []string{"MODEL", "RUNS", "MIN", "MEDIAN", "P95", "TOK/S", "MESSAGE"}
+
+	cells := make([][]string, len(rows))
+	for i, row := range rows {
+		if row.Err != "" {
+			cells[i] = []string{row.Model, fmt.Sprintf("%d", row.Runs), "-", "-", "-", "-", "error: " + row.Err}
+			continue
+		}
+		cells[i] = []string{
+			row.Model,
+			fmt.Sprintf("%d", row.Runs),
+			formatLatency(row.Min),
+			formatLatency(row.Median),
+			formatLatency(row.P95),
+			fmt.Sprintf("%.1f", row.TokensPerSec),
+			row.Message,
+		}
+	}
+
+	widths := make([]int, len(headers))
+	for i, h := range headers {
+		widths[i] = len(h)
+	}
+	for _, row := range cells {
+		for i, cell := range row {
+			if len(cell) > widths[i] {
+				widths[i] = len(cell)
+			}
+		}
+	}
+
+	formatRow := func(row []string) string {
+		parts := make([]string, len(row))
+		for i, cell := range row {
+			parts[i] = fmt.Sprintf("%-*s", widths[i], cell)
+		}
+		return strings.TrimRight(strings.Join(parts, "  "), " ")
+	}
+
+	var result strings.Builder
+	if IsNoColor() {
+		result.WriteString("\nModel Benchmark\n")
+		result.WriteString(formatRow(headers) + "\n")
+		for _, row := range cells {
+			result.WriteString(formatRow(row) + "\n")
+		}
+		return result.String()
+	}
+
+	result.WriteString("\n" + HeaderStyle.Render(WithEmoji("bench", "Model Benchmark")) + "\n")
+	result.WriteString(MutedStyle.Render(formatRow(headers)) + "\n")
+	for i, row := range cells {
+		if rows[i].Err != "" {
+			result.WriteString(ErrorStyle.Render(formatRow(row)) + "\n")
+		} else {
+			result.WriteString(BodyStyle.Render(formatRow(row)) + "\n")
+		}
+	}
+
+	return result.String()
+}
+
+// formatLatency renders a duration with millisecond precision
+func formatLatency(d time.Duration) string {
+	return d.Round(time.Millisecond).String()
+}
+
+// ConfigEntry is a single effective setting
+type ConfigEntry struct {
+	Key    string
+	Value  string
+	Source string
+}
+
+// ConfigFormatter handles formatting configuration settings
+type ConfigFormatter struct{}
+
+// NewConfigFormatter creates a new config formatter
+func NewConfigFormatter() *ConfigFormatter {
+	return &ConfigFormatter{}
+}
+
+// FormatSettings formats settings as aligned key = value lines with their source
+func (f *ConfigFormatter) FormatSettings(entries []ConfigEntry) string {
+	keyWidth := 0
+	for _, entry := range entries {
+		if len(entry.Key) > keyWidth {
+			keyWidth = len(entry.Key)
+		}
+	}
+
+	var result strings.Builder
+	for _, entry := range entries {
+		key := fmt.Sprintf("%-*s", keyWidth, entry.Key)
+		source := fmt.Sprintf("(%s)", entry.Source)
+
+		if IsNoColor() {
+			result.WriteString(fmt.Sprintf("%s = %s %s\n", key, entry.Value, source))
+		} else {
+			result.WriteString(InfoStyle.Render(key) + " = " +
+				BodyStyle.Render(entry.Value) + " " +
+				MutedStyle.Render(source) + "\n")
+		}
+	}
+
+	return result.String()
+}
+
+// DebugField is a single named value in a diagnostic section
+type DebugField struct {
+	Name  string
+	Value string
+}
+
+// DebugFormatter handles formatting diagnostic output
+type DebugFormatter struct{}
+
+// NewDebugFormatter creates a new debug formatter
+func NewDebugFormatter() *DebugFormatter {
+	return &DebugFormatter{}
+}
+
+// FormatPrompt formats the system and user messages of a prompt verbatim,
+// each under a heading with its size
+func (f *DebugFormatter) FormatPrompt(system, user string) string {
+	var result strings.Builder
+	for _, part := range []struct{ title, text string }{
+		{"System prompt", system},
+		{"User prompt", user},
+	} {
+		title := fmt.Sprintf("%s (%d chars, %d lines)", part.title, len(part.text), strings.Count(part.text, "\n")+1)
+		if IsNoColor() {
+			result.WriteString(fmt.Sprintf("\n%s\n%s\n", title, strings.Repeat("─", noColorSeparatorWidth)))
+		} else {
+			result.WriteString("\n" + HeaderStyle.Render(title) + "\n" + CreateSeparator(SeparatorWidth()) + "\n")
+		}
+		result.WriteString(strings.TrimRight(part.text, "\n") + "\n")
+	}
+
+	return result.String()
+}
+
+// FormatSection formats a titled section of aligned name: value lines.
+// Multi-line values are indented under their name.
+func (f *DebugFormatter) FormatSection(title string, fields []DebugField) string {
+	nameWidth := 0
+	for _, field := range fields {
+		if len(field.Name) > nameWidth {
+			nameWidth = len(field.Name)
+		}
+	}
+
+	var result strings.Builder
+	if IsNoColor() {
+		result.WriteString(fmt.Sprintf("\n%s\n%s\n", title, strings.Repeat("─", noColorSeparatorWidth)))
+	} else {
+		result.WriteString("\n" + HeaderStyle.Render(title) + "\n" + CreateSeparator(SeparatorWidth()) + "\n")
+	}
+
+	for _, field := range fields {
+		value := field.Value
+		if value == "" {
+			value = "(none)"
+		}
+		value = strings.ReplaceAll(strings.TrimRight(value, "\n"), "\n", "\n"+strings.Repeat(" ", nameWidth+2))
+
+		name := fmt.Sprintf("%-*s", nameWidth, field.Name)
+		if IsNoColor() {
+			result.WriteString(fmt.Sprintf("%s  %s\n", name, value))
+		} else {
+			result.WriteString(InfoStyle.Render(name) + "  " + BodyStyle.Render(value) + "\n")
 		}
 	}
 
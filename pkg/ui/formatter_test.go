@@ -0,0 +1,264 @@
+package ui
+
+import (
+	"bufio"
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// checkGolden compares got with testdata/name, rewriting it with -update.
+// Golden files hold uncolored output whether or not stdout is a terminal.
+func checkGolden(t *testing.T, name, got string) {
+	t.Helper()
+	path := filepath.Join("testdata", name)
+
+	if *update {
+		if err := os.MkdirAll("testdata", 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(got), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read golden file (run with -update to create it): %v", err)
+	}
+	if got != string(want) {
+		t.Errorf("Output differs from %s:\n--- expected\n%s\n--- got\n%s", path, want, got)
+	}
+}
+
+func TestFormatGitHub(t *testing.T) {
+	suggestions := []Suggestion{
+		{Severity: "HIGH", Title: "Handle error", Description: "Close can fail", File: "cmd/root.go", Line: 42},
+		{Severity: "MEDIUM", Title: "Cache regex", File: "pkg/prompt/builder.go"},
+		{Severity: "LOW", Title: "Docs: add, please", Description: "100% of\nexports"},
+	}
+
+	got := NewSuggestionFormatter().FormatGitHub(suggestions)
+	want := "::error file=cmd/root.go,line=42,title=Handle error::Close can fail\n" +
+		"::warning file=pkg/prompt/builder.go,title=Cache regex::Cache regex\n" +
+		"::notice title=Docs%3A add%2C please::100%25 of%0Aexports\n"
+
+	if got != want {
+		t.Errorf("Expected:\n%s\ngot:\n%s", want, got)
+	}
+}
+
+func TestFormatSuggestionsListGrouped(t *testing.T) {
+	t.Setenv("NO_COLOR", "1")
+
+	suggestions := []Suggestion{
+		{Severity: "LOW", Title: "Rename var"},
+		{Severity: "LOW", Title: "Add doc"},
+		{Severity: "HIGH", Title: "Handle error"},
+	}
+
+	formatter := NewSuggestionFormatter()
+	formatter.GroupBySeverity = true
+	got := formatter.FormatSuggestionsList(suggestions, "staged", 3)
+
+	if strings.Count(got, "LOW\n\n") != 1 || strings.Count(got, "HIGH\n\n") != 1 {
+		t.Errorf("Expected one heading per severity group, got:\n%s", got)
+	}
+	if strings.Index(got, "LOW\n\n") > strings.Index(got, "HIGH\n\n") {
+		t.Errorf("Expected headings in display order, got:\n%s", got)
+	}
+}
+
+func TestFormatSuggestionsSummary(t *testing.T) {
+	t.Setenv("NO_COLOR", "1")
+
+	suggestions := []Suggestion{
+		{Severity: "LOW", Title: "Rename var"},
+		{Severity: "HIGH", Title: "Handle error"},
+		{Severity: "LOW", Title: "Add doc"},
+		{Severity: "HIGH", Title: "Check nil"},
+		{Severity: "INFO", Title: "Nice test"},
+	}
+
+	got := NewSuggestionFormatter().FormatSuggestionsSummary(suggestions, 7)
+	want := "\nFound 5 suggestions: 2 HIGH, 2 LOW, 1 INFO (filtered from 7 total)\n"
+	if got != want {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+}
+
+func TestFormatPrompt(t *testing.T) {
+	t.Setenv("NO_COLOR", "1")
+
+	got := NewDebugFormatter().FormatPrompt("be brief", "Diff:\n+x\n")
+
+	if !strings.Contains(got, "System prompt (8 chars, 1 lines)\n") || !strings.Contains(got, "\nbe brief\n") {
+		t.Errorf("Expected system prompt section, got:\n%s", got)
+	}
+	if !strings.Contains(got, "User prompt (9 chars, 3 lines)\n") || !strings.HasSuffix(got, "\nDiff:\n+x\n") {
+		t.Errorf("Expected verbatim user prompt section, got:\n%s", got)
+	}
+}
+
+func TestFormatBenchTable(t *testing.T) {
+	t.Setenv("NO_COLOR", "1")
+
+	got := NewBenchFormatter().FormatTable([]BenchRow{
+		{Model: "llama3.1:8b", Runs: 3, Min: 1200 * time.Millisecond, Median: 1500 * time.Millisecond,
+			P95: 2 * time.Second, TokensPerSec: 42.5, Message: "Fix login redirect"},
+		{Model: "mistral:7b", Runs: 0, Err: "model not found"},
+	})
+
+	want := "\nModel Benchmark\n" +
+		"MODEL        RUNS  MIN   MEDIAN  P95  TOK/S  MESSAGE\n" +
+		"llama3.1:8b  3     1.2s  1.5s    2s   42.5   Fix login redirect\n" +
+		"mistral:7b   0     -     -       -    -      error: model not found\n"
+	if got != want {
+		t.Errorf("Expected:\n%s\ngot:\n%s", want, got)
+	}
+}
+
+func TestWrapText(t *testing.T) {
+	tests := []struct {
+		text  string
+		width int
+		want  string
+	}{
+		{"short line", 20, "short line"},
+		{"wrap these words onto lines", 10, "wrap these\nwords onto\nlines"},
+		{"- a list item that wraps", 12, "- a list\n  item that\n  wraps"},
+		{"keep\n\nbreaks", 10, "keep\n\nbreaks"},
+		{"abcdefghijkl", 5, "abcde\nfghij\nkl"},
+	}
+
+	for _, tt := range tests {
+		if got := wrapText(tt.text, tt.width); got != tt.want {
+			t.Errorf("wrapText(%q, %d) = %q, expected %q", tt.text, tt.width, got, tt.want)
+		}
+	}
+}
+
+const goldenCommitMessage = `Fix token refresh race in AuthService
+
+Concurrent requests could each start a refresh and overwrite each other's tokens. Guard the refresh with a mutex so only one runs at a time.
+
+- Add a mutex around refresh in internal/auth/service.go`
+
+func TestFormatGeneratedWrapsGolden(t *testing.T) {
+	lipgloss.SetColorProfile(termenv.Ascii)
+	got := NewCommitMessageFormatter().formatMessage("✨ Generated Commit Message", goldenCommitMessage, 40)
+
+	for _, line := range strings.Split(got, "\n") {
+		if w := lipgloss.Width(line); w > 40 {
+			t.Errorf("Line is %d columns wide, expected at most 40: %q", w, line)
+		}
+	}
+	checkGolden(t, "commit_message_40.golden", got)
+}
+
+func TestFormatDescriptionWrapsGolden(t *testing.T) {
+	// Exercise the styled layout even though stdout isn't a terminal
+	colorMode = ColorAlways
+	t.Cleanup(func() { colorMode = ColorAuto })
+	lipgloss.SetColorProfile(termenv.Ascii)
+	description := "This branch adds a pr-describe command that drafts pull request titles and bodies from the branch diff. It also moves branch-describe onto the three-dot diff so changes on the base branch are no longer included."
+
+	got := NewBranchFormatter().formatDescription(description, false, 40)
+
+	for _, line := range strings.Split(got, "\n") {
+		if w := lipgloss.Width(line); w > 40 {
+			t.Errorf("Line is %d columns wide, expected at most 40: %q", w, line)
+		}
+	}
+	checkGolden(t, "branch_description_40.golden", got)
+}
+
+func TestSetColorMode(t *testing.T) {
+	t.Cleanup(func() { colorMode = ColorAuto })
+	t.Setenv("NO_COLOR", "1")
+
+	if err := SetColorMode(ColorAlways); err != nil || IsNoColor() {
+		t.Errorf("Expected --color=always to override NO_COLOR (err %v)", err)
+	}
+	if err := SetColorMode(ColorNever); err != nil || !IsNoColor() {
+		t.Errorf("Expected --color=never to disable color (err %v)", err)
+	}
+
+	t.Setenv("NO_COLOR", "")
+	if err := SetColorMode(ColorAuto); err != nil || IsNoColor() != !IsTerminal() {
+		t.Errorf("Expected --color=auto to follow the terminal (err %v)", err)
+	}
+
+	if err := SetColorMode("sometimes"); err == nil {
+		t.Error("Expected an error for an unknown mode")
+	}
+}
+
+func TestSetEmoji(t *testing.T) {
+	t.Cleanup(func() { colorMode = ColorAuto; emojiEnabled = true })
+	SetColorMode(ColorAlways)
+
+	suggestion := Suggestion{Severity: "HIGH", Title: "Unchecked error", Description: "Handle it"}
+	withEmoji := NewSuggestionFormatter().FormatSuggestion(1, suggestion)
+	if !strings.HasPrefix(withEmoji, "🔴 1. ") {
+		t.Errorf("Expected the severity emoji, got %q", withEmoji)
+	}
+
+	SetEmoji(false)
+	plain := NewSuggestionFormatter().FormatSuggestion(1, suggestion)
+	if !strings.HasPrefix(plain, "[!] 1. ") || !strings.Contains(plain, "\x1b[") {
+		t.Errorf("Expected the ASCII severity marker and still color, got %q", plain)
+	}
+	if got := GetSeverityIcon("LOW"); got != "[-]" {
+		t.Errorf("Expected the ASCII marker for LOW, got %q", got)
+	}
+	if got := WithEmoji("generated", "Generated Commit Message"); got != "Generated Commit Message" {
+		t.Errorf("Expected decorative emoji to be dropped, got %q", got)
+	}
+	if got := WithEmoji("warning", "Careful"); got != "! Careful" {
+		t.Errorf("Expected the ASCII stand-in, got %q", got)
+	}
+}
+
+func TestAnimatedMessageWithoutTerminal(t *testing.T) {
+	var buf bytes.Buffer
+	a := NewAnimatedMessage("Working", &buf)
+
+	a.Start()
+	time.Sleep(150 * time.Millisecond)
+	a.Stop()
+
+	if got := buf.String(); got != "Working\n" {
+		t.Errorf("Expected the message once without frames, got %q", got)
+	}
+}
+
+func TestPickModel(t *testing.T) {
+	models := []string{"llama3.2:3b", "qwen2.5-coder:7b"}
+	input := bufio.NewReader(strings.NewReader("2\ny\n"))
+
+	choice, err := PickModel("llama3:8b", models, input)
+	if err != nil || choice != "qwen2.5-coder:7b" {
+		t.Fatalf("Expected the second model, got %q, %v", choice, err)
+	}
+
+	// The same reader still holds the answer to the next question
+	if save, err := AskSaveModel(choice, input); err != nil || !save {
+		t.Errorf("Expected yes to saving, got %v, %v", save, err)
+	}
+
+	for _, answer := range []string{"", "0", "3", "qwen"} {
+		if _, err := PickModel("llama3:8b", models, strings.NewReader(answer+"\n")); err == nil {
+			t.Errorf("Expected error for answer %q", answer)
+		}
+	}
+}
@@ -0,0 +1,169 @@
+package ui
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// PickModel lists the installed models numbered from 1, explaining that
+// missing is not installed, and reads the chosen number from input.
+// Anything other than a listed number is an error.
+func PickModel(missing string, models []string, input io.Reader) (string, error) {
+	if len(models) == 0 {
+		return "", fmt.Errorf("no models are installed")
+	}
+
+	Print(formatModelList(missing, models))
+
+	answer, err := readLine(input)
+	if err != nil {
+		return "", err
+	}
+	n, err := strconv.Atoi(answer)
+	if err != nil || n < 1 || n > len(models) {
+		return "", fmt.Errorf("no model picked; expected a number from 1 to %d", len(models))
+	}
+	return models[n-1], nil
+}
+
+// AskSaveModel asks whether model should become the configured default
+func AskSaveModel(model string, input io.Reader) (bool, error) {
+	question := fmt.Sprintf("Save %s as the default model (ollama.model)?", model)
+	if IsNoColor() {
+		Print(fmt.Sprintf("%s [y/N]: ", question))
+	} else {
+		Print(fmt.Sprintf("%s %s: ", InfoStyle.Render(question), MutedStyle.Render("[y/N]")))
+	}
+
+	answer, err := readLine(input)
+	if err != nil {
+		return false, err
+	}
+	answer = strings.ToLower(answer)
+	return answer == "y" || answer == "yes", nil
+}
+
+// formatModelList formats the numbered list of models to pick from
+func formatModelList(missing string, models []string) string {
+	var b strings.Builder
+	heading := fmt.Sprintf("Model %s is not installed. Pick one of the installed models:", missing)
+	if IsNoColor() {
+		b.WriteString("\n" + heading + "\n")
+	} else {
+		b.WriteString("\n" + WarningStyle.Render(heading) + "\n")
+	}
+
+	for i, model := range models {
+		number := fmt.Sprintf("%3d)", i+1)
+		if !IsNoColor() {
+			number = MutedStyle.Render(number)
+		}
+		b.WriteString(fmt.Sprintf("%s %s\n", number, model))
+	}
+
+	b.WriteString(fmt.Sprintf("Model [1-%d]: ", len(models)))
+	return b.String()
+}
+
+// ToggleFiles lists labels numbered from 1, all selected, and lets the user
+// toggle entries by number ("2", "1 3" or "2-4") until they answer with an
+// empty line. It returns which entries are still selected.
+func ToggleFiles(labels []string, input io.Reader) ([]bool, error) {
+	reader, ok := input.(*bufio.Reader)
+	if !ok {
+		reader = bufio.NewReader(input)
+	}
+
+	selected := make([]bool, len(labels))
+	for i := range selected {
+		selected[i] = true
+	}
+
+	for {
+		Print(formatToggleList(labels, selected))
+
+		answer, err := readLine(reader)
+		if err != nil {
+			return nil, err
+		}
+		if answer == "" {
+			return selected, nil
+		}
+
+		numbers, err := parseToggles(answer, len(labels))
+		if err != nil {
+			ShowWarning(err.Error())
+			continue
+		}
+		for _, n := range numbers {
+			selected[n-1] = !selected[n-1]
+		}
+	}
+}
+
+// formatToggleList formats the numbered list of files with a mark on the
+// selected ones
+func formatToggleList(labels []string, selected []bool) string {
+	var b strings.Builder
+	heading := "Staged files to include in the commit:"
+	if IsNoColor() {
+		b.WriteString("\n" + heading + "\n")
+	} else {
+		b.WriteString("\n" + InfoStyle.Render(heading) + "\n")
+	}
+
+	for i, label := range labels {
+		number := fmt.Sprintf("%3d)", i+1)
+		if !IsNoColor() {
+			number = MutedStyle.Render(number)
+		}
+		mark := "[ ]"
+		if selected[i] {
+			mark = "[x]"
+		}
+		b.WriteString(fmt.Sprintf("%s %s %s\n", number, mark, label))
+	}
+
+	b.WriteString("Numbers to toggle, Enter to continue: ")
+	return b.String()
+}
+
+// parseToggles parses the numbers and ranges in answer, e.g. "1 3,5-7",
+// checking each is between 1 and count
+func parseToggles(answer string, count int) ([]int, error) {
+	var numbers []int
+	for _, field := range strings.FieldsFunc(answer, func(r rune) bool { return r == ' ' || r == ',' }) {
+		from, to, isRange := strings.Cut(field, "-")
+		if !isRange {
+			to = from
+		}
+
+		first, err1 := strconv.Atoi(from)
+		last, err2 := strconv.Atoi(to)
+		if err1 != nil || err2 != nil || first < 1 || last > count || first > last {
+			return nil, fmt.Errorf("%q is not a file number; expected numbers from 1 to %d", field, count)
+		}
+		for n := first; n <= last; n++ {
+			numbers = append(numbers, n)
+		}
+	}
+	return numbers, nil
+}
+
+// readLine reads one trimmed line of input. A *bufio.Reader is used as is,
+// so several questions can share one reader without losing buffered input.
+func readLine(input io.Reader) (string, error) {
+	reader, ok := input.(*bufio.Reader)
+	if !ok {
+		reader = bufio.NewReader(input)
+	}
+
+	line, err := reader.ReadString('\n')
+	if err != nil && err != io.EOF {
+		return "", err
+	}
+	return strings.TrimSpace(line), nil
+}
@@ -0,0 +1,45 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestParseToggles(t *testing.T) {
+	numbers, err := parseToggles("1 3,5-6", 6)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if got := fmt.Sprint(numbers); got != "[1 3 5 6]" {
+		t.Errorf("Expected [1 3 5 6], got %s", got)
+	}
+
+	for _, answer := range []string{"0", "7", "x", "4-2"} {
+		if _, err := parseToggles(answer, 6); err == nil {
+			t.Errorf("Expected %q to be rejected", answer)
+		}
+	}
+}
+
+func TestToggleFiles(t *testing.T) {
+	labels := []string{"main.go", "README.md", "go.sum"}
+
+	// An invalid answer is asked again; toggling twice restores a file
+	selected, err := ToggleFiles(labels, strings.NewReader("2 3\nnine\n3\n\n"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if got := fmt.Sprint(selected); got != "[true false true]" {
+		t.Errorf("Expected only README.md to be deselected, got %s", got)
+	}
+
+	// Running out of input keeps the current selection
+	selected, err = ToggleFiles(labels, strings.NewReader("1"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if got := fmt.Sprint(selected); got != "[false true true]" {
+		t.Errorf("Expected main.go to be deselected, got %s", got)
+	}
+}
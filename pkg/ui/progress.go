@@ -10,6 +10,33 @@ import (
 	"github.com/schollz/progressbar/v3"
 )
 
+// quiet routes status output to stderr and disables animations so that
+// stdout carries only the command's result
+var quiet bool
+
+// SetQuiet enables or disables quiet mode
+func SetQuiet(q bool) {
+	quiet = q
+}
+
+// IsQuiet reports whether quiet mode is enabled
+func IsQuiet() bool {
+	return quiet
+}
+
+// statusWriter returns where status and decoration output should go
+func statusWriter() io.Writer {
+	if quiet {
+		return os.Stderr
+	}
+	return os.Stdout
+}
+
+// Print writes decorative output to stdout, or to stderr in quiet mode
+func Print(s string) {
+	fmt.Fprint(statusWriter(), s)
+}
+
 // LoadingSpinner creates a beautiful loading spinner
 func NewLoadingSpinner(message string) *spinner.Spinner {
 	if IsNoColor() {
@@ -49,17 +76,27 @@ type StreamingSpinner struct {
 
 // Start begins the streaming animation
 func (s *StreamingSpinner) Start() {
+	if quiet {
+		return
+	}
 	if !s.started {
 		fmt.Print(InfoStyle.Render(s.message))
 		s.started = true
 	}
 }
 
-// Update adds a dot to the streaming animation
+// Update adds a dot to the streaming animation. When stdout is not a
+// terminal, e.g. in a CI log, only the message from Start is printed.
 func (s *StreamingSpinner) Update() {
+	if quiet {
+		return
+	}
 	if !s.started {
 		s.Start()
 	}
+	if !IsTerminal() {
+		return
+	}
 
 	if IsNoColor() {
 		fmt.Print(".")
@@ -73,10 +110,32 @@ func (s *StreamingSpinner) Update() {
 	}
 }
 
-// Stop finishes the streaming animation
+// UpdateMessage replaces the message, redrawing the line in place so it can
+// show progress such as a running word count. When stdout is not a terminal
+// the line can't be redrawn, so only the stored message changes.
+func (s *StreamingSpinner) UpdateMessage(message string) {
+	if message == s.message {
+		return
+	}
+	s.message = message
+	if quiet || !IsTerminal() {
+		return
+	}
+	if !s.started {
+		s.Start()
+		return
+	}
+
+	fmt.Print("\r\033[K" + InfoStyle.Render(message))
+	s.dots = 0
+}
+
+// Stop finishes the streaming animation. It is safe to call more than once,
+// so it can be deferred alongside an explicit Stop.
 func (s *StreamingSpinner) Stop() {
 	if s.started {
 		fmt.Println() // New line
+		s.started = false
 	}
 }
 
@@ -144,8 +203,14 @@ func NewAnimatedMessage(message string, writer io.Writer) *AnimatedMessage {
 	}
 }
 
-// Start begins the animation
+// Start begins the animation. When the writer is not a terminal the
+// message is printed once instead, so logs don't fill with frames.
 func (a *AnimatedMessage) Start() {
+	if !isTerminalWriter(a.writer) {
+		fmt.Fprintln(a.writer, a.message)
+		return
+	}
+
 	a.ticker = time.NewTicker(100 * time.Millisecond)
 
 	go func() {
@@ -185,8 +250,8 @@ func (a *AnimatedMessage) update() {
 
 // ShowSuccess displays a success message with animation
 func ShowSuccess(message string) {
-	if IsNoColor() {
-		fmt.Printf("✓ %s\n", message)
+	if IsNoColor() || quiet {
+		fmt.Fprintf(statusWriter(), "✓ %s\n", message)
 	} else {
 		fmt.Println(RenderSuccessBox(message))
 	}
@@ -194,8 +259,8 @@ func ShowSuccess(message string) {
 
 // ShowError displays an error message with animation
 func ShowError(message string) {
-	if IsNoColor() {
-		fmt.Printf("✗ %s\n", message)
+	if IsNoColor() || quiet {
+		fmt.Fprintf(statusWriter(), "✗ %s\n", message)
 	} else {
 		fmt.Println(RenderErrorBox(message))
 	}
@@ -203,8 +268,8 @@ func ShowError(message string) {
 
 // ShowWarning displays a warning message with animation
 func ShowWarning(message string) {
-	if IsNoColor() {
-		fmt.Printf("⚠ %s\n", message)
+	if IsNoColor() || quiet {
+		fmt.Fprintf(statusWriter(), "%s %s\n", Emoji("warning"), message)
 	} else {
 		fmt.Println(RenderWarningBox(message))
 	}
@@ -212,8 +277,8 @@ func ShowWarning(message string) {
 
 // ShowInfo displays an info message
 func ShowInfo(message string) {
-	if IsNoColor() {
-		fmt.Printf("ℹ %s\n", message)
+	if IsNoColor() || quiet {
+		fmt.Fprintf(statusWriter(), "ℹ %s\n", message)
 	} else {
 		fmt.Println(InfoStyle.Render("ℹ ") + BodyStyle.Render(message))
 	}
@@ -2,10 +2,32 @@ package ui
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"strings"
 
 	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+	"golang.org/x/term"
+)
+
+// Color modes accepted by SetColorMode
+const (
+	ColorAuto   = "auto"
+	ColorAlways = "always"
+	ColorNever  = "never"
+)
+
+// colorMode is the resolved --color setting
+var colorMode = ColorAuto
+
+const (
+	// defaultTerminalWidth is used when stdout is not a terminal
+	defaultTerminalWidth = 80
+	// maxSeparatorWidth caps separators on very wide terminals
+	maxSeparatorWidth = 100
+	// noColorSeparatorWidth keeps NO_COLOR output stable for piping
+	noColorSeparatorWidth = 60
 )
 
 var (
@@ -109,6 +131,11 @@ var (
 			Border(lipgloss.RoundedBorder()).
 			BorderForeground(adaptiveBorderColor)
 
+	// LocationStyle renders file:line anchors
+	LocationStyle = lipgloss.NewStyle().
+			Foreground(accentColor).
+			Underline(true)
+
 	CommitMessageStyle = lipgloss.NewStyle().
 				Foreground(adaptiveTextColor).
 				Padding(1).
@@ -161,25 +188,171 @@ func GetSeverityIcon(severity string) string {
 
 	switch strings.ToUpper(severity) {
 	case "HIGH":
-		return "🔴"
+		return Emoji("high")
 	case "MEDIUM":
-		return "🟡"
+		return Emoji("medium")
 	case "LOW":
-		return "🟢"
+		return Emoji("low")
 	default:
-		return "⚪"
+		return Emoji("severity")
+	}
+}
+
+// SetColorMode sets when output is colored: always, never, or auto, which
+// colors only when stdout is a terminal and NO_COLOR is unset
+func SetColorMode(mode string) error {
+	switch mode {
+	case ColorAuto, ColorAlways, ColorNever:
+	default:
+		return fmt.Errorf("invalid color mode %q, expected auto, always or never", mode)
+	}
+	colorMode = mode
+
+	// lipgloss detects the profile on its own; keep it in line with the mode
+	if IsNoColor() {
+		lipgloss.SetColorProfile(termenv.Ascii)
+	} else if mode == ColorAlways {
+		lipgloss.SetColorProfile(termenv.TrueColor)
 	}
+	return nil
 }
 
 // IsNoColor checks if color output should be disabled
 func IsNoColor() bool {
-	return os.Getenv("NO_COLOR") != ""
+	switch colorMode {
+	case ColorAlways:
+		return false
+	case ColorNever:
+		return true
+	}
+	return os.Getenv("NO_COLOR") != "" || !IsTerminal()
+}
+
+// TerminalWidth returns the width of the terminal attached to stdout,
+// defaulting to 80 columns when stdout is not a TTY
+func TerminalWidth() int {
+	width, _, err := term.GetSize(int(os.Stdout.Fd()))
+	if err != nil || width <= 0 {
+		return defaultTerminalWidth
+	}
+	return width
+}
+
+// IsTerminal reports whether stdout is attached to a terminal
+func IsTerminal() bool {
+	return term.IsTerminal(int(os.Stdout.Fd()))
+}
+
+// IsInteractive reports whether stdin is a terminal, so questions can be
+// answered
+func IsInteractive() bool {
+	return term.IsTerminal(int(os.Stdin.Fd()))
+}
+
+// isTerminalWriter reports whether w writes to a terminal
+func isTerminalWriter(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	return ok && term.IsTerminal(int(f.Fd()))
+}
+
+// ClearScreen clears the terminal and moves the cursor to the top left
+func ClearScreen() {
+	fmt.Print("\033[H\033[2J")
+}
+
+// SeparatorWidth returns the width formatters should use for separators.
+// NO_COLOR output uses a fixed width so piped output is reproducible.
+func SeparatorWidth() int {
+	if IsNoColor() {
+		return noColorSeparatorWidth
+	}
+
+	width := TerminalWidth()
+	if width > maxSeparatorWidth {
+		width = maxSeparatorWidth
+	}
+	return width
+}
+
+// renderWrapped renders text with style, word-wrapping it first so the
+// rendered block including borders and padding fits in width columns
+func renderWrapped(style lipgloss.Style, text string, width int) string {
+	inner := width - style.GetHorizontalFrameSize()
+	if inner < 1 {
+		inner = 1
+	}
+	return style.Render(wrapText(text, inner))
+}
+
+// wrapText word-wraps each line of text to width display columns. Existing
+// line breaks are kept, continuation lines keep the line's indentation (plus
+// two spaces under a "- " or "* " list item) and words longer than a line are
+// broken.
+func wrapText(text string, width int) string {
+	lines := strings.Split(text, "\n")
+	var wrapped []string
+
+	for _, line := range lines {
+		if lipgloss.Width(line) <= width {
+			wrapped = append(wrapped, line)
+			continue
+		}
+
+		trimmed := strings.TrimLeft(line, " ")
+		indent := line[:len(line)-len(trimmed)]
+		hanging := indent
+		if strings.HasPrefix(trimmed, "- ") || strings.HasPrefix(trimmed, "* ") {
+			hanging += "  "
+		}
+		if lipgloss.Width(hanging) >= width {
+			indent, hanging = "", ""
+		}
+
+		current := indent
+		empty := true
+		for _, word := range strings.Fields(trimmed) {
+			if !empty && lipgloss.Width(current)+1+lipgloss.Width(word) > width {
+				wrapped = append(wrapped, current)
+				current, empty = hanging, true
+			}
+			if !empty {
+				current += " "
+			}
+
+			// Break words that can't fit on a line of their own
+			for lipgloss.Width(current)+lipgloss.Width(word) > width {
+				room := width - lipgloss.Width(current)
+				head, tail := splitAtWidth(word, room)
+				wrapped = append(wrapped, current+head)
+				current, word = hanging, tail
+			}
+			current += word
+			empty = false
+		}
+		wrapped = append(wrapped, current)
+	}
+
+	return strings.Join(wrapped, "\n")
+}
+
+// splitAtWidth splits word after at most width display columns, keeping at
+// least one rune in the head
+func splitAtWidth(word string, width int) (head, tail string) {
+	used := 0
+	for i, r := range word {
+		w := lipgloss.Width(string(r))
+		if used+w > width && i > 0 {
+			return word[:i], word[i:]
+		}
+		used += w
+	}
+	return word, ""
 }
 
 // CreateSeparator creates a styled separator line
 func CreateSeparator(width int) string {
 	if width <= 0 {
-		width = 60
+		width = SeparatorWidth()
 	}
 	return MutedStyle.Render(strings.Repeat("─", width))
 }
@@ -187,7 +360,7 @@ func CreateSeparator(width int) string {
 // CreateDivider creates a thick divider
 func CreateDivider(width int) string {
 	if width <= 0 {
-		width = 60
+		width = SeparatorWidth()
 	}
 	return MutedStyle.Render(strings.Repeat("━", width))
 }
@@ -237,7 +410,7 @@ func RenderWarningBox(message string) string {
 		Foreground(adaptiveTextColor)
 
 	content := fmt.Sprintf("%s %s",
-		WarningStyle.Render("⚠"),
+		WarningStyle.Render(Emoji("warning")),
 		BodyStyle.Render(message))
 
 	return style.Render(content)
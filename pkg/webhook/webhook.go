@@ -0,0 +1,96 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Client posts JSON payloads to a webhook URL
+type Client struct {
+	httpClient *http.Client
+	maxRetries int
+	backoff    time.Duration
+}
+
+// NewClient creates a webhook client with a short timeout and a few retries
+func NewClient() *Client {
+	return &Client{
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+		maxRetries: 3,
+		backoff:    500 * time.Millisecond,
+	}
+}
+
+// Post sends payload as JSON to url, retrying on network errors and 5xx responses
+func (c *Client) Post(ctx context.Context, url string, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal webhook payload: %w", err)
+	}
+
+	var lastErr error
+	for i := 0; i < c.maxRetries; i++ {
+		lastErr = c.post(ctx, url, body)
+		if lastErr == nil {
+			return nil
+		}
+
+		// Don't retry on the last attempt or on client errors
+		if i == c.maxRetries-1 || !isRetryable(lastErr) {
+			break
+		}
+
+		// Linear backoff keeps the worst case well under the request timeout
+		select {
+		case <-time.After(time.Duration(i+1) * c.backoff):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	return lastErr
+}
+
+// post performs a single delivery attempt
+func (c *Client) post(ctx context.Context, url string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "gh-smart-commit")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to deliver webhook: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return &StatusError{StatusCode: resp.StatusCode}
+	}
+
+	return nil
+}
+
+// StatusError is returned when the webhook responds with a non-2xx status
+type StatusError struct {
+	StatusCode int
+}
+
+// Error implements the error interface
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("webhook responded with status %d", e.StatusCode)
+}
+
+// isRetryable reports whether a failed delivery is worth retrying
+func isRetryable(err error) bool {
+	if statusErr, ok := err.(*StatusError); ok {
+		return statusErr.StatusCode >= 500
+	}
+	return true
+}
@@ -0,0 +1,84 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type testPayload struct {
+	Event   string `json:"event"`
+	Message string `json:"message"`
+}
+
+func TestPost(t *testing.T) {
+	var received testPayload
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" {
+			t.Errorf("Expected POST method, got '%s'", r.Method)
+		}
+		if ct := r.Header.Get("Content-Type"); ct != "application/json" {
+			t.Errorf("Expected JSON content type, got '%s'", ct)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&received); err != nil {
+			t.Errorf("Failed to decode payload: %v", err)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	client := NewClient()
+	err := client.Post(context.Background(), server.URL, testPayload{Event: "commit-message", Message: "Fix bug"})
+	if err != nil {
+		t.Fatalf("Post failed: %v", err)
+	}
+
+	if received.Event != "commit-message" || received.Message != "Fix bug" {
+		t.Errorf("Unexpected payload received: %+v", received)
+	}
+}
+
+func TestPostRetriesServerErrors(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts < 2 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client := NewClient()
+	client.backoff = time.Millisecond
+
+	if err := client.Post(context.Background(), server.URL, testPayload{}); err != nil {
+		t.Fatalf("Expected retry to succeed, got %v", err)
+	}
+	if attempts != 2 {
+		t.Errorf("Expected 2 attempts, got %d", attempts)
+	}
+}
+
+func TestPostDoesNotRetryClientErrors(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	client := NewClient()
+	client.backoff = time.Millisecond
+
+	if err := client.Post(context.Background(), server.URL, testPayload{}); err == nil {
+		t.Error("Expected error for 400 response")
+	}
+	if attempts != 1 {
+		t.Errorf("Expected a single attempt, got %d", attempts)
+	}
+}